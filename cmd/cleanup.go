@@ -0,0 +1,29 @@
+package main
+
+import (
+	"io"
+
+	"github.com/apprenda/kuberang/pkg/config"
+	"github.com/apprenda/kuberang/pkg/kuberang"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdCleanup returns the cleanup command
+func NewCmdCleanup(cfg *config.Config, in io.Reader, out io.Writer) *cobra.Command {
+	var all, yes bool
+	cmd := &cobra.Command{
+		Use:   "cleanup",
+		Short: "remove the kuberang objects left behind by previous runs in the namespace",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signalContext()
+			defer stop()
+			if all {
+				return kuberang.CleanupAll(ctx, cfg, out, in, yes)
+			}
+			return kuberang.Cleanup(ctx, cfg, out)
+		},
+	}
+	cmd.Flags().BoolVar(&all, "all", false, "Remove the kuberang objects found in every namespace that can be read, after listing them and asking for confirmation.")
+	cmd.Flags().BoolVar(&yes, "yes", false, "Don't ask for confirmation before removing the objects found with --all.")
+	return cmd
+}
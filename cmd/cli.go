@@ -1,7 +1,10 @@
 package main
 
 import (
+	"errors"
 	"io"
+	"os"
+	"time"
 
 	"github.com/apprenda/kuberang/pkg/config"
 	"github.com/apprenda/kuberang/pkg/kuberang"
@@ -10,29 +13,152 @@ import (
 
 // NewKuberangCommand creates the kuberang command
 func NewKuberangCommand(version string, in io.Reader, out io.Writer) *cobra.Command {
+	// cfg is built from the flags of the command and its subcommands
+	cfg := &config.Config{}
+	var quiet, compact bool
 	cmd := &cobra.Command{
 		Use:   "kuberang",
 		Short: "kuberang tests your kubernetes cluster using kubectl",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return doCheckKubernetes()
+			return doCheckKubernetes(cfg, out)
+		},
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := applyConfigSources(cmd.Flags()); err != nil {
+				return err
+			}
+			if compact {
+				if cmd.Flags().Changed("output") && cfg.OutputFormat != kuberang.OutputCompact {
+					return errors.New("--compact and --output are mutually exclusive")
+				}
+				cfg.OutputFormat = kuberang.OutputCompact
+			}
+			// Surface failures as annotations when running in GitHub Actions
+			if !cmd.Flags().Changed("output") && !compact && !cfg.Plain && cfg.GroupBy == "" && os.Getenv("GITHUB_ACTIONS") == "true" {
+				cfg.OutputFormat = kuberang.OutputGitHub
+			}
+			if quiet {
+				if cfg.Verbosity > 0 {
+					return errors.New("--quiet and --verbose are mutually exclusive")
+				}
+				cfg.Verbosity = -1
+			}
+			return nil
 		},
 		SilenceUsage:  true,
 		SilenceErrors: true,
 	}
 
-	cmd.PersistentFlags().StringVar(&config.Kubeconfig, "kubeconfig", "", "Path to the kubeconfig file")
-	cmd.PersistentFlags().StringVarP(&config.Namespace, "namespace", "n", "",
+	cmd.PersistentFlags().String("config", "", "Path to a YAML file setting flags by name, e.g. \"namespace: kuberang\". Flags are also set by KUBERANG_ environment variables, e.g. KUBERANG_NAMESPACE. The command line takes precedence over the environment, which takes precedence over the file.")
+	cmd.PersistentFlags().StringVar(&cfg.Kubeconfig, "kubeconfig", "", "Path to the kubeconfig file")
+	cmd.PersistentFlags().StringVarP(&cfg.Namespace, "namespace", "n", "",
 		"Kubernetes namespace in which kuberang will operate. Defaults to 'default' if not specified.")
-	cmd.PersistentFlags().StringVar(&config.RegistryURL, "registry-url", "",
+	cmd.PersistentFlags().StringArrayVar(&cfg.KubectlArgs, "kubectl-arg", nil, "Extra global flag passed to every kubectl command, e.g. --kubectl-arg=--request-timeout=10s. Can be repeated.")
+	cmd.PersistentFlags().StringVar(&cfg.As, "as", "", "User to impersonate for every kubectl command.")
+	cmd.PersistentFlags().StringSliceVar(&cfg.AsGroups, "as-group", nil, "Group to impersonate for every kubectl command. Can be repeated.")
+	cmd.PersistentFlags().CountVarP(&cfg.Verbosity, "verbose", "v", "Print diagnostic output. Repeat for more detail: -v prints kubectl commands, -vv their output, -vvv retry and timing information.")
+	cmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Only print failures.")
+	cmd.PersistentFlags().StringVar(&cfg.RegistryURL, "registry-url", "",
 		"Override the default Docker Hub URL to use a local offline registry for required Docker images.")
-	cmd.Flags().BoolVar(&config.SkipCleanup, "skip-cleanup", false, "Don't clean up. Leave all deployed artifacts running on the cluster.")
-	cmd.Flags().BoolVar(&config.SkipDNSTests, "skip-dns-tests", false, "Don't test kubernetes DNS if none is deployed.")
-	cmd.Flags().BoolVar(&config.IgnorePodIPAccessibilityCheck, "ignore-pod-ip-accessibility-check", false, "Don't fail the smoke test if the pod IP accessibility check fails.")
+	cmd.Flags().BoolVar(&cfg.PauseBeforeCleanup, "pause-before-cleanup", false, "When a check failed, list the objects about to be deleted and wait for enter, or \"keep\" to leave them running. Has no effect without a terminal.")
+	cmd.Flags().DurationVar(&cfg.PauseTimeout, "pause-timeout", 5*time.Minute, "Time --pause-before-cleanup waits for an answer before cleaning up.")
+	cmd.Flags().BoolVar(&cfg.Repeat, "repeat", false, "Run the checks until interrupted, waiting --interval between runs. The wait grows after every failed run and is printed before every run.")
+	cmd.Flags().DurationVar(&cfg.RepeatInterval, "interval", time.Minute, "Time between two runs with --repeat, while runs succeed.")
+	cmd.Flags().Float64Var(&cfg.BackoffFactor, "backoff-factor", 2, "Factor applied to the time between two runs with --repeat after every failed run.")
+	cmd.Flags().DurationVar(&cfg.BackoffMax, "backoff-max", 15*time.Minute, "Maximum time between two runs with --repeat, however many runs failed.")
+	cmd.Flags().BoolVar(&cfg.ForceDelete, "force-delete", false, "Delete the test workloads at once, with --grace-period=0 --force, instead of waiting for their grace period.")
+	cmd.Flags().BoolVar(&cfg.NoPreClean, "no-pre-clean", false, "Don't delete existing kuberang objects at startup. Existing objects then fail the preconditions.")
+	cmd.PersistentFlags().StringVar(&cfg.ClusterDomain, "cluster-domain", "cluster.local", "DNS domain of the cluster.")
+	cmd.Flags().StringVar(&cfg.CACert, "ca-cert", "", "Path to a PEM CA bundle trusted by the HTTPS checks run from this node, in addition to the system trust store.")
+	cmd.Flags().BoolVar(&cfg.InsecureSkipVerify, "insecure-skip-verify", false, "Don't verify certificates in the HTTPS checks run from this node.")
+	cmd.Flags().StringVar(&cfg.NodeChecks, "node-checks", "ignored", "How to treat the checks accessing pods and the internet from this node (options \"required\"|\"ignored\"|\"off\")")
+	cmd.Flags().StringVar(&cfg.FromNode, "from-node", "", "Run the checks accessing pods and the internet from this node on the given cluster node instead, through a privileged host network pod.")
+	cmd.Flags().IntVar(&cfg.Parallelism, "parallelism", 10, "Number of nginx pods accessed concurrently from this node.")
+	cmd.Flags().BoolVar(&cfg.NodeDNSCheck, "node-dns-check", false, "Access the nginx service via its FQDN from this node. Only use on nodes configured to resolve cluster names, e.g. control plane nodes.")
+	cmd.Flags().StringVar(&cfg.CollectDiagnostics, "collect-diagnostics", "", "When a required check fails, write a support bundle to the given directory or .tar.gz file. Defaults to --output-dir when set.")
+	cmd.Flags().StringVar(&cfg.OutputDir, "output-dir", "", "Directory receiving the JSON report, the run metadata and, by default, every other artifact of the run. Created if missing.")
+	cmd.Flags().StringVar(&cfg.ResultConfigMap, "write-result-configmap", "", "Name of a ConfigMap of the namespace receiving the JSON result under the result.json key. Created or replaced.")
+	cmd.Flags().StringVar(&cfg.OTelEndpoint, "otel-endpoint", "", "OTLP/HTTP endpoint, e.g. http://collector:4318, receiving an OpenTelemetry trace of the run with a span per check and per kubectl invocation. Defaults to the standard OTEL_EXPORTER_OTLP_ENDPOINT variable.")
+	cmd.Flags().StringVar(&cfg.StatsDAddr, "statsd-addr", "", "StatsD host:port receiving, over UDP, a counter per check outcome, a timer per check duration and a success gauge for the run.")
+	cmd.Flags().StringVar(&cfg.StatsDPrefix, "statsd-prefix", "kuberang", "Prefix of the StatsD metric names.")
+	cmd.Flags().StringVar(&cfg.PromTextfile, "prom-textfile", "", "Path of a .prom file atomically rewritten after each run with the success and duration of every check, for the node_exporter textfile collector.")
+	cmd.Flags().BoolVar(&cfg.StatsDTags, "statsd-tags", false, "Tag the StatsD metrics with the check ID, DogStatsD style, instead of including it in the metric names.")
+	cmd.Flags().BoolVar(&cfg.PrePull, "pre-pull", false, "Pull the test images on every node before deploying the test workloads, reporting the pull latency of each node.")
+	cmd.Flags().IntVar(&cfg.MinNodes, "min-nodes", 0, "Fail before deploying anything if the cluster has fewer Ready schedulable nodes than this.")
+	cmd.Flags().Float64Var(&cfg.MinReadyFraction, "min-ready-fraction", 1, "Fraction of the expected nginx replicas that must become available for the checks to proceed. Replicas that never become ready are reported as warnings.")
+	cmd.Flags().StringSliceVar(&cfg.Nodes, "nodes", nil, "Comma-separated list of node names to which the test workloads will be restricted. The run fails if one of them cannot be targeted.")
+	cmd.Flags().StringVar(&cfg.NodeRole, "node-role", "", "Restrict the test workloads to nodes with the given role, as found in the node-role.kubernetes.io labels (e.g. worker).")
+	cmd.Flags().StringVar(&cfg.NodeLabel, "node-label", "", "Restrict the test workloads to nodes matching the given label selector.")
+	cmd.Flags().BoolVar(&cfg.IncludeControlPlane, "include-control-plane", false, "Run the test workloads on control-plane nodes and on nodes tainted NoSchedule or NoExecute as well, tolerating their taints.")
+	cmd.Flags().BoolVar(&cfg.RequireAllNodes, "require-all-nodes", false, "Expect an nginx pod on every schedulable node, including the ones that are NotReady.")
+	cmd.Flags().StringSliceVar(&cfg.ExcludeNodes, "exclude-nodes", nil, "Comma-separated list of node names that the test workloads must stay off. Takes precedence over --nodes.")
+	cmd.Flags().IntVar(&cfg.Sample, "sample", 0, "Repeat each pod connectivity check this many times and report the success ratio, flagging intermittent failures.")
+	cmd.Flags().Float64Var(&cfg.SampleMinRatio, "sample-min-ratio", 0, "Success ratio below which a sampled connectivity check fails. Checks that never succeed always fail.")
+	cmd.Flags().BoolVar(&cfg.Throughput, "throughput", false, "Measure the HTTP download throughput from BusyBox to every nginx pod.")
+	cmd.Flags().IntVar(&cfg.ThroughputSizeMB, "throughput-size-mb", 64, "Size in megabytes of the payload downloaded to measure throughput.")
+	cmd.Flags().StringVar(&cfg.Benchmark, "benchmark", "", "Issue HTTP requests against the nginx service from BusyBox and report throughput and latency percentiles, e.g. requests=500,concurrency=10.")
+	cmd.Flags().Lookup("benchmark").NoOptDefVal = kuberang.DefaultBenchmark
+	cmd.Flags().DurationVar(&cfg.BenchmarkMaxP95, "benchmark-max-p95", 0, "Fail the benchmark when its p95 latency is above this duration.")
+	cmd.Flags().StringVar(&cfg.DNSProbeImage, "dns-probe-image", "", "Image providing dig, used to check DNS lookups over TCP. The check is skipped when not set, as BusyBox cannot force TCP lookups.")
+	cmd.Flags().IntVar(&cfg.DNSStress, "dns-stress", 0, "Resolve the nginx service name, and an external name unless offline, this many times from BusyBox and report the failure rate and latency distribution.")
+	cmd.Flags().Lookup("dns-stress").NoOptDefVal = "300"
+	cmd.Flags().Float64Var(&cfg.DNSStressMaxFailureRate, "dns-stress-max-failure-rate", 0.01, "Fraction of failed lookups above which the DNS stress check fails.")
+	cmd.Flags().BoolVar(&cfg.ReadinessGateCheck, "readiness-gate-check", false, "Make an nginx pod fail its readiness probe and verify that it leaves the service endpoints, then restore it and verify that it returns.")
+	cmd.Flags().BoolVar(&cfg.CheckClockSkew, "check-clock-skew", false, "Compare the clock of every node, as seen from a probe pod, with the clock of this machine.")
+	cmd.Flags().DurationVar(&cfg.ClockSkewTolerance, "clock-skew-tolerance", 2*time.Second, "Largest clock skew allowed by --check-clock-skew.")
+	cmd.Flags().BoolVar(&cfg.CheckClockSpread, "check-clock-spread", false, "Compare the clocks of the nodes with each other, as seen from probe pods, and warn when they drift apart.")
+	cmd.Flags().DurationVar(&cfg.ClockSpreadThreshold, "clock-spread-threshold", 10*time.Second, "Largest difference between node clocks before --check-clock-spread warns.")
+	cmd.Flags().BoolVar(&cfg.LivenessCheck, "liveness-check", false, "Make a container fail its liveness probe and verify that the kubelet restarts it within a minute. Enabled by the full profile.")
+	cmd.Flags().BoolVar(&cfg.CheckRollout, "check-rollout", false, "Roll out a new template of the nginx deployment once the other checks are done, and verify that the rollout completes.")
+	cmd.Flags().StringVar(&cfg.DeploymentStrategy, "deployment-strategy", "", `Strategy of the nginx deployment used by --check-rollout (options "RollingUpdate"|"Recreate"). Defaults to the strategy set by kubectl.`)
+	cmd.Flags().BoolVar(&cfg.CheckCronJob, "check-cronjob", false, "Create a cronjob running every minute and wait up to 90 seconds for one of its jobs to succeed.")
+	cmd.Flags().BoolVar(&cfg.CheckSystemServices, "check-system-services", false, "Resolve and connect to the services listed with --system-services from BusyBox. Services that are not present are skipped.")
+	cmd.Flags().StringSliceVar(&cfg.SystemServices, "system-services", []string{"kube-dns:53", "metrics-server:443"}, "Comma-separated list of services probed by --check-system-services, as [namespace/]name:port. The namespace defaults to kube-system.")
+	cmd.Flags().StringSliceVar(&cfg.ForbiddenTaints, "forbidden-taints", nil, "Comma-separated list of taints, as key[=value][:effect], e.g. dedicated=gpu:NoSchedule. The run fails if a test pod was scheduled onto a node carrying one of them, or onto a cordoned node.")
+	cmd.Flags().BoolVar(&cfg.CheckNodeLocalDNS, "check-nodelocal-dns", false, "Detect NodeLocal DNSCache and resolve the nginx service name through it and through the cluster DNS service, telling a broken node-local cache apart from broken cluster DNS.")
+	cmd.Flags().StringArrayVar(&cfg.CustomProbes, "custom-probe", nil, "Shell command run in the BusyBox pod, passing when it exits with 0, e.g. --custom-probe='nslookup example.com'. Can be repeated.")
+	cmd.Flags().StringArrayVar(&cfg.ExpectOutputs, "expect-output", nil, "String the output of the --custom-probe at the same position must contain. Can be repeated.")
+	cmd.Flags().DurationVar(&cfg.CustomProbeTimeout, "custom-probe-timeout", 10*time.Second, "Time after which a --custom-probe is killed and fails. Failing probes are retried like the other checks.")
+	cmd.Flags().IntVar(&cfg.MaxTotalRetries, "max-total-retries", 0, "Maximum number of retries shared by all the checks of a run, after which the remaining checks are attempted once. 0 leaves the retries unbounded.")
+	cmd.Flags().BoolVar(&cfg.FailOnRetries, "fail-on-retries", false, "Report the checks that only succeeded after a retry as warnings instead of passing them.")
+	cmd.Flags().StringSliceVar(&cfg.FailOn, "fail-on", nil, "Comma-separated list of check IDs whose warnings fail the run, e.g. apiservices-available.")
+	cmd.Flags().BoolVar(&cfg.CheckCRD, "check-crd", false, "Create a throwaway CustomResourceDefinition, create and read back a custom resource, then delete both. Skipped without the privileges to create CustomResourceDefinitions.")
+	cmd.Flags().BoolVar(&cfg.HairpinCheck, "hairpin-check", false, "Access the nginx service from one of its own pods until a request is load-balanced back to it, checking the hairpin mode of the kubelet and the CNI. Requires --identify-backends. Enabled by the full profile along with --identify-backends.")
+	cmd.Flags().BoolVar(&cfg.EphemeralNamespace, "ephemeral-namespace", false, "Run in a namespace created for the run, and check that it is deleted in time afterwards.")
+	cmd.Flags().DurationVar(&cfg.NamespaceDeletionTimeout, "namespace-deletion-timeout", 2*time.Minute, "Time allowed for the ephemeral namespace to be deleted.")
+	cmd.Flags().BoolVar(&cfg.VerifyCleanup, "verify-cleanup", false, "After the cleanup, confirm that the nginx service, its endpoints and the pods of the run are gone, listing whatever lingers.")
+	cmd.Flags().DurationVar(&cfg.VerifyCleanupTimeout, "verify-cleanup-timeout", time.Minute, "Time allowed by --verify-cleanup for the objects to be gone.")
+	cmd.Flags().StringVar(&cfg.ExpectBusyboxDigest, "expect-busybox-digest", "", "Fail unless the BusyBox pod runs the image with this digest, e.g. sha256:...")
+	cmd.Flags().StringVar(&cfg.ExpectNginxDigest, "expect-nginx-digest", "", "Fail unless every nginx pod runs the image with this digest, e.g. sha256:...")
+	cmd.Flags().StringVar(&cfg.TargetService, "target-service", "", "Check the service IP, DNS name and endpoints of this existing service from BusyBox instead of deploying nginx.")
+	cmd.Flags().StringVar(&cfg.TargetNamespace, "target-namespace", "", "Namespace of the service given with --target-service. Defaults to the namespace kuberang operates in.")
+	cmd.Flags().BoolVar(&cfg.IdentifyBackends, "identify-backends", false, "Configure every nginx pod to answer with its own pod name, so that responses can be attributed to a backend.")
+	cmd.Flags().BoolVar(&cfg.ReportNodesWithoutPods, "report-nodes-without-pods", false, "List the schedulable nodes that did not receive an nginx pod.")
+	cmd.Flags().StringVar(&cfg.Profile, "profile", "standard", `Set of checks to run (options "standard"|"full"). The full profile adds variants such as services using a named targetPort or several ports, and the replacement of a deleted nginx pod.`)
+	cmd.Flags().StringVar(&cfg.ServiceAccount, "service-account", "default", "ServiceAccount the test pods run as, which must exist in the namespace.")
+	cmd.Flags().DurationVar(&cfg.ServiceAccountTimeout, "service-account-timeout", 0, "Time to wait for the ServiceAccount to be provisioned in a new namespace. At least 30s with --ephemeral-namespace.")
+	cmd.Flags().StringVar(&cfg.GroupBy, "group-by", "", `Nest the printed checks under what they pertain to (options "node"). Nodes whose checks all passed are collapsed to a single line.`)
+	cmd.Flags().BoolVar(&cfg.TUI, "tui", false, "List the checks as they complete, with colored marks, above a status line updated in place with a spinner and the count of checks done and failed. Falls back to the line by line output when not printing to a terminal.")
+	addSkipCleanupFlag(cmd.Flags(), cfg)
+	addNetworkFlags(cmd.Flags(), cfg)
+	addDNSFlags(cmd.Flags(), cfg)
+	addOutputFlags(cmd.Flags(), cfg)
+	cmd.Flags().BoolVar(&compact, "compact", false, "Print a single status line, e.g. \"kuberang: PASS (23/24, ctx=prod)\". Shorthand for --output compact.")
 	cmd.AddCommand(NewCmdVersion(out))
+	cmd.AddCommand(NewCmdScan(cfg, out))
+	cmd.AddCommand(NewCmdCleanup(cfg, in, out))
+	cmd.AddCommand(NewCmdDiagnose(cfg, out))
+	cmd.AddCommand(NewCmdPreflight(cfg, out))
+	cmd.AddCommand(NewCmdNetwork(cfg, out))
+	cmd.AddCommand(NewCmdDNS(cfg, out))
 
 	return cmd
 }
 
-func doCheckKubernetes() error {
-	return kuberang.CheckKubernetes()
+func doCheckKubernetes(cfg *config.Config, out io.Writer) error {
+	ctx, stop := signalContext()
+	defer stop()
+	if cfg.Repeat {
+		return kuberang.Repeat(ctx, cfg, out, os.Stderr)
+	}
+	return kuberang.CheckKubernetes(ctx, cfg, out)
 }
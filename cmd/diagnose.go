@@ -0,0 +1,29 @@
+package main
+
+import (
+	"errors"
+	"io"
+
+	"github.com/apprenda/kuberang/pkg/config"
+	"github.com/apprenda/kuberang/pkg/kuberang"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdDiagnose returns the diagnose command
+func NewCmdDiagnose(cfg *config.Config, out io.Writer) *cobra.Command {
+	var keep bool
+	cmd := &cobra.Command{
+		Use:   "diagnose <check-id>",
+		Short: "run a single check with verbose logging, extended retries, and the related events and logs",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("diagnose takes the ID of a single check")
+			}
+			ctx, stop := signalContext()
+			defer stop()
+			return kuberang.Diagnose(ctx, cfg, out, args[0], keep)
+		},
+	}
+	cmd.Flags().BoolVar(&keep, "keep", false, "Leave the resources created for the check running on the cluster.")
+	return cmd
+}
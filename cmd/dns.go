@@ -0,0 +1,34 @@
+package main
+
+import (
+	"errors"
+	"io"
+
+	"github.com/apprenda/kuberang/pkg/config"
+	"github.com/apprenda/kuberang/pkg/kuberang"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdDNS returns the dns command
+func NewCmdDNS(cfg *config.Config, out io.Writer) *cobra.Command {
+	var lookups int
+	var perNode bool
+	cmd := &cobra.Command{
+		Use:   "dns",
+		Short: "deploy BusyBox and run DNS diagnostics naming the failing part of the DNS chain",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if lookups < 1 {
+				return errors.New("--lookups must be at least 1")
+			}
+			ctx, stop := signalContext()
+			defer stop()
+			return kuberang.DNS(ctx, cfg, out, lookups, perNode)
+		},
+	}
+	cmd.Flags().IntVar(&lookups, "lookups", 20, "Number of lookups over which the DNS latency is measured.")
+	cmd.Flags().BoolVar(&perNode, "per-node", false, "Also resolve the test service from a probe pod on every node.")
+	addSkipCleanupFlag(cmd.Flags(), cfg)
+	addDNSFlags(cmd.Flags(), cfg)
+	addOutputFlags(cmd.Flags(), cfg)
+	return cmd
+}
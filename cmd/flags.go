@@ -0,0 +1,47 @@
+package main
+
+import (
+	"github.com/apprenda/kuberang/pkg/config"
+	"github.com/apprenda/kuberang/pkg/kuberang"
+	"github.com/spf13/pflag"
+)
+
+// The flags shared by the root command and the subcommands running part of
+// the checks are registered by the functions below, so that their defaults
+// and help read the same everywhere.
+
+// addSkipCleanupFlag registers the flag leaving the test workloads running
+func addSkipCleanupFlag(fs *pflag.FlagSet, cfg *config.Config) {
+	fs.BoolVar(&cfg.SkipCleanup, "skip-cleanup", false, "Don't clean up. Leave all deployed artifacts running on the cluster.")
+}
+
+// addOutputFlags registers the flags selecting how the results are printed
+func addOutputFlags(fs *pflag.FlagSet, cfg *config.Config) {
+	fs.StringVarP(&cfg.OutputFormat, "output", "o", "simple", `output format (options "simple"|"json"|"template"|"compact"|"github"|"teamcity"). Defaults to "github" when GITHUB_ACTIONS=true.`)
+	fs.BoolVar(&cfg.Plain, "plain", false, `Print every check on one line starting with a fixed-width status token ("PASS ", "FAIL ", "WARN ", "SKIP " or "IGNR "), followed by its ID and name, and every line of its detail prefixed with "DETAIL ". No colors are printed.`)
+	fs.StringVar(&cfg.Template, "template", "", "Go template used to render the results when using the template output format.")
+	fs.StringVar(&cfg.TemplateFile, "template-file", "", "Path to a Go template used to render the results when using the template output format.")
+}
+
+// addDNSFlags registers the flags of the DNS checks reaching beyond the
+// cluster DNS service
+func addDNSFlags(fs *pflag.FlagSet, cfg *config.Config) {
+	fs.BoolVar(&cfg.Offline, "offline", false, "Skip every check that needs access to the internet.")
+	fs.StringVar(&cfg.NodeLocalDNSIP, "nodelocal-dns-ip", kuberang.DefaultNodeLocalDNSIP, "Link-local address NodeLocal DNSCache listens on.")
+}
+
+// addNetworkFlags registers the flags of the checks of the pod network
+func addNetworkFlags(fs *pflag.FlagSet, cfg *config.Config) {
+	fs.BoolVar(&cfg.SkipDNSTests, "skip-dns-tests", false, "Don't test kubernetes DNS if none is deployed.")
+	fs.StringVar(&cfg.ProbePath, "probe-path", "/", "Path requested from nginx by the HTTP checks, e.g. /healthz for custom images.")
+	fs.StringVar(&cfg.ExpectBody, "expect-body", "", "Fail the HTTP checks against nginx when the response does not contain this string.")
+	fs.IntVar(&cfg.ExpectStatus, "expect-status", 200, "Status code the HTTP checks against nginx expect. The actual code is reported on mismatch.")
+	fs.StringVar(&cfg.PodSecurity, "pod-security", "", `Make the test pods comply with the given Pod Security Standard (options "restricted"), for namespaces enforcing it. The pods run as user 101 with the runtime default seccomp profile and no capabilities. Cannot be used with --from-node.`)
+	fs.StringVar(&cfg.DNSRequire, "dns-require", "both", `Forms of the nginx service name that must resolve from BusyBox (options "fqdn"|"short"|"both"). The failure of a form that is not required is reported but ignored, for clusters that restrict search domains on purpose.`)
+	fs.BoolVar(&cfg.IgnorePodIPAccessibilityCheck, "ignore-pod-ip-accessibility-check", false, "Don't fail the smoke test if the pod IP accessibility check fails.")
+	fs.StringSliceVar(&cfg.DNSServers, "dns-servers", nil, "Comma-separated list of DNS server IPs, e.g. the cluster DNS, the node resolver and an upstream, each queried from BusyBox. Servers giving different answers are reported as a warning.")
+	fs.StringVar(&cfg.DNSServersName, "dns-servers-name", "", "Name resolved against the servers given with --dns-servers. Defaults to the FQDN of the nginx service, which upstream servers cannot resolve.")
+	fs.BoolVar(&cfg.PerNodeServiceCheck, "per-node-service-check", false, "Access the nginx service from a probe pod on every node, pointing out the nodes whose pods reach nginx pods but not the service.")
+	fs.BoolVar(&cfg.ReversePathCheck, "reverse-path-check", false, "Start a listener on port 8123 in BusyBox and access it from an nginx pod, checking the pod network from nginx to BusyBox. Enabled by the full profile.")
+	fs.BoolVar(&cfg.CrossNode, "cross-node", false, "Schedule BusyBox and the nginx pods on different nodes and access an nginx pod on every other node, reporting the source and destination node of each path. Requires at least two nodes.")
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"io"
+
+	"github.com/apprenda/kuberang/pkg/config"
+	"github.com/apprenda/kuberang/pkg/kuberang"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdNetwork returns the network command
+func NewCmdNetwork(cfg *config.Config, out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "network",
+		Short: "deploy the test workloads and only check the pod network, DNS and the kubernetes service",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signalContext()
+			defer stop()
+			return kuberang.Network(ctx, cfg, out)
+		},
+	}
+	addSkipCleanupFlag(cmd.Flags(), cfg)
+	addNetworkFlags(cmd.Flags(), cfg)
+	addOutputFlags(cmd.Flags(), cfg)
+	return cmd
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"io"
+
+	"github.com/apprenda/kuberang/pkg/config"
+	"github.com/apprenda/kuberang/pkg/kuberang"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdPreflight returns the preflight command
+func NewCmdPreflight(cfg *config.Config, out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "preflight",
+		Short: "check that the cluster can be tested, without deploying anything",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signalContext()
+			defer stop()
+			return kuberang.Preflight(ctx, cfg, out)
+		},
+	}
+	return cmd
+}
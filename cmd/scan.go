@@ -0,0 +1,25 @@
+package main
+
+import (
+	"io"
+
+	"github.com/apprenda/kuberang/pkg/config"
+	"github.com/apprenda/kuberang/pkg/kuberang"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdScan returns the scan command
+func NewCmdScan(cfg *config.Config, out io.Writer) *cobra.Command {
+	var allNamespaces bool
+	cmd := &cobra.Command{
+		Use:   "scan",
+		Short: "list the kuberang objects left behind by previous runs",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signalContext()
+			defer stop()
+			return kuberang.Scan(ctx, cfg, out, allNamespaces)
+		},
+	}
+	cmd.Flags().BoolVar(&allNamespaces, "all-namespaces", true, "Look for kuberang objects in every namespace. When false, only the namespace given with --namespace is scanned.")
+	return cmd
+}
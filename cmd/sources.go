@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v2"
+)
+
+// envPrefix prefixes the environment variables setting flags, e.g.
+// KUBERANG_NAMESPACE for --namespace
+const envPrefix = "KUBERANG_"
+
+// Flags not given on the command line are taken from the environment, then
+// from the --config file. Both name the settings after the flags.
+
+// envVar returns the environment variable setting the flag
+func envVar(flag string) string {
+	return envPrefix + strings.ToUpper(strings.Replace(flag, "-", "_", -1))
+}
+
+// applyConfigSources sets every flag that was not given on the command line
+// from its environment variable, or else from the YAML file given with
+// --config, whose keys are flag names. Keys naming flags of other commands
+// are ignored, so that a single file serves every command.
+func applyConfigSources(fs *pflag.FlagSet) error {
+	var err error
+	fs.VisitAll(func(f *pflag.Flag) {
+		if err != nil || f.Changed || f.Name == "help" || f.Name == "config" {
+			return
+		}
+		if v, ok := os.LookupEnv(envVar(f.Name)); ok {
+			if serr := fs.Set(f.Name, v); serr != nil {
+				err = fmt.Errorf("invalid %s: %v", envVar(f.Name), serr)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	path, _ := fs.GetString("config")
+	if path == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading the config file: %v", err)
+	}
+	settings := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return fmt.Errorf("error parsing the config file %s: %v", path, err)
+	}
+	for name, value := range settings {
+		f := fs.Lookup(name)
+		if f == nil || f.Changed || name == "help" || name == "config" {
+			continue
+		}
+		// Lists set repeatable flags once per item
+		values, ok := value.([]interface{})
+		if !ok {
+			values = []interface{}{value}
+		}
+		for _, v := range values {
+			if err := fs.Set(name, fmt.Sprint(v)); err != nil {
+				return fmt.Errorf("invalid %s in the config file %s: %v", name, path, err)
+			}
+		}
+	}
+	return nil
+}
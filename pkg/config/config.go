@@ -1,5 +1,275 @@
 package config
 
+import (
+	"context"
+	"time"
+)
+
+// Config holds the settings of a run. The command line flags fill one in
+// for the CLI. Library users build their own, and concurrent runs each get
+// theirs.
+type Config struct {
+	// Kubeconfig is the path to the kubeconfig file
+	Kubeconfig string
+	// KubectlRunner runs kubectl with the given arguments and stdin, and
+	// returns its combined output. Defaults to the kubectl binary on the
+	// PATH; library users and tests set their own, e.g. a fake cluster.
+	KubectlRunner func(ctx context.Context, input []byte, args ...string) ([]byte, error)
+	// KubectlArgs are extra global flags passed to every kubectl command
+	KubectlArgs []string
+	// As and AsGroups are the user and groups kubectl impersonates
+	As       string
+	AsGroups []string
+	// Namespace where the kuberang tests will be executed
+	Namespace string
+	// RegistryURL to be used for downloading the container images used in the smoke test
+	RegistryURL string
+	// SkipCleanup determines whether the workloads should be cleaned up after the test
+	SkipCleanup bool
+	// ForceDelete deletes the test workloads without waiting for their
+	// grace period
+	ForceDelete bool
+	// NoPreClean skips the deletion of existing kuberang objects at startup
+	NoPreClean bool
+	// SkipDNSTests determines whether the DNS tests should be performed
+	SkipDNSTests bool
+	// IgnorePodIPAccessibilityCheck determines whether a failed pod IP accessibility check
+	// should fail the smoke test as a whole
+	IgnorePodIPAccessibilityCheck bool
+	// ClusterDomain is the DNS domain of the cluster
+	ClusterDomain string
+	// FromNode is the node from which the node-side checks are run, through
+	// a privileged host network pod, instead of this machine
+	FromNode string
+	// Parallelism is the number of node-side checks run concurrently
+	Parallelism int
+	// NodeDNSCheck determines whether the nginx service should be accessed via
+	// its FQDN from the machine running kuberang
+	NodeDNSCheck bool
+	// PrePull pulls the test images on every node before deploying the
+	// test workloads
+	PrePull bool
+	// CollectDiagnostics is the directory or .tar.gz path where a support
+	// bundle is written when a required check fails
+	CollectDiagnostics string
+	// CACert is the path to a CA bundle trusted by the HTTPS checks run from
+	// this node
+	CACert string
+	// InsecureSkipVerify disables certificate verification for the HTTPS
+	// checks run from this node
+	InsecureSkipVerify bool
+	// NodeChecks controls how the checks run from this node are treated
+	NodeChecks string
+	// OutputDir is the default parent of every artifact written by a run
+	OutputDir string
+	// MinNodes is the minimum number of Ready schedulable nodes the cluster
+	// must have for the checks to proceed
+	MinNodes int
+	// MinReadyFraction is the fraction of the expected nginx replicas that must
+	// become available for the checks to proceed
+	MinReadyFraction float64
+	// Profile selects the set of checks to run
+	Profile string
+	// Nodes restricts the test workloads to the named nodes
+	Nodes []string
+	// NodeRole restricts the test workloads to nodes with the given role
+	NodeRole string
+	// NodeLabel restricts the test workloads to nodes matching the label selector
+	NodeLabel string
+	// RequireAllNodes expects the test workloads to run on every schedulable
+	// node, including the ones that are not Ready
+	RequireAllNodes bool
+	// IncludeControlPlane runs the test workloads on control-plane nodes
+	// and on nodes tainted against scheduling, which are left out otherwise
+	IncludeControlPlane bool
+	// ExcludeNodes keeps the test workloads off the named nodes
+	ExcludeNodes []string
+	// Sample is the number of times each pod connectivity check is repeated
+	// to detect intermittent failures
+	Sample int
+	// SampleMinRatio is the success ratio below which a sampled check fails
+	SampleMinRatio float64
+	// Throughput measures the download throughput from every nginx pod
+	Throughput bool
+	// ThroughputSizeMB is the size of the payload downloaded to measure
+	// throughput
+	ThroughputSizeMB int
+	// Benchmark is the load issued against the nginx service, e.g.
+	// requests=500,concurrency=10. Empty disables the benchmark.
+	Benchmark string
+	// BenchmarkMaxP95 fails the benchmark when the p95 latency is above it
+	BenchmarkMaxP95 time.Duration
+	// DNSProbeImage is an image providing dig, used for the checks BusyBox
+	// cannot perform such as DNS lookups over TCP
+	DNSProbeImage string
+	// DNSStress is the number of lookups performed by the DNS stress check.
+	// Zero disables the check.
+	DNSStress int
+	// DNSStressMaxFailureRate is the fraction of failed lookups above which
+	// the DNS stress check fails
+	DNSStressMaxFailureRate float64
+	// Offline skips every check that needs access to the internet
+	Offline bool
+	// ReadinessGateCheck makes an nginx pod unready and back to verify that
+	// it leaves and returns to the service endpoints
+	ReadinessGateCheck bool
+	// CheckClockSkew compares the clock of every node with the local clock
+	CheckClockSkew bool
+	// ClockSkewTolerance is the largest clock skew allowed
+	ClockSkewTolerance time.Duration
+	// CheckClockSpread compares the clocks of the nodes with each other
+	CheckClockSpread bool
+	// ClockSpreadThreshold is the largest difference between node clocks
+	// before a warning is reported
+	ClockSpreadThreshold time.Duration
+	// LivenessCheck makes a container fail its liveness probe and checks
+	// that it is restarted. Always enabled with the full profile.
+	LivenessCheck bool
+	// CheckRollout triggers a rollout of the nginx deployment and waits for
+	// it to complete
+	CheckRollout bool
+	// DeploymentStrategy is the strategy of the nginx deployment used by the
+	// rollout check, left as is when empty
+	DeploymentStrategy string
+	// CheckCronJob waits for a cronjob to schedule a job that succeeds
+	CheckCronJob bool
+	// CheckSystemServices probes SystemServices from BusyBox
+	CheckSystemServices bool
+	// SystemServices are the in-cluster services probed by
+	// CheckSystemServices, as [namespace/]name:port
+	SystemServices []string
+	// DNSServers are the DNS servers against which DNSServersName is
+	// resolved from BusyBox
+	DNSServers []string
+	// DNSServersName is the name resolved against DNSServers, the nginx
+	// service FQDN when empty
+	DNSServersName string
+	// HairpinCheck accesses the nginx service from one of its own backends
+	HairpinCheck bool
+	// ReversePathCheck accesses BusyBox from an nginx pod, through a listener
+	// started in BusyBox for the check
+	ReversePathCheck bool
+	// ForbiddenTaints are the taints, as key[=value][:effect], of the nodes
+	// the test pods must not run on. When set, cordoned nodes are forbidden
+	// as well.
+	ForbiddenTaints []string
+	// CheckNodeLocalDNS resolves a name through NodeLocal DNSCache, when
+	// detected, and through the cluster DNS service. NodeLocalDNSIP is the
+	// link-local address the cache listens on.
+	CheckNodeLocalDNS bool
+	NodeLocalDNSIP    string
+	// NetworkOnly limits the run to the checks of the pod network
+	NetworkOnly bool
+	// FailOnRetries reports the checks that only succeeded after a retry as
+	// warnings
+	FailOnRetries bool
+	// MaxTotalRetries bounds the number of retries shared by all the checks
+	// of a run, 0 leaving them unbounded
+	MaxTotalRetries int
+	// FailOn lists the IDs of the checks whose warnings fail the run
+	FailOn []string
+	// ResultConfigMap is the name of the ConfigMap receiving the JSON result
+	ResultConfigMap string
+	// CheckCRD round-trips a custom resource through a throwaway
+	// CustomResourceDefinition
+	CheckCRD bool
+	// PerNodeServiceCheck accesses the nginx service from a pod on every node
+	PerNodeServiceCheck bool
+	// ProbePath is the path requested from nginx by the HTTP checks, whose
+	// response must contain ExpectBody when set
+	ProbePath  string
+	ExpectBody string
+	// ExpectStatus is the status the HTTP checks against nginx expect, any
+	// success when 0
+	ExpectStatus int
+	// PodSecurity is the Pod Security preset the test pods comply with,
+	// "restricted" or none
+	PodSecurity string
+	// DNSRequire declares which forms of the service name must resolve from
+	// a pod, "fqdn", "short" or "both"
+	DNSRequire string
+	// CrossNode schedules BusyBox and nginx on different nodes and accesses
+	// an nginx pod on every other node
+	CrossNode bool
+	// EphemeralNamespace runs kuberang in a namespace created for the run and
+	// deleted afterwards
+	EphemeralNamespace bool
+	// VerifyCleanup confirms that the service, its endpoints and the pods are
+	// gone within VerifyCleanupTimeout of the cleanup
+	VerifyCleanup        bool
+	VerifyCleanupTimeout time.Duration
+	// NamespaceDeletionTimeout bounds the wait for the ephemeral namespace to
+	// be deleted
+	NamespaceDeletionTimeout time.Duration
+	// ExpectBusyboxDigest and ExpectNginxDigest are the digests of the images
+	// the test pods are expected to run
+	ExpectBusyboxDigest string
+	ExpectNginxDigest   string
+	// TargetService is an existing service checked instead of nginx, in
+	// TargetNamespace or the configured namespace
+	TargetService   string
+	TargetNamespace string
+	// OTelEndpoint is the OTLP/HTTP endpoint receiving the trace of the run
+	OTelEndpoint string
+	// StatsDAddr is the host:port receiving the StatsD metrics of the run,
+	// named after StatsDPrefix. With StatsDTags, the checks are told apart
+	// with DogStatsD tags rather than in the metric names.
+	StatsDAddr   string
+	StatsDPrefix string
+	StatsDTags   bool
+	// CustomProbes are commands run in BusyBox, which must exit with 0 and
+	// print the ExpectOutputs at the same position, within
+	// CustomProbeTimeout
+	CustomProbes       []string
+	ExpectOutputs      []string
+	CustomProbeTimeout time.Duration
+	// PromTextfile is the path of the Prometheus textfile atomically
+	// rewritten with the metrics of each run
+	PromTextfile string
+	// IdentifyBackends configures every nginx pod to answer with its own pod name
+	IdentifyBackends bool
+	// ServiceAccount is the ServiceAccount the test pods run as
+	ServiceAccount string
+	// ServiceAccountTimeout bounds the wait for ServiceAccount to exist
+	ServiceAccountTimeout time.Duration
+	// PauseBeforeCleanup lets the user keep the test workloads of a failed
+	// run, waiting up to PauseTimeout for an answer
+	PauseBeforeCleanup bool
+	PauseTimeout       time.Duration
+	// Repeat runs the checks until interrupted, RepeatInterval apart. After a
+	// failed run the interval is multiplied by BackoffFactor, up to
+	// BackoffMax, until a run succeeds.
+	Repeat         bool
+	RepeatInterval time.Duration
+	BackoffFactor  float64
+	BackoffMax     time.Duration
+	// GroupBy nests the printed checks under what they pertain to
+	GroupBy string
+	// Plain prints every check on a single line starting with a fixed-width
+	// status token, for log scrapers
+	Plain bool
+	// TUI lists the checks as they complete above a status line updated in
+	// place, when printing to a terminal
+	TUI bool
+	// Verbosity controls how much diagnostic output is printed. Negative values
+	// only print failures, 1 prints kubectl commands, 2 adds their output and
+	// 3 adds retry and timing information.
+	Verbosity int
+	// OutputFormat is the format in which the results are printed
+	OutputFormat string
+	// Template is the inline Go template used by the template output format
+	Template string
+	// TemplateFile is the path to the Go template used by the template output format
+	TemplateFile string
+	// ReportNodesWithoutPods determines whether the nodes that did not receive
+	// an nginx pod should be listed
+	ReportNodesWithoutPods bool
+}
+
+// The package-level settings predate Config and are only read by Globals.
+//
+// Deprecated: build a Config instead. These variables will be removed in the
+// next release.
 var (
 	// Kubeconfig is the path to the kubeconfig file
 	Kubeconfig string
@@ -15,3 +285,19 @@ var (
 	// should fail the smoke test as a whole
 	IgnorePodIPAccessibilityCheck bool
 )
+
+// Globals returns a Config holding the deprecated package-level settings, for
+// callers that still set them. The settings added along with Config have no
+// package-level counterpart and keep their zero value.
+//
+// Deprecated: build a Config instead.
+func Globals() *Config {
+	return &Config{
+		Kubeconfig:                    Kubeconfig,
+		Namespace:                     Namespace,
+		RegistryURL:                   RegistryURL,
+		SkipCleanup:                   SkipCleanup,
+		SkipDNSTests:                  SkipDNSTests,
+		IgnorePodIPAccessibilityCheck: IgnorePodIPAccessibilityCheck,
+	}
+}
@@ -0,0 +1,45 @@
+package kuberang
+
+import (
+	"fmt"
+	"strings"
+)
+
+// checkAPIServices reports the registered APIs that are not available. These
+// are usually aggregated APIs, such as the metrics API, whose server is down.
+// Some clusters run without some aggregated APIs on purpose, so this is a
+// warning unless listed with --fail-on.
+func checkAPIServices(r *reporter) {
+	name := "Registered APIs available"
+	ko := r.kube.run("get", "apiservices", "-o", "json")
+	if !ko.Success {
+		r.skipped("apiservices-available", name, "APIServices are not visible")
+		return
+	}
+	if unavailable := unavailableAPIServices(ko.APIServices()); len(unavailable) > 0 {
+		r.warn("apiservices-available", name, strings.Join(unavailable, "\n")+"\n")
+		return
+	}
+	r.ok("apiservices-available", name)
+}
+
+// unavailableAPIServices describes the APIs whose Available condition is
+// false, along with the service backing them and the reason
+func unavailableAPIServices(services []APIService) []string {
+	unavailable := []string{}
+	for _, s := range services {
+		if s.Available == nil || s.Available.Status != "False" {
+			continue
+		}
+		line := s.Name
+		if s.Service != "" {
+			line += " (service " + s.Service + ")"
+		}
+		line += fmt.Sprintf(" is unavailable: %s", s.Available.Reason)
+		if s.Available.Message != "" {
+			line += ": " + s.Available.Message
+		}
+		unavailable = append(unavailable, line)
+	}
+	return unavailable
+}
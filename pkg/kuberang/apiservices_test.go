@@ -0,0 +1,16 @@
+package kuberang
+
+import "testing"
+
+func TestUnavailableAPIServices(t *testing.T) {
+	ko := KubeOutput{RawOut: []byte(`{"items": [
+		{"metadata": {"name": "v1."}, "spec": {"service": null}, "status": {"conditions": [{"type": "Available", "status": "True", "reason": "Local"}]}},
+		{"metadata": {"name": "v1beta1.metrics.k8s.io"}, "spec": {"service": {"namespace": "kube-system", "name": "metrics-server"}},
+		 "status": {"conditions": [{"type": "Available", "status": "False", "reason": "FailedDiscoveryCheck", "message": "the server is currently unable to handle the request"}]}}
+	]}`)}
+	unavailable := unavailableAPIServices(ko.APIServices())
+	expected := "v1beta1.metrics.k8s.io (service kube-system/metrics-server) is unavailable: FailedDiscoveryCheck: the server is currently unable to handle the request"
+	if len(unavailable) != 1 || unavailable[0] != expected {
+		t.Errorf("Unexpected unavailable APIs: %v", unavailable)
+	}
+}
@@ -0,0 +1,86 @@
+package kuberang
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+// Artifact is a file written by a run
+type Artifact struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// runMetadata describes a run and the artifacts it wrote to the output
+// directory
+type runMetadata struct {
+	TestID    int64      `json:"testID"`
+	Success   bool       `json:"success"`
+	StartTime time.Time  `json:"startTime"`
+	EndTime   time.Time  `json:"endTime"`
+	Artifacts []Artifact `json:"artifacts"`
+}
+
+// artifactPath returns the path given to an artifact flag, or the default
+// name within the output directory when the flag is not set. An empty string
+// is returned when neither is set.
+func artifactPath(cfg *config.Config, flagValue, defaultName string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	if cfg.OutputDir == "" {
+		return ""
+	}
+	return filepath.Join(cfg.OutputDir, defaultName)
+}
+
+// writeOutputDir writes the JSON report and the run metadata to the output
+// directory, and records them as artifacts of the run
+func writeOutputDir(dir string, result *CheckResult) error {
+	reportPath := filepath.Join(dir, "report.json")
+	metadataPath := filepath.Join(dir, "run-metadata.json")
+	result.Artifacts = append(result.Artifacts,
+		Artifact{Name: "report", Path: reportPath},
+		Artifact{Name: "run-metadata", Path: metadataPath},
+	)
+
+	report, err := json.MarshalIndent(result, "", "    ")
+	if err != nil {
+		return fmt.Errorf("error marshaling result: %v", err)
+	}
+	if err := ioutil.WriteFile(reportPath, report, 0644); err != nil {
+		return fmt.Errorf("error writing report: %v", err)
+	}
+
+	metadata, err := json.MarshalIndent(runMetadata{
+		TestID:    result.TestID,
+		Success:   result.Success,
+		StartTime: result.StartTime,
+		EndTime:   result.EndTime,
+		Artifacts: result.Artifacts,
+	}, "", "    ")
+	if err != nil {
+		return fmt.Errorf("error marshaling run metadata: %v", err)
+	}
+	if err := ioutil.WriteFile(metadataPath, metadata, 0644); err != nil {
+		return fmt.Errorf("error writing run metadata: %v", err)
+	}
+	return nil
+}
+
+// printArtifacts lists the artifacts written by the run
+func printArtifacts(out io.Writer, artifacts []Artifact) {
+	if len(artifacts) == 0 {
+		return
+	}
+	fmt.Fprintln(out, "Artifacts:")
+	for _, a := range artifacts {
+		fmt.Fprintf(out, "  %-14s %s\n", a.Name, a.Path)
+	}
+}
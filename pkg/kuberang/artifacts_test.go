@@ -0,0 +1,52 @@
+package kuberang
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+func TestArtifactPath(t *testing.T) {
+	cfg := &config.Config{}
+	if path := artifactPath(cfg, "", "report.xml"); path != "" {
+		t.Errorf("Expected no path without an output directory, got %s", path)
+	}
+	cfg.OutputDir = "out"
+	if path := artifactPath(cfg, "", "report.xml"); path != filepath.Join("out", "report.xml") {
+		t.Errorf("Expected the artifact to default to the output directory, got %s", path)
+	}
+	if path := artifactPath(cfg, "junit.xml", "report.xml"); path != "junit.xml" {
+		t.Errorf("Expected the flag to take precedence, got %s", path)
+	}
+}
+
+func TestWriteOutputDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kuberang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	result := sampleResult()
+	if err := writeOutputDir(dir, result); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, "run-metadata.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	metadata := runMetadata{}
+	if err := json.Unmarshal(b, &metadata); err != nil {
+		t.Fatal(err)
+	}
+	if metadata.TestID != result.TestID || len(metadata.Artifacts) != 2 || metadata.Artifacts[0].Path != filepath.Join(dir, "report.json") {
+		t.Errorf("Unexpected run metadata: %+v", metadata)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "report.json")); err != nil {
+		t.Errorf("Expected the report to be written: %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package kuberang
+
+import "fmt"
+
+// backendConfig is the nginx server configuration used to make each nginx pod
+// identifiable. The root path answers with the pod's hostname (its pod name),
+// while every other path is served from the default document root.
+const backendConfig = `server {
+    listen 80;
+    location = / {
+        default_type text/plain;
+        return 200 "$hostname\n";
+    }
+    location / {
+        root /usr/share/nginx/html;
+    }
+}
+`
+
+// createBackendConfigMap creates the ConfigMap holding the nginx configuration
+// that makes the nginx pods identifiable
+func createBackendConfigMap(r *reporter, w *workloads) bool {
+	if ko := r.kube.run("create", "configmap", w.ngConfigMap, "--from-literal=default.conf="+backendConfig); !ko.Success {
+		r.err("nginx-configmap", "Created Nginx backend identity ConfigMap", ko.CombinedOut)
+		return false
+	}
+	if ko := r.kube.run("label", "configmap", w.ngConfigMap, "app=kuberang-nginx", fmt.Sprintf("kuberang/testid=%d", w.testID)); !ko.Success {
+		r.err("nginx-configmap", "Created Nginx backend identity ConfigMap", ko.CombinedOut)
+		return false
+	}
+	r.ok("nginx-configmap", "Created Nginx backend identity ConfigMap")
+	return true
+}
+
+// identifiableBackendSpec adds the volume and container mount that replace
+// the nginx default server with backendConfig to the given pod spec
+func identifiableBackendSpec(podSpec, container map[string]interface{}, configMapName string) {
+	appendToList(podSpec, "volumes", map[string]interface{}{
+		"name": "kuberang-nginx-conf",
+		"configMap": map[string]interface{}{
+			"name": configMapName,
+		},
+	})
+	appendToList(container, "volumeMounts", map[string]interface{}{
+		"name":      "kuberang-nginx-conf",
+		"mountPath": "/etc/nginx/conf.d",
+		"readOnly":  true,
+	})
+}
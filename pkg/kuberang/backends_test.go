@@ -0,0 +1,77 @@
+package kuberang
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+func TestCreateBackendConfigMap(t *testing.T) {
+	defer func(c func(context.Context, []byte, ...string) ([]byte, error)) { kubectlCommand = c }(kubectlCommand)
+	calls := [][]string{}
+	fail := false
+	kubectlCommand = func(ctx context.Context, input []byte, args ...string) ([]byte, error) {
+		calls = append(calls, args)
+		if fail {
+			return []byte("error: configmaps \"kuberang-nginx-conf-1\" already exists\n"), errors.New("exit status 1")
+		}
+		return nil, nil
+	}
+	w := newWorkloads(&config.Config{IdentifyBackends: true}, 1)
+	result := &CheckResult{}
+	if !createBackendConfigMap(newReporter(ioutil.Discard, result), w) {
+		t.Fatal("Expected the ConfigMap to be created")
+	}
+	want := [][]string{
+		{"create", "configmap", "kuberang-nginx-conf-1", "--from-literal=default.conf=" + backendConfig},
+		{"label", "configmap", "kuberang-nginx-conf-1", "app=kuberang-nginx", "kuberang/testid=1"},
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("got kubectl calls %q, want %q", calls, want)
+	}
+	if len(result.Checks) != 1 || result.Checks[0].Status != StatusOK {
+		t.Errorf("Unexpected checks %+v", result.Checks)
+	}
+
+	// Not retried, and the label is not attempted
+	calls, fail = nil, true
+	result = &CheckResult{}
+	if createBackendConfigMap(newReporter(ioutil.Discard, result), w) || len(calls) != 1 {
+		t.Errorf("Expected the check to fail after a single kubectl call, got %q", calls)
+	}
+	if c := result.Checks[0]; c.Status != StatusError || !strings.Contains(c.Detail, "already exists") {
+		t.Errorf("Unexpected check %+v", c)
+	}
+}
+
+func TestIdentifiableBackendSpec(t *testing.T) {
+	spec, container := map[string]interface{}{}, map[string]interface{}{"name": "kuberang-nginx"}
+	identifiableBackendSpec(spec, container, "kuberang-nginx-conf-1")
+	volumes := spec["volumes"].([]interface{})
+	if len(volumes) != 1 || !reflect.DeepEqual(volumes[0], map[string]interface{}{
+		"name":      "kuberang-nginx-conf",
+		"configMap": map[string]interface{}{"name": "kuberang-nginx-conf-1"},
+	}) {
+		t.Errorf("Unexpected volumes %v", volumes)
+	}
+	mounts := container["volumeMounts"].([]interface{})
+	if len(mounts) != 1 || !reflect.DeepEqual(mounts[0], map[string]interface{}{
+		"name":      "kuberang-nginx-conf",
+		"mountPath": "/etc/nginx/conf.d",
+		"readOnly":  true,
+	}) {
+		t.Errorf("Unexpected volume mounts %v", mounts)
+	}
+
+	// The mount reaches the nginx container through the overrides
+	spec["containers"] = []interface{}{container}
+	overrides := podSpecOverrides(spec)
+	if len(overrides) != 1 || !strings.Contains(overrides[0], `"containers":[{"name":"kuberang-nginx","volumeMounts":[{"mountPath":"/etc/nginx/conf.d","name":"kuberang-nginx-conf","readOnly":true}]}]`) {
+		t.Errorf("Unexpected overrides %q", overrides)
+	}
+}
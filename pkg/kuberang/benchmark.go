@@ -0,0 +1,179 @@
+package kuberang
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultBenchmark is the benchmark run when --benchmark is given no value
+const DefaultBenchmark = "requests=500,concurrency=10"
+
+// benchmarkSpec is the load generated by the benchmark
+type benchmarkSpec struct {
+	requests    int
+	concurrency int
+}
+
+// BenchmarkResult is the outcome of the HTTP benchmark against the nginx
+// service. Latencies are in milliseconds.
+type BenchmarkResult struct {
+	Requests          int     `json:"requests"`
+	Concurrency       int     `json:"concurrency"`
+	Errors            int     `json:"errors"`
+	RequestsPerSecond float64 `json:"requestsPerSecond"`
+	P50Ms             float64 `json:"p50Ms"`
+	P95Ms             float64 `json:"p95Ms"`
+	P99Ms             float64 `json:"p99Ms"`
+}
+
+// parseBenchmarkSpec parses the value of --benchmark, e.g.
+// requests=500,concurrency=10
+func parseBenchmarkSpec(s string) (benchmarkSpec, error) {
+	spec := benchmarkSpec{requests: 500, concurrency: 10}
+	for _, field := range strings.Split(s, ",") {
+		kv := strings.SplitN(strings.TrimSpace(field), "=", 2)
+		if len(kv) != 2 {
+			return spec, fmt.Errorf("Invalid --benchmark setting %q, expected key=value", field)
+		}
+		n, err := strconv.Atoi(kv[1])
+		if err != nil || n <= 0 {
+			return spec, fmt.Errorf("Invalid --benchmark value %q, expected a positive number", kv[1])
+		}
+		switch kv[0] {
+		case "requests":
+			spec.requests = n
+		case "concurrency":
+			spec.concurrency = n
+		default:
+			return spec, fmt.Errorf("Unknown --benchmark setting %q", kv[0])
+		}
+	}
+	if spec.concurrency > spec.requests {
+		spec.concurrency = spec.requests
+	}
+	return spec, nil
+}
+
+// benchmarkScript returns the shell script run in the BusyBox pod. Each of
+// the concurrent workers prints one line per request with its latency in
+// nanoseconds, and the whole run is bracketed by start and end timestamps.
+func benchmarkScript(spec benchmarkSpec, url string) string {
+	var b bytes.Buffer
+	b.WriteString("echo start $(date +%s%N); ")
+	for i := 0; i < spec.concurrency; i++ {
+		n := spec.requests / spec.concurrency
+		if i < spec.requests%spec.concurrency {
+			n++
+		}
+		fmt.Fprintf(&b, "(for i in $(seq %d); do s=$(date +%%s%%N); if wget -q -T %s -O /dev/null %s; then echo ok $(($(date +%%s%%N)-s)); else echo err; fi; done) & ", n, wgetTimeoutSeconds, url)
+	}
+	b.WriteString("wait; echo end $(date +%s%N)")
+	return b.String()
+}
+
+// parseBenchmarkOutput turns the output of the benchmark script into a
+// result. An error is returned if the output holds no usable timings.
+func parseBenchmarkOutput(spec benchmarkSpec, out string) (*BenchmarkResult, error) {
+	t := parseTimings(out)
+	if len(t.latencies) == 0 || t.end <= t.start {
+		return nil, fmt.Errorf("The benchmark output holds no usable timings:\n%s", out)
+	}
+	return &BenchmarkResult{
+		Requests:          spec.requests,
+		Concurrency:       spec.concurrency,
+		Errors:            t.errors,
+		P50Ms:             percentile(t.latencies, 50),
+		P95Ms:             percentile(t.latencies, 95),
+		P99Ms:             percentile(t.latencies, 99),
+		RequestsPerSecond: float64(len(t.latencies)) / time.Duration(t.end-t.start).Seconds(),
+	}, nil
+}
+
+// timings are the outcomes printed by the scripts timing operations in the
+// BusyBox pod: "ok <nanoseconds>" or "err" for every operation, optionally
+// bracketed by "start <timestamp>" and "end <timestamp>"
+type timings struct {
+	// latencies of the successful operations in milliseconds, sorted
+	latencies  []float64
+	errors     int
+	start, end int64
+}
+
+// parseTimings parses the output of a timing script. Lines that do not hold
+// a usable number, e.g. when date does not support nanoseconds, are skipped.
+func parseTimings(out string) timings {
+	t := timings{latencies: []float64{}}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "err" {
+			t.errors++
+			continue
+		}
+		if len(fields) != 2 {
+			continue
+		}
+		ns, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "ok":
+			t.latencies = append(t.latencies, float64(ns)/float64(time.Millisecond))
+		case "start":
+			t.start = ns
+		case "end":
+			t.end = ns
+		}
+	}
+	sort.Float64s(t.latencies)
+	return t
+}
+
+// percentile returns the nearest-rank percentile of the sorted values
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// checkBenchmark issues the configured load against the nginx service from
+// the BusyBox pod, records the numbers in the result and fails when the p95
+// latency is above --benchmark-max-p95
+func checkBenchmark(r *reporter, busyboxPodName, serviceIP string) bool {
+	name := "Benchmarked Nginx service at " + serviceIP + " from BusyBox"
+	spec, _ := parseBenchmarkSpec(r.cfg.Benchmark)
+	ko := r.kube.run("exec", busyboxPodName, "--", "sh", "-c", benchmarkScript(spec, "http://"+serviceIP))
+	if !ko.Success {
+		r.err("benchmark", name, ko.CombinedOut)
+		return false
+	}
+	result, err := parseBenchmarkOutput(spec, ko.CombinedOut)
+	if err != nil {
+		r.err("benchmark", name, err.Error()+"\n")
+		return false
+	}
+	r.result.Benchmark = result
+	name = fmt.Sprintf("%s: %.1f req/s, p50 %.1fms, p95 %.1fms, p99 %.1fms, %d errors",
+		name, result.RequestsPerSecond, result.P50Ms, result.P95Ms, result.P99Ms, result.Errors)
+	maxP95 := float64(r.cfg.BenchmarkMaxP95) / float64(time.Millisecond)
+	switch {
+	case maxP95 > 0 && result.P95Ms > maxP95:
+		r.err("benchmark", name, fmt.Sprintf("p95 latency %.1fms is above the %v threshold\n", result.P95Ms, r.cfg.BenchmarkMaxP95))
+		return false
+	case result.Errors > 0:
+		r.warn("benchmark", name, fmt.Sprintf("%d of %d requests failed\n", result.Errors, result.Requests))
+	default:
+		r.ok("benchmark", name)
+	}
+	return true
+}
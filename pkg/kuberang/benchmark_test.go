@@ -0,0 +1,39 @@
+package kuberang
+
+import "testing"
+
+func TestParseBenchmarkSpec(t *testing.T) {
+	spec, err := parseBenchmarkSpec("requests=100,concurrency=4")
+	if err != nil || spec.requests != 100 || spec.concurrency != 4 {
+		t.Errorf("Unexpected spec %+v (%v)", spec, err)
+	}
+	if spec, _ := parseBenchmarkSpec("requests=3,concurrency=10"); spec.concurrency != 3 {
+		t.Errorf("Expected concurrency to be capped by the number of requests, got %d", spec.concurrency)
+	}
+	for _, s := range []string{"requests", "requests=0", "duration=10"} {
+		if _, err := parseBenchmarkSpec(s); err == nil {
+			t.Errorf("Expected %q to be rejected", s)
+		}
+	}
+}
+
+func TestParseBenchmarkOutput(t *testing.T) {
+	out := `start 1000000000
+ok 1000000
+ok 3000000
+err
+ok 2000000
+ok 4000000
+end 3000000000
+`
+	result, err := parseBenchmarkOutput(benchmarkSpec{requests: 5, concurrency: 2}, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Errors != 1 || result.P50Ms != 2 || result.P95Ms != 4 || result.P99Ms != 4 || result.RequestsPerSecond != 2 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if _, err := parseBenchmarkOutput(benchmarkSpec{requests: 1, concurrency: 1}, "start %s%N\nok x\nend %s%N\n"); err == nil {
+		t.Errorf("Expected output without timings to be rejected")
+	}
+}
@@ -0,0 +1,155 @@
+package kuberang
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/apprenda/kuberang/pkg/config"
+	"github.com/apprenda/kuberang/pkg/util"
+)
+
+// CleanupAll removes the kuberang objects left behind in every namespace the
+// user can read, along with the namespaces created by --ephemeral-namespace
+// and the CustomResourceDefinitions created by --check-crd. Only the objects
+// carrying the test ID label are removed, so that other objects named after
+// kuberang are left alone. The objects are
+// listed grouped by namespace, and removed once confirmed on in unless yes is
+// set. Namespaces that cannot be read are reported and left alone.
+func CleanupAll(ctx context.Context, cfg *config.Config, out io.Writer, in io.Reader, yes bool) error {
+	k := newKubectl(ctx, cfg)
+	ko := k.run("get", "namespaces", "-o", "json")
+	if !ko.Success {
+		return fmt.Errorf("Failed to list namespaces: %s", strings.TrimSpace(ko.CombinedOut))
+	}
+	leftovers := []Object{}
+	unreadable := []string{}
+	for _, ns := range ko.Objects() {
+		if _, ok := ns.Labels[testIDLabel]; ok && isEphemeralNamespace(ns.Name) {
+			// Deleting the namespace removes everything in it
+			leftovers = append(leftovers, ns)
+			continue
+		}
+		objects, err := findTestObjects(k, "--namespace="+ns.Name)
+		if err != nil {
+			unreadable = append(unreadable, ns.Name)
+			continue
+		}
+		for _, o := range objects {
+			if !o.Owned {
+				leftovers = append(leftovers, o)
+			}
+		}
+	}
+	if crds := k.run("get", "customresourcedefinitions", "-l", testIDLabel, "-o", "json"); crds.Success {
+		leftovers = append(leftovers, crds.Objects()...)
+	} else {
+		unreadable = append(unreadable, "CustomResourceDefinitions")
+	}
+	if len(unreadable) > 0 {
+		util.PrettyPrintWarn(out, "Could not read namespace(s) %s", strings.Join(unreadable, ", "))
+	}
+	if len(leftovers) == 0 {
+		fmt.Fprintln(out, "No kuberang objects found")
+		return nil
+	}
+
+	sort.Sort(byNamespace(leftovers))
+	printObjectsByNamespace(out, leftovers)
+	if !yes && !confirmed(out, in, fmt.Sprintf("Delete these %d objects?", len(leftovers))) {
+		return errors.New("Cleanup aborted")
+	}
+
+	removed, failed := 0, 0
+	for _, o := range leftovers {
+		ref := strings.ToLower(o.Kind) + "/" + o.Name
+		args := []string{"delete", "--ignore-not-found=true", ref}
+		if o.Namespace != "" {
+			args = append(args, "--namespace="+o.Namespace)
+			ref = o.Namespace + "/" + ref
+		}
+		if ko := k.run(args...); ko.Success {
+			util.PrettyPrintOk(out, "Deleted %s", ref)
+			removed++
+		} else {
+			util.PrettyPrintErr(out, "Deleted %s", ref)
+			printFailureDetail(out, ko.CombinedOut)
+			failed++
+		}
+	}
+	namespaces := namespacesOf(leftovers)
+	if containsString(namespaces, "") {
+		namespaces = namespaces[1:]
+	}
+	fmt.Fprintf(out, "Deleted %d of %d kuberang objects in %d namespace(s), %d failure(s)\n", removed, len(leftovers), len(namespaces), failed)
+	if failed > 0 {
+		return fmt.Errorf("Failed to remove %d kuberang objects", failed)
+	}
+	return nil
+}
+
+// byNamespace sorts objects by namespace, kind and name, the cluster-scoped
+// objects first
+type byNamespace []Object
+
+func (s byNamespace) Len() int      { return len(s) }
+func (s byNamespace) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byNamespace) Less(i, j int) bool {
+	a, b := s[i], s[j]
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	if a.Kind != b.Kind {
+		return a.Kind < b.Kind
+	}
+	return a.Name < b.Name
+}
+
+// namespacesOf returns the namespaces of the objects, in order of appearance
+func namespacesOf(objects []Object) []string {
+	namespaces := []string{}
+	for _, o := range objects {
+		if !containsString(namespaces, o.Namespace) {
+			namespaces = append(namespaces, o.Namespace)
+		}
+	}
+	return namespaces
+}
+
+// printObjectsByNamespace prints the sorted objects under a heading for each
+// namespace, and the cluster-scoped ones under a heading of their own
+func printObjectsByNamespace(out io.Writer, objects []Object) {
+	for _, ns := range namespacesOf(objects) {
+		if ns == "" {
+			fmt.Fprintln(out, "Cluster-scoped:")
+		} else {
+			fmt.Fprintf(out, "Namespace %s:\n", ns)
+		}
+		w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "  KIND\tNAME\tAGE")
+		for _, o := range objects {
+			if o.Namespace == ns {
+				fmt.Fprintf(w, "  %s\t%s\t%s\n", o.Kind, o.Name, shortDuration(time.Since(o.Created)))
+			}
+		}
+		w.Flush()
+	}
+}
+
+// confirmed asks the question and returns true if the answer read from in
+// is yes
+func confirmed(out io.Writer, in io.Reader, question string) bool {
+	fmt.Fprintf(out, "%s [y/N] ", question)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package kuberang
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+func TestByNamespace(t *testing.T) {
+	objects := []Object{
+		{Namespace: "team-b", Kind: "Service", Name: "kuberang-nginx-1"},
+		{Namespace: "team-a", Kind: "Service", Name: "kuberang-nginx-2"},
+		{Namespace: "team-b", Kind: "Deployment", Name: "kuberang-nginx"},
+		{Namespace: "team-a", Kind: "Deployment", Name: "kuberang-busybox"},
+	}
+	sort.Sort(byNamespace(objects))
+	got := []string{}
+	for _, o := range objects {
+		got = append(got, o.Namespace+"/"+o.Kind+"/"+o.Name)
+	}
+	want := "team-a/Deployment/kuberang-busybox team-a/Service/kuberang-nginx-2 team-b/Deployment/kuberang-nginx team-b/Service/kuberang-nginx-1"
+	if strings.Join(got, " ") != want {
+		t.Errorf("got %v, want %s", got, want)
+	}
+	if ns := namespacesOf(objects); strings.Join(ns, ",") != "team-a,team-b" {
+		t.Errorf("namespacesOf returned %v", ns)
+	}
+}
+
+func TestConfirmed(t *testing.T) {
+	tests := map[string]bool{
+		"y\n":    true,
+		"YES\n":  true,
+		"n\n":    false,
+		"\n":     false,
+		"":       false,
+		"maybe ": false,
+	}
+	for answer, want := range tests {
+		if got := confirmed(&bytes.Buffer{}, strings.NewReader(answer), "Delete?"); got != want {
+			t.Errorf("confirmed(%q) = %v, want %v", answer, got, want)
+		}
+	}
+}
+
+func TestCleanupAll(t *testing.T) {
+	defer func(c func(context.Context, []byte, ...string) ([]byte, error)) { kubectlCommand = c }(kubectlCommand)
+	deleted := []string{}
+	kubectlCommand = func(ctx context.Context, input []byte, args ...string) ([]byte, error) {
+		command := strings.Join(args, " ")
+		switch {
+		case strings.HasPrefix(command, "get namespaces"):
+			return []byte(`{"items": [{"kind": "Namespace", "metadata": {"name": "team-a"}}, {"kind": "Namespace", "metadata": {"name": "kuberang-1500000000", "labels": {"kuberang/testid": "1500000000"}}}, {"kind": "Namespace", "metadata": {"name": "kuberang-1600000000"}}, {"kind": "Namespace", "metadata": {"name": "kuberang-system"}}]}`), nil
+		case strings.HasPrefix(command, "get customresourcedefinitions -l kuberang/testid"):
+			return []byte(`{"items": [{"kind": "CustomResourceDefinition", "metadata": {"name": "kuberang1500000000s.smoketest.kuberang.io"}}]}`), nil
+		case strings.HasPrefix(command, "get ") && strings.Contains(command, "--namespace=team-a") && strings.Contains(command, "-l kuberang/testid"):
+			return []byte(`{"items": [{"kind": "Service", "metadata": {"name": "kuberang-nginx-1", "namespace": "team-a", "labels": {"kuberang/testid": "1"}}}]}`), nil
+		case strings.HasPrefix(command, "get ") && strings.Contains(command, "--namespace=team-a"):
+			// A deployment of the user named after kuberang, without the
+			// test ID label
+			return []byte(`{"items": [{"kind": "Service", "metadata": {"name": "kuberang-nginx-1", "namespace": "team-a", "labels": {"kuberang/testid": "1"}}}, {"kind": "Deployment", "metadata": {"name": "kuberang-foo", "namespace": "team-a"}}]}`), nil
+		case strings.HasPrefix(command, "get "):
+			return []byte(`{"items": []}`), nil
+		case strings.HasPrefix(command, "delete "):
+			deleted = append(deleted, command)
+			return nil, nil
+		}
+		t.Errorf("Unexpected kubectl arguments %q", args)
+		return nil, errors.New("exit status 1")
+	}
+	if err := CleanupAll(context.Background(), &config.Config{}, &bytes.Buffer{}, strings.NewReader(""), true); err != nil {
+		t.Fatalf("CleanupAll returned %v", err)
+	}
+	want := []string{
+		"delete --ignore-not-found=true customresourcedefinition/kuberang1500000000s.smoketest.kuberang.io",
+		"delete --ignore-not-found=true namespace/kuberang-1500000000",
+		"delete --ignore-not-found=true service/kuberang-nginx-1 --namespace=team-a",
+	}
+	if !reflect.DeepEqual(deleted, want) {
+		t.Errorf("got deletions %q, want %q", deleted, want)
+	}
+}
+
+func TestIsEphemeralNamespace(t *testing.T) {
+	tests := map[string]bool{
+		"kuberang-1500000000": true,
+		"kuberang-system":     false,
+		"kuberang-":           false,
+		"team-a":              false,
+	}
+	for name, want := range tests {
+		if got := isEphemeralNamespace(name); got != want {
+			t.Errorf("isEphemeralNamespace(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package kuberang
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ClockSkewResult is the clock offset of a node relative to this machine
+type ClockSkewResult struct {
+	Node   string `json:"node"`
+	SkewMs int64  `json:"skewMs"`
+}
+
+// checkClockSkew compares the clock of every node under test, as seen from
+// its probe pod, against the clock of this machine. The local time is taken
+// halfway through the exec to make up for its latency.
+func checkClockSkew(r *reporter, w *workloads) bool {
+	success := true
+	for _, node := range w.nodes {
+		name := "Clock of node " + node + " in sync with this machine"
+		skew, err := nodeClockOffset(r.kube, w, node)
+		r.onNode(node)
+		if err != nil {
+			r.err("clock-skew", name, err.Error())
+			success = false
+			continue
+		}
+		r.result.ClockSkew = append(r.result.ClockSkew, ClockSkewResult{Node: node, SkewMs: int64(skew / time.Millisecond)})
+		name = fmt.Sprintf("%s (skew %v)", name, skew)
+		if absDuration(skew) > r.cfg.ClockSkewTolerance {
+			r.err("clock-skew", name, fmt.Sprintf("Clock skew of %v exceeds the %v tolerance\n", skew, r.cfg.ClockSkewTolerance))
+			success = false
+		} else {
+			r.ok("clock-skew", name)
+		}
+	}
+	return success
+}
+
+// nodeClockOffset returns the offset of the clock of the node, as seen from
+// its probe pod, relative to the clock of this machine. The local time is
+// taken halfway through the exec to make up for its round trip.
+func nodeClockOffset(k *kubectl, w *workloads, node string) (time.Duration, error) {
+	before := time.Now()
+	ko := k.run("exec", w.probePods[node], "--", "date", "+%s%N")
+	after := time.Now()
+	if !ko.Success {
+		return 0, errors.New(ko.CombinedOut)
+	}
+	podTime, err := parsePodClock(ko.CombinedOut)
+	if err != nil {
+		return 0, fmt.Errorf("%v\n", err)
+	}
+	return podTime.Sub(before.Add(after.Sub(before) / 2)), nil
+}
+
+// checkClockSpread measures the clock of every node under test in quick
+// succession and warns when the spread between any two nodes is above the
+// threshold, naming the nodes with the earliest and latest clocks
+func checkClockSpread(r *reporter, w *workloads) {
+	name := "Node clocks consistent across the cluster"
+	offsets := map[string]time.Duration{}
+	for _, node := range w.nodes {
+		offset, err := nodeClockOffset(r.kube, w, node)
+		if err != nil {
+			r.warn("clock-spread", name, "Could not read the clock of node "+node+": "+err.Error())
+			return
+		}
+		offsets[node] = offset
+	}
+	earliest, latest, spread := clockSpread(w.nodes, offsets)
+	name = fmt.Sprintf("%s (spread %v)", name, spread)
+	if spread > r.cfg.ClockSpreadThreshold {
+		r.warn("clock-spread", name, fmt.Sprintf("Clock of node %s is %v ahead of node %s, above the %v threshold\n", latest, spread, earliest, r.cfg.ClockSpreadThreshold))
+		return
+	}
+	r.ok("clock-spread", name)
+}
+
+// clockSpread returns the nodes with the earliest and latest clocks along
+// with the difference between them
+func clockSpread(nodes []string, offsets map[string]time.Duration) (earliest, latest string, spread time.Duration) {
+	for _, node := range nodes {
+		if earliest == "" || offsets[node] < offsets[earliest] {
+			earliest = node
+		}
+		if latest == "" || offsets[node] > offsets[latest] {
+			latest = node
+		}
+	}
+	return earliest, latest, offsets[latest] - offsets[earliest]
+}
+
+// parsePodClock parses the output of `date +%s%N`. Versions of date that
+// don't support %N leave it, or a bare N, in the output, in which case
+// only the seconds are used.
+func parsePodClock(out string) (time.Time, error) {
+	s := strings.TrimSpace(out)
+	if ns, err := strconv.ParseInt(s, 10, 64); err == nil && len(s) > 10 {
+		return time.Unix(0, ns), nil
+	}
+	s = strings.TrimRight(s, "%N")
+	secs, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Unexpected date output %q", out)
+	}
+	return time.Unix(secs, 0), nil
+}
+
+// absDuration returns the absolute value of the duration
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
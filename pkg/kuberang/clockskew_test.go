@@ -0,0 +1,35 @@
+package kuberang
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePodClock(t *testing.T) {
+	tests := map[string]time.Time{
+		"1476913134123456789\n": time.Unix(0, 1476913134123456789),
+		"1476913134N\n":         time.Unix(1476913134, 0),
+		"1476913134%N\n":        time.Unix(1476913134, 0),
+	}
+	for out, expected := range tests {
+		parsed, err := parsePodClock(out)
+		if err != nil || !parsed.Equal(expected) {
+			t.Errorf("Expected %q to parse as %v, got %v (%v)", out, expected, parsed, err)
+		}
+	}
+	if _, err := parsePodClock("date: invalid format"); err == nil {
+		t.Errorf("Expected garbage to be rejected")
+	}
+}
+
+func TestClockSpread(t *testing.T) {
+	offsets := map[string]time.Duration{
+		"node1": 2 * time.Second,
+		"node2": -3 * time.Second,
+		"node3": 500 * time.Millisecond,
+	}
+	earliest, latest, spread := clockSpread([]string{"node1", "node2", "node3"}, offsets)
+	if earliest != "node2" || latest != "node1" || spread != 5*time.Second {
+		t.Errorf("Unexpected spread: %s to %s, %v", earliest, latest, spread)
+	}
+}
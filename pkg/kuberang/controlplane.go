@@ -0,0 +1,100 @@
+package kuberang
+
+import (
+	"strings"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+// controlPlaneRoles are the node-role labels of the control-plane nodes
+var controlPlaneRoles = []string{
+	"node-role.kubernetes.io/control-plane",
+	"node-role.kubernetes.io/master",
+}
+
+// nodeConditionTaintPrefix is the key prefix of the taints the node
+// lifecycle controller adds for the conditions of a node, such as NotReady
+const nodeConditionTaintPrefix = "node.kubernetes.io/"
+
+// isControlPlane returns true if the node is labeled as a control-plane node
+// or carries a taint keeping pods from being scheduled or running on it. The
+// taints reflecting the conditions of the node do not count, so that a
+// NotReady worker is not mistaken for a control-plane node.
+func isControlPlane(n Node) bool {
+	for _, role := range controlPlaneRoles {
+		if _, ok := n.Labels[role]; ok {
+			return true
+		}
+	}
+	for _, t := range n.Taints {
+		if strings.HasPrefix(t.Key, nodeConditionTaintPrefix) {
+			continue
+		}
+		if t.Effect == "NoSchedule" || t.Effect == "NoExecute" {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptsTestPods returns true if the node is not cordoned, and is not a
+// control-plane node unless --include-control-plane is set
+func acceptsTestPods(cfg *config.Config, n Node) bool {
+	return !n.Unschedulable && (cfg.IncludeControlPlane || !isControlPlane(n))
+}
+
+// controlPlaneNodes returns the names of the uncordoned nodes left out of
+// the checks for being control-plane nodes
+func controlPlaneNodes(cfg *config.Config, ko KubeOutput) []string {
+	excluded := []string{}
+	if cfg.IncludeControlPlane {
+		return excluded
+	}
+	for _, n := range ko.Nodes() {
+		if !n.Unschedulable && isControlPlane(n) {
+			excluded = append(excluded, n.Name)
+		}
+	}
+	return excluded
+}
+
+// nodeTolerations returns the tolerations of the taints of the selected
+// nodes, so that the test pods can run on the ones included with
+// --include-control-plane
+func nodeTolerations(cfg *config.Config, ko KubeOutput, selected []string) []interface{} {
+	tolerations := []interface{}{}
+	if !cfg.IncludeControlPlane {
+		return tolerations
+	}
+	isSelected := map[string]bool{}
+	for _, name := range selected {
+		isSelected[name] = true
+	}
+	seen := map[Taint]bool{}
+	for _, n := range ko.Nodes() {
+		if !isSelected[n.Name] {
+			continue
+		}
+		for _, t := range n.Taints {
+			t.Value = ""
+			if seen[t] {
+				continue
+			}
+			seen[t] = true
+			tolerations = append(tolerations, map[string]interface{}{
+				"key":      t.Key,
+				"operator": "Exists",
+				"effect":   t.Effect,
+			})
+		}
+	}
+	return tolerations
+}
+
+// tolerationsSpec lets the pod run on the tainted nodes selected for the
+// checks
+func tolerationsSpec(spec map[string]interface{}, w *workloads) {
+	if len(w.tolerations) > 0 {
+		spec["tolerations"] = w.tolerations
+	}
+}
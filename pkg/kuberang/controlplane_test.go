@@ -0,0 +1,85 @@
+package kuberang
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+const sampleControlPlaneNodes = `{"items": [
+	{"metadata": {"name": "cp1", "labels": {"node-role.kubernetes.io/control-plane": ""}},
+	 "spec": {"taints": [{"key": "node-role.kubernetes.io/control-plane", "effect": "NoSchedule"}]}},
+	{"metadata": {"name": "master1", "labels": {"node-role.kubernetes.io/master": ""}}},
+	{"metadata": {"name": "gpu1"},
+	 "spec": {"taints": [{"key": "gpu", "value": "true", "effect": "NoExecute"}]}},
+	{"metadata": {"name": "worker1"},
+	 "spec": {"taints": [{"key": "spot", "effect": "PreferNoSchedule"}]}},
+	{"metadata": {"name": "worker2"}}
+]}`
+
+func TestControlPlaneNodes(t *testing.T) {
+	ko := KubeOutput{Success: true, RawOut: []byte(sampleControlPlaneNodes)}
+	cfg := &config.Config{RequireAllNodes: true}
+
+	if count := ko.NodeCountFor(cfg); count != 2 {
+		t.Errorf("Expected 2 nodes to accept the test workloads, got %d", count)
+	}
+	if excluded := controlPlaneNodes(cfg, ko); !reflect.DeepEqual(excluded, []string{"cp1", "master1", "gpu1"}) {
+		t.Errorf("Unexpected excluded nodes %v", excluded)
+	}
+	nodes, err := selectNodes(cfg, ko, nil)
+	if err != nil || !reflect.DeepEqual(nodes, []string{"worker1", "worker2"}) {
+		t.Errorf("Unexpected selected nodes %v (%v)", nodes, err)
+	}
+	if tolerations := nodeTolerations(cfg, ko, nodes); len(tolerations) != 0 {
+		t.Errorf("Expected no tolerations, got %v", tolerations)
+	}
+
+	cfg.IncludeControlPlane = true
+	if count := ko.NodeCountFor(cfg); count != 5 {
+		t.Errorf("Expected every node to accept the test workloads, got %d", count)
+	}
+	if excluded := controlPlaneNodes(cfg, ko); len(excluded) != 0 {
+		t.Errorf("Expected no excluded nodes, got %v", excluded)
+	}
+	expected := []interface{}{
+		map[string]interface{}{"key": "node-role.kubernetes.io/control-plane", "operator": "Exists", "effect": "NoSchedule"},
+		map[string]interface{}{"key": "gpu", "operator": "Exists", "effect": "NoExecute"},
+	}
+	if tolerations := nodeTolerations(cfg, ko, []string{"cp1", "master1", "gpu1"}); !reflect.DeepEqual(tolerations, expected) {
+		t.Errorf("Unexpected tolerations %v", tolerations)
+	}
+}
+
+func TestNotReadyNodeIsNotControlPlane(t *testing.T) {
+	ko := KubeOutput{Success: true, RawOut: []byte(`{"items": [
+	{"metadata": {"name": "worker1"},
+	 "spec": {"taints": [{"key": "node.kubernetes.io/not-ready", "effect": "NoSchedule"}, {"key": "node.kubernetes.io/unreachable", "effect": "NoExecute"}]},
+	 "status": {"conditions": [{"type": "Ready", "status": "False"}]}},
+	{"metadata": {"name": "worker2"}, "status": {"conditions": [{"type": "Ready", "status": "True"}]}}
+]}`)}
+	cfg := &config.Config{RequireAllNodes: true}
+	if excluded := controlPlaneNodes(cfg, ko); len(excluded) != 0 {
+		t.Errorf("Expected the NotReady node not to be taken for a control-plane node, got %v", excluded)
+	}
+	nodes, err := selectNodes(cfg, ko, nil)
+	if err != nil || !reflect.DeepEqual(nodes, []string{"worker1", "worker2"}) {
+		t.Errorf("Expected --require-all-nodes to select the NotReady node, got %v (%v)", nodes, err)
+	}
+}
+
+func TestDaemonSetsTolerateControlPlane(t *testing.T) {
+	ko := KubeOutput{Success: true, RawOut: []byte(sampleControlPlaneNodes)}
+	cfg := &config.Config{IncludeControlPlane: true}
+	w := newWorkloads(cfg, 1)
+	w.nodes = []string{"cp1", "worker2"}
+	w.tolerations = nodeTolerations(cfg, ko, w.nodes)
+	for _, ds := range []map[string]interface{}{nodeProbeDaemonSet(w), prePullDaemonSet(w)} {
+		template := ds["spec"].(map[string]interface{})["template"].(map[string]interface{})
+		spec := template["spec"].(map[string]interface{})
+		if !reflect.DeepEqual(spec["tolerations"], w.tolerations) || len(w.tolerations) != 1 {
+			t.Errorf("Expected DaemonSet %v to tolerate %v, got %v", ds["metadata"].(map[string]interface{})["name"], w.tolerations, spec["tolerations"])
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package kuberang
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// crdGroup is the API group of the throwaway CustomResourceDefinitions
+	crdGroup = "smoketest.kuberang.io"
+	// crdTimeout bounds the wait for the CustomResourceDefinition to be
+	// established and its resource to be served
+	crdTimeout = 60 * time.Second
+)
+
+// crdNames returns the plural and kind of the throwaway custom resource
+// of the run, along with the name of its CustomResourceDefinition
+func crdNames(w *workloads) (plural, kind, name string) {
+	plural = fmt.Sprintf("kuberang%ds", w.testID)
+	kind = fmt.Sprintf("Kuberang%d", w.testID)
+	return plural, kind, plural + "." + crdGroup
+}
+
+// checkCRD creates a CustomResourceDefinition unique to the run, waits for it
+// to be established, then creates a custom resource and reads it back.
+// Creating CustomResourceDefinitions requires cluster-wide privileges, so the
+// check is skipped when they are missing.
+func checkCRD(r *reporter, w *workloads) bool {
+	name := "Created and read back a custom resource"
+	plural, _, crd := crdNames(w)
+	manifest, _ := json.Marshal(crdManifest(w))
+	ko := r.kube.runWithInput(manifest, "create", "-f", "-")
+	if !ko.Success && isForbidden(ko.CombinedOut) {
+		r.skipped("crd-round-trip", name, "insufficient privileges for CRD check")
+		return true
+	}
+	if !ko.Success {
+		r.err("crd-round-trip", name, ko.CombinedOut)
+		return false
+	}
+	w.crdDeployed = true
+
+	start := time.Now()
+	established := false
+	for !established && time.Since(start) < crdTimeout {
+		if ko = r.kube.run("get", "customresourcedefinition", crd, "-o", "json"); ko.Success {
+			established = isConditionTrue(ko.StatusConditions(), "Established")
+		}
+		if !established && !sleep(r.ctx, 1*time.Second) {
+			break
+		}
+	}
+	if !established {
+		r.err("crd-round-trip", name, fmt.Sprintf("CustomResourceDefinition %s not established after %v\n%s", crd, crdTimeout, ko.CombinedOut))
+		return false
+	}
+
+	// kubectl only learns about the new resource once discovery is
+	// refreshed, so the custom resource creation is retried
+	manifest, _ = json.Marshal(customResourceManifest(w))
+	for time.Since(start) < crdTimeout {
+		if ko = r.kube.runWithInput(manifest, "create", "-f", "-"); ko.Success {
+			break
+		}
+		if !sleep(r.ctx, 2*time.Second) {
+			break
+		}
+	}
+	if !ko.Success {
+		r.err("crd-round-trip", name, "Failed to create a custom resource, check the discovery of the API server\n"+ko.CombinedOut)
+		return false
+	}
+	if ko = r.kube.run("get", plural+"."+crdGroup, w.customResource, "-o", "json"); !ko.Success || !strings.Contains(ko.CombinedOut, fmt.Sprintf("%d", w.testID)) {
+		r.err("crd-round-trip", name, "Failed to read back the custom resource\n"+ko.CombinedOut)
+		return false
+	}
+	r.ok("crd-round-trip", fmt.Sprintf("%s in %v", name, time.Since(start)))
+	return true
+}
+
+// removeCRD deletes the custom resource, if it was created at all, and the
+// CustomResourceDefinition
+func removeCRD(r *reporter, w *workloads) {
+	plural, _, crd := crdNames(w)
+	runDelete(r.kube, plural+"."+crdGroup, w.customResource, "--ignore-not-found")
+	if ko := runDelete(r.kube, "customresourcedefinition", crd); ko.Success {
+		r.ok("cleanup-crd", "Removed CustomResourceDefinition")
+	} else {
+		r.err("cleanup-crd", "Removed CustomResourceDefinition", ko.CombinedOut)
+	}
+}
+
+// isForbidden returns true if the kubectl output reports missing privileges
+func isForbidden(out string) bool {
+	return strings.Contains(out, "Forbidden") || strings.Contains(out, "forbidden")
+}
+
+// crdManifest returns the CustomResourceDefinition of the run, accepting any
+// content in its namespaced custom resources
+func crdManifest(w *workloads) map[string]interface{} {
+	plural, kind, name := crdNames(w)
+	return map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata": map[string]interface{}{
+			"name": name,
+			"labels": map[string]interface{}{
+				"app":             "kuberang-crd",
+				"kuberang/testid": fmt.Sprintf("%d", w.testID),
+			},
+		},
+		"spec": map[string]interface{}{
+			"group": crdGroup,
+			"scope": "Namespaced",
+			"names": map[string]interface{}{
+				"plural":   plural,
+				"singular": strings.ToLower(kind),
+				"kind":     kind,
+			},
+			"versions": []interface{}{
+				map[string]interface{}{
+					"name":    "v1",
+					"served":  true,
+					"storage": true,
+					"schema": map[string]interface{}{
+						"openAPIV3Schema": map[string]interface{}{
+							"type":                                 "object",
+							"x-kubernetes-preserve-unknown-fields": true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// customResourceManifest returns the custom resource of the run
+func customResourceManifest(w *workloads) map[string]interface{} {
+	_, kind, _ := crdNames(w)
+	return map[string]interface{}{
+		"apiVersion": crdGroup + "/v1",
+		"kind":       kind,
+		"metadata": map[string]interface{}{
+			"name": w.customResource,
+		},
+		"spec": map[string]interface{}{
+			"testID": fmt.Sprintf("%d", w.testID),
+		},
+	}
+}
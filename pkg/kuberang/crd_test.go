@@ -0,0 +1,18 @@
+package kuberang
+
+import (
+	"testing"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+func TestCRDNames(t *testing.T) {
+	w := newWorkloads(&config.Config{}, 42)
+	plural, kind, name := crdNames(w)
+	if plural != "kuberang42s" || kind != "Kuberang42" || name != "kuberang42s.smoketest.kuberang.io" {
+		t.Errorf("Unexpected names: %s %s %s", plural, kind, name)
+	}
+	if cr := customResourceManifest(w); cr["apiVersion"] != "smoketest.kuberang.io/v1" || cr["kind"] != kind {
+		t.Errorf("Custom resource does not match its definition: %v", cr)
+	}
+}
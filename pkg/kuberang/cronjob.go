@@ -0,0 +1,101 @@
+package kuberang
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// cronJobTimeout bounds the wait for a job of the cronjob to succeed. The
+// cronjob runs every minute, so its first job may take a minute to start.
+const cronJobTimeout = 90 * time.Second
+
+// checkCronJob creates a cronjob running every minute and waits for one of
+// the jobs it schedules to succeed
+func checkCronJob(r *reporter, w *workloads) bool {
+	name := fmt.Sprintf("CronJob scheduled a job that succeeded (timeout %v)", cronJobTimeout)
+	manifest, _ := json.Marshal(cronJobManifest(w))
+	if ko := r.kube.runWithInput(manifest, "create", "-f", "-"); !ko.Success {
+		r.err("cronjob-scheduled", name, ko.CombinedOut)
+		return false
+	}
+	w.cronJobDeployed = true
+	r.print(0, printLine, "Waiting up to %v for the cronjob to schedule a job...", cronJobTimeout)
+
+	start := time.Now()
+	for time.Since(start) < cronJobTimeout {
+		if ko := r.kube.run("get", "jobs", "-l", w.labels("kuberang-cron"), "-o", "json"); ko.Success {
+			if jobs := ko.SucceededJobs(); len(jobs) > 0 {
+				r.ok("cronjob-scheduled", fmt.Sprintf("%s after %v", name, time.Since(start)))
+				return true
+			}
+		}
+		if !sleep(r.ctx, 2*time.Second) {
+			break
+		}
+	}
+	detail := fmt.Sprintf("No job of cronjob %s succeeded within %v, check the cronjob controller and the clock of the control plane\n", w.cronJob, cronJobTimeout)
+	if ko := r.kube.run("describe", "cronjob", w.cronJob); ko.Success {
+		detail += ko.CombinedOut
+	}
+	r.err("cronjob-scheduled", name, detail)
+	return false
+}
+
+// removeCronJob deletes the cronjob, waiting for the garbage collector to
+// delete its jobs and their pods first
+func removeCronJob(r *reporter, w *workloads) {
+	if ko := runDelete(r.kube, "cronjob", w.cronJob, "--cascade=foreground"); ko.Success {
+		r.ok("cleanup-cronjob", "Powered down cronjob")
+	} else {
+		r.err("cleanup-cronjob", "Powered down cronjob", ko.CombinedOut)
+	}
+}
+
+// cronJobManifest returns a cronjob running `true` in a BusyBox pod every
+// minute
+func cronJobManifest(w *workloads) map[string]interface{} {
+	labels := map[string]interface{}{
+		"app":             "kuberang-cron",
+		"kuberang/testid": fmt.Sprintf("%d", w.testID),
+	}
+	container := map[string]interface{}{
+		"name":            "true",
+		"image":           w.image("busybox:latest"),
+		"imagePullPolicy": "IfNotPresent",
+		"command":         []string{"true"},
+	}
+	spec := map[string]interface{}{
+		"restartPolicy": "Never",
+		"affinity":      nodeAffinity(w.cfg, w.nodes),
+		"containers":    []interface{}{container},
+	}
+	serviceAccountSpec(w.cfg, spec)
+	podSecuritySpec(w.cfg, spec, container, false)
+	return map[string]interface{}{
+		"apiVersion": "batch/v1",
+		"kind":       "CronJob",
+		"metadata": map[string]interface{}{
+			"name":   w.cronJob,
+			"labels": labels,
+		},
+		"spec": map[string]interface{}{
+			"schedule":          "*/1 * * * *",
+			"concurrencyPolicy": "Forbid",
+			"jobTemplate": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"labels": labels,
+				},
+				"spec": map[string]interface{}{
+					"backoffLimit": 0,
+					"template": map[string]interface{}{
+						"metadata": map[string]interface{}{
+							"labels": labels,
+						},
+						"spec": spec,
+					},
+				},
+			},
+		},
+	}
+}
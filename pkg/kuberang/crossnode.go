@@ -0,0 +1,90 @@
+package kuberang
+
+import (
+	"sort"
+)
+
+// CrossNodePath is a pod-to-pod path between two nodes exercised by the
+// cross-node check
+type CrossNodePath struct {
+	FromNode string `json:"fromNode"`
+	ToNode   string `json:"toNode"`
+	PodIP    string `json:"podIP"`
+	Success  bool   `json:"success"`
+}
+
+// crossNodeAffinities returns the affinities pinning BusyBox to the first
+// node under test and nginx to the others, so that every BusyBox to nginx
+// path crosses nodes
+func crossNodeAffinities(nodes []string) (busybox, nginx map[string]interface{}) {
+	return pinnedNodeAffinity(nodes[:1]), pinnedNodeAffinity(nodes[1:])
+}
+
+// crossNodeTargets returns a ready pod on every node other than from, ordered
+// by node name
+func crossNodeTargets(from string, pods []Pod) []Pod {
+	byNode := map[string]Pod{}
+	for _, p := range pods {
+		if !p.Ready || p.IP == "" || p.NodeName == "" || p.NodeName == from {
+			continue
+		}
+		if _, ok := byNode[p.NodeName]; !ok {
+			byNode[p.NodeName] = p
+		}
+	}
+	nodes := make([]string, 0, len(byNode))
+	for n := range byNode {
+		nodes = append(nodes, n)
+	}
+	sort.Strings(nodes)
+	targets := make([]Pod, len(nodes))
+	for i, n := range nodes {
+		targets[i] = byNode[n]
+	}
+	return targets
+}
+
+// checkCrossNode accesses an nginx pod on every other node from BusyBox,
+// reporting the source and destination node of each path. Inter-node traffic
+// is where overlay and routing problems show up.
+func checkCrossNode(r *reporter, w *workloads, busyboxPodName string, nginxPods []Pod) bool {
+	name := "Accessed an Nginx pod on another node from BusyBox"
+	ko := r.kube.run("get", "pods", "-l", w.labels("kuberang-busybox"), "-o", "json")
+	from := ""
+	for _, p := range ko.Pods() {
+		if p.Name == busyboxPodName {
+			from = p.NodeName
+		}
+	}
+	if from == "" {
+		r.err("cross-node-pod-from-pod", name, "Could not find the node of BusyBox pod "+busyboxPodName+"\n"+ko.CombinedOut)
+		return false
+	}
+	targets := crossNodeTargets(from, nginxPods)
+	if len(targets) == 0 {
+		r.onNode(from)
+		r.err("cross-node-pod-from-pod", name, "No ready Nginx pod runs on a node other than "+from+", the BusyBox node\n")
+		return false
+	}
+	success := true
+	for _, p := range targets {
+		var kubeOut KubeOutput
+		attempt := retryAttempts(r.ctx, 3, func() bool {
+			kubeOut = wgetNginx(r.kube, busyboxPodName, p.IP)
+			return kubeOut.Success
+		})
+		r.result.CrossNode = append(r.result.CrossNode, CrossNodePath{FromNode: from, ToNode: p.NodeName, PodIP: p.IP, Success: attempt > 0})
+		name := "Accessed Nginx pod at " + p.IP + " on node " + p.NodeName + " from BusyBox on node " + from + " (cross-node)"
+		r.onNode(from)
+		switch {
+		case attempt > 0:
+			r.okAfter("cross-node-pod-from-pod", name, attempt, 3)
+		case r.cfg.IgnorePodIPAccessibilityCheck:
+			r.ignored("cross-node-pod-from-pod", name, kubeOut.CombinedOut)
+		default:
+			r.err("cross-node-pod-from-pod", name, "Traffic between pods on nodes "+from+" and "+p.NodeName+" failed, check the overlay or the routes between the nodes\n"+kubeOut.CombinedOut)
+			success = false
+		}
+	}
+	return success
+}
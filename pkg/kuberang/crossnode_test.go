@@ -0,0 +1,20 @@
+package kuberang
+
+import "testing"
+
+func TestCrossNodeTargets(t *testing.T) {
+	pods := []Pod{
+		{Name: "nginx-a", IP: "172.16.0.12", NodeName: "node1", Ready: true},
+		{Name: "nginx-b", IP: "172.16.2.7", NodeName: "node3", Ready: true},
+		{Name: "nginx-c", IP: "172.16.1.5", NodeName: "node2", Ready: false},
+		{Name: "nginx-d", IP: "172.16.1.6", NodeName: "node2", Ready: true},
+		{Name: "nginx-e", IP: "172.16.2.8", NodeName: "node3", Ready: true},
+	}
+	targets := crossNodeTargets("node1", pods)
+	if len(targets) != 2 || targets[0].Name != "nginx-d" || targets[1].Name != "nginx-b" {
+		t.Errorf("Unexpected targets %v", targets)
+	}
+	if targets := crossNodeTargets("node1", pods[:1]); len(targets) != 0 {
+		t.Errorf("Expected no target on a single node, got %v", targets)
+	}
+}
@@ -0,0 +1,70 @@
+package kuberang
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+// customProbeAttempts is the number of attempts of every custom probe
+const customProbeAttempts = 3
+
+// validateCustomProbes returns an error when there are more expected outputs
+// than custom probes, or when the probes cannot be given any time to run
+func validateCustomProbes(cfg *config.Config) error {
+	if len(cfg.ExpectOutputs) > len(cfg.CustomProbes) {
+		return errors.New("--expect-output is matched against the --custom-probe at the same position, there are more of the former")
+	}
+	if len(cfg.CustomProbes) > 0 && cfg.CustomProbeTimeout < time.Second {
+		return errors.New("--custom-probe-timeout must be at least 1s")
+	}
+	return nil
+}
+
+// customProbeArgs returns the kubectl arguments running the probe command in
+// the pod through a shell, killed once --custom-probe-timeout elapses
+func customProbeArgs(cfg *config.Config, pod, command string) []string {
+	seconds := int(math.Ceil(cfg.CustomProbeTimeout.Seconds()))
+	return []string{"exec", pod, "--", "timeout", fmt.Sprintf("%d", seconds), "sh", "-c", command}
+}
+
+// checkCustomProbe runs the command in the pod, retrying until it exits with
+// 0 and, when expected is not empty, prints a string containing expected
+func checkCustomProbe(r *reporter, pod, command, expected string) bool {
+	name := fmt.Sprintf("Ran custom probe %q in BusyBox", command)
+	var ko KubeOutput
+	attempt := retryAttempts(r.ctx, customProbeAttempts, func() bool {
+		ko = r.kube.run(customProbeArgs(r.cfg, pod, command)...)
+		return ko.Success && strings.Contains(ko.CombinedOut, expected)
+	})
+	switch {
+	case attempt > 0:
+		r.okAfter("custom-probe", name, attempt, customProbeAttempts)
+		return true
+	case ko.Success:
+		r.err("custom-probe", name, fmt.Sprintf("Output does not contain %q:\n%s", expected, ko.CombinedOut))
+	default:
+		r.err("custom-probe", name, ko.CombinedOut)
+	}
+	return false
+}
+
+// checkCustomProbes runs every --custom-probe in the pod, along with the
+// --expect-output at the same position
+func checkCustomProbes(r *reporter, pod string) bool {
+	success := true
+	for i, command := range r.cfg.CustomProbes {
+		expected := ""
+		if i < len(r.cfg.ExpectOutputs) {
+			expected = r.cfg.ExpectOutputs[i]
+		}
+		if !checkCustomProbe(r, pod, command, expected) {
+			success = false
+		}
+	}
+	return success
+}
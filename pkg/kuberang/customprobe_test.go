@@ -0,0 +1,71 @@
+package kuberang
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+func TestValidateCustomProbes(t *testing.T) {
+	cfg := &config.Config{}
+	if err := validateCustomProbes(cfg); err != nil {
+		t.Errorf("Unexpected error without custom probes: %v", err)
+	}
+	cfg.CustomProbes = []string{"true"}
+	if err := validateCustomProbes(cfg); err == nil {
+		t.Error("Expected a zero timeout to be rejected")
+	}
+	cfg.CustomProbeTimeout = 10 * time.Second
+	cfg.ExpectOutputs = []string{"a", "b"}
+	if err := validateCustomProbes(cfg); err == nil {
+		t.Error("Expected more expected outputs than probes to be rejected")
+	}
+}
+
+func TestCheckCustomProbes(t *testing.T) {
+	defer func(c func(context.Context, []byte, ...string) ([]byte, error)) { kubectlCommand = c }(kubectlCommand)
+	outputs := map[string]string{
+		"cat /etc/resolv.conf": "nameserver 10.96.0.10\n",
+		"exit 1":               "command terminated with exit code 1\n",
+	}
+	calls := 0
+	kubectlCommand = func(ctx context.Context, input []byte, args ...string) ([]byte, error) {
+		calls++
+		expected := []string{"exec", "busybox", "--", "timeout", "2", "sh", "-c"}
+		if !reflect.DeepEqual(args[:len(expected)], expected) {
+			t.Errorf("Unexpected kubectl arguments %q", args)
+		}
+		command := args[len(args)-1]
+		if command == "exit 1" {
+			return []byte(outputs[command]), errors.New("exit status 1")
+		}
+		return []byte(outputs[command]), nil
+	}
+	r := newReporter(ioutil.Discard, &CheckResult{})
+	r.cfg.CustomProbeTimeout = 1500 * time.Millisecond
+	r.cfg.CustomProbes = []string{"cat /etc/resolv.conf"}
+	r.cfg.ExpectOutputs = []string{"nameserver"}
+	if !checkCustomProbes(r, "busybox") || calls != 1 {
+		t.Errorf("Expected the probe to pass on the first attempt, got %d calls", calls)
+	}
+
+	// Cancelled so that the failing probes are not retried
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r = newReporter(ioutil.Discard, &CheckResult{})
+	r.ctx = ctx
+	r.cfg.CustomProbeTimeout = 1500 * time.Millisecond
+	r.cfg.CustomProbes = []string{"cat /etc/resolv.conf", "exit 1"}
+	r.cfg.ExpectOutputs = []string{"search"}
+	if checkCustomProbes(r, "busybox") {
+		t.Error("Expected the probes to fail")
+	}
+	if failed := r.result.Failed(); len(failed) != 2 || failed[0].ID != "custom-probe" {
+		t.Errorf("Expected both probes to fail, got %+v", failed)
+	}
+}
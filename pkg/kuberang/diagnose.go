@@ -0,0 +1,139 @@
+package kuberang
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apprenda/kuberang/pkg/config"
+	"github.com/apprenda/kuberang/pkg/util"
+)
+
+// diagnoseRetries is the number of attempts of the checks run by diagnose
+const diagnoseRetries = 10
+
+// errNodeAccess is reported when a node-side access failed every attempt
+var errNodeAccess = errors.New("every attempt failed, rerun with -vvv for the error of each attempt")
+
+// Diagnose runs a single check with kubectl logging, extended retries, and
+// the events and logs of the resources it required printed afterwards. The
+// kuberang resources found in the namespace are reused, and the ones
+// created for the check are removed unless keep is set.
+func Diagnose(ctx context.Context, cfg *config.Config, out io.Writer, checkID string, keep bool) error {
+	spec, ok := checkRegistry[checkID]
+	if !ok {
+		return fmt.Errorf("Unknown check %q, checks that can be diagnosed: %s", checkID, strings.Join(registeredCheckIDs(), ", "))
+	}
+	c := *cfg
+	cfg = &c
+	if cfg.Verbosity < logOutput {
+		cfg.Verbosity = logOutput
+	}
+	client, err := nodeHTTPClient(cfg)
+	if err != nil {
+		return err
+	}
+	result := &CheckResult{Namespace: namespace(cfg), StartTime: time.Now()}
+	r := newReporter(out, result)
+	ctx = withRetrySettings(ctx, retrySettings{verbosity: cfg.Verbosity})
+	k := newKubectl(ctx, cfg)
+	r.cfg, r.ctx, r.kube = cfg, ctx, k
+	if !precheckKubectl(r) {
+		return errors.New("Kubectl must be configured on this machine before running kuberang")
+	}
+
+	// Reuse the test ID of the workloads left in the namespace, so that
+	// their labels and service name are found
+	testID := time.Now().UnixNano()
+	for _, d := range []string{"kuberang-busybox", "kuberang-nginx"} {
+		if ko := k.getDeployment(d); ko.Success {
+			if id, err := strconv.ParseInt(ko.ObjectLabels()["kuberang/testid"], 10, 64); err == nil {
+				testID = id
+			}
+		}
+	}
+	w := newWorkloads(cfg, testID)
+	env := &checkEnv{client: client, retries: diagnoseRetries}
+
+	created := []string{}
+	if !keep {
+		defer func() {
+			if len(created) == 0 {
+				return
+			}
+			defer cleanupContext(r)()
+			if ko := runDelete(r.kube, append([]string{"--ignore-not-found=true"}, created...)...); ko.Success {
+				r.ok("cleanup", "Removed "+strings.Join(created, ", "))
+			} else {
+				r.err("cleanup", "Removed "+strings.Join(created, ", "), ko.CombinedOut)
+			}
+		}()
+	}
+	for _, resource := range spec.requires {
+		switch resource {
+		case resourceBusybox:
+			if !k.getDeployment(w.bbDeployment).Success {
+				if !deployBusybox(r, w) {
+					return errors.New("Failed to deploy BusyBox")
+				}
+				created = append(created, "deployment/"+w.bbDeployment)
+			}
+			if !waitForAvailable(r, w.bbDeployment) {
+				return errors.New("BusyBox did not become available")
+			}
+			env.busyboxPodName = k.run("get", "pods", "-l", w.labels("kuberang-busybox"), "-o", "json").FirstPodName()
+		case resourceNginx:
+			if !k.getDeployment(w.ngDeployment).Success {
+				if err := discoverNodes(r, w); err != nil {
+					return err
+				}
+				// Recorded first, so that a partial deployment is removed
+				created = append(created, w.nginxObjects()...)
+				if !deployNginx(r, w) {
+					return errors.New("Failed to deploy nginx")
+				}
+			}
+			if !waitForAvailable(r, w.ngDeployment) {
+				return errors.New("Nginx did not become available")
+			}
+			env.nginxPods = k.run("get", "pods", "-l", w.labels("kuberang-nginx"), "-o", "json").Pods()
+			env.podIPs = readyPodIPs(env.nginxPods)
+			env.serviceIP = k.getService(w.ngService).ServiceCluserIP()
+		}
+	}
+
+	ok = spec.run(r, w, env)
+
+	util.PrintHeader(out, "Events")
+	fmt.Fprint(out, k.run("get", "events", "--sort-by=.lastTimestamp").CombinedOut)
+	for _, resource := range spec.requires {
+		util.PrintHeader(out, "Logs of "+resource)
+		fmt.Fprint(out, k.run("logs", "-l", w.labels("kuberang-"+resource), "--tail=100", "--prefix").CombinedOut)
+	}
+	if !ok {
+		return fmt.Errorf("Check %s failed", checkID)
+	}
+	return nil
+}
+
+// waitForAvailable waits for at least one replica of the deployment to be
+// available
+func waitForAvailable(r *reporter, deployment string) bool {
+	name := "Deployment " + deployment + " available"
+	start := time.Now()
+	for time.Since(start) < deploymentTimeout {
+		if ko := r.kube.getDeployment(deployment); ko.Success && ko.ObservedReplicaCount() > 0 {
+			r.ok("deployments-ready", name)
+			return true
+		}
+		if !sleep(r.ctx, 1*time.Second) {
+			break
+		}
+	}
+	r.err("deployments-ready", name, "")
+	return false
+}
@@ -0,0 +1,124 @@
+package kuberang
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// diagnosticsLogTail is the number of log lines collected for each pod
+	diagnosticsLogTail = 500
+	// maxDiagnosticsFileSize bounds the size of every file in the bundle
+	maxDiagnosticsFileSize = 1 << 20
+)
+
+// diagnosticsFile is a single file of the diagnostics bundle along with the
+// command that produced it
+type diagnosticsFile struct {
+	name    string
+	command string
+	content []byte
+}
+
+// collectDiagnostics gathers the support bundle of a failed run and records
+// its path in the result
+func collectDiagnostics(r *reporter, w *workloads) {
+	name := "Collected diagnostics bundle"
+	files := []diagnosticsFile{}
+	var report bytes.Buffer
+	renderJSON(&report, r.result)
+	files = append(files, diagnosticsFile{name: "report.json", command: "kuberang -o json", content: report.Bytes()})
+
+	add := func(file string, args ...string) {
+		ko := r.kube.run(args...)
+		files = append(files, diagnosticsFile{name: file, command: "kubectl " + strings.Join(args, " "), content: ko.RawOut})
+	}
+	add("kubectl-version.txt", "version")
+	add("nodes.yaml", "get", "nodes", "-o", "yaml")
+	add("namespace-all.txt", "get", "all", "-o", "wide")
+	add("namespace-events.txt", "get", "events", "--sort-by=.lastTimestamp")
+	add("kuberang-pods-describe.txt", "describe", "pods", "-l", fmt.Sprintf("kuberang/testid=%d", w.testID))
+	if ko := r.kube.run("get", "pods", "-l", fmt.Sprintf("kuberang/testid=%d", w.testID), "-o", "json"); ko.Success {
+		for _, p := range ko.Pods() {
+			add("logs/"+p.Name+".txt", "logs", p.Name, fmt.Sprintf("--tail=%d", diagnosticsLogTail))
+		}
+	}
+	add("kube-system-coredns.txt", "get", "pods", "--namespace="+systemNamespace, "-l", "k8s-app=kube-dns", "-o", "wide")
+	add("kube-system-kube-proxy.txt", "get", "pods", "--namespace="+systemNamespace, "-l", "k8s-app=kube-proxy", "-o", "wide")
+
+	path, err := diagnosticsBundlePath(artifactPath(r.cfg, r.cfg.CollectDiagnostics, "."), time.Now())
+	if err == nil {
+		err = writeDiagnosticsBundle(path, files)
+	}
+	if err != nil {
+		r.warn("collect-diagnostics", name, err.Error()+"\n")
+		return
+	}
+	r.result.DiagnosticsBundle = path
+	r.result.Artifacts = append(r.result.Artifacts, Artifact{Name: "diagnostics", Path: path})
+	r.ok("collect-diagnostics", name)
+}
+
+// diagnosticsBundlePath returns the path of the bundle given the value of
+// --collect-diagnostics. Paths ending in .tar.gz or .tgz are used as is,
+// anything else is a directory in which a timestamped bundle is created.
+func diagnosticsBundlePath(target string, now time.Time) (string, error) {
+	if strings.HasSuffix(target, ".tar.gz") || strings.HasSuffix(target, ".tgz") {
+		return target, nil
+	}
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return "", fmt.Errorf("error creating diagnostics directory: %v", err)
+	}
+	return filepath.Join(target, "kuberang-diagnostics-"+now.UTC().Format("20060102-150405")+".tar.gz"), nil
+}
+
+// writeDiagnosticsBundle writes the files to a gzipped tarball along with a
+// manifest listing the command behind every file. Files larger than
+// maxDiagnosticsFileSize are truncated.
+func writeDiagnosticsBundle(path string, files []diagnosticsFile) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating diagnostics bundle: %v", err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	var manifest bytes.Buffer
+	for _, file := range files {
+		fmt.Fprintf(&manifest, "%s\t%s\n", file.name, file.command)
+	}
+	files = append([]diagnosticsFile{{name: "MANIFEST", content: manifest.Bytes()}}, files...)
+	dir := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(path), ".tgz"), ".tar.gz")
+	for _, file := range files {
+		content := file.content
+		if len(content) > maxDiagnosticsFileSize {
+			content = append(content[:maxDiagnosticsFileSize:maxDiagnosticsFileSize], []byte("\n[truncated]\n")...)
+		}
+		hdr := &tar.Header{
+			Name:    dir + "/" + file.name,
+			Mode:    0644,
+			Size:    int64(len(content)),
+			ModTime: time.Now(),
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return fmt.Errorf("error writing diagnostics bundle: %v", err)
+		}
+		if _, err := tw.Write(content); err != nil {
+			return fmt.Errorf("error writing diagnostics bundle: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("error writing diagnostics bundle: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("error writing diagnostics bundle: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package kuberang
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiagnosticsBundlePath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kuberang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2016, 10, 19, 22, 41, 0, 0, time.UTC)
+	if path, _ := diagnosticsBundlePath("bundle.tgz", now); path != "bundle.tgz" {
+		t.Errorf("Expected tarball paths to be used as is, got %s", path)
+	}
+	path, err := diagnosticsBundlePath(filepath.Join(dir, "out"), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := filepath.Join(dir, "out", "kuberang-diagnostics-20161019-224100.tar.gz"); path != expected {
+		t.Errorf("Expected %s, got %s", expected, path)
+	}
+}
+
+func TestWriteDiagnosticsBundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kuberang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "bundle.tar.gz")
+	files := []diagnosticsFile{
+		{name: "nodes.yaml", command: "kubectl get nodes -o yaml", content: []byte("items: []\n")},
+		{name: "logs/big.txt", command: "kubectl logs big", content: make([]byte, maxDiagnosticsFileSize+1)},
+	}
+	if err := writeDiagnosticsBundle(path, files); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	contents := map[string]string{}
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+		b, _ := ioutil.ReadAll(tr)
+		contents[hdr.Name] = string(b)
+	}
+	if !strings.Contains(contents["bundle/MANIFEST"], "nodes.yaml\tkubectl get nodes -o yaml") {
+		t.Errorf("Unexpected manifest: %q", contents["bundle/MANIFEST"])
+	}
+	if contents["bundle/nodes.yaml"] != "items: []\n" {
+		t.Errorf("Unexpected nodes.yaml: %q", contents["bundle/nodes.yaml"])
+	}
+	if big := contents["bundle/logs/big.txt"]; !strings.HasSuffix(big, "[truncated]\n") || len(big) > maxDiagnosticsFileSize+20 {
+		t.Errorf("Expected big.txt to be truncated, got %d bytes", len(big))
+	}
+}
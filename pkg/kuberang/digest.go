@@ -0,0 +1,64 @@
+package kuberang
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// checkImageDigests compares the digests of the images run by the busybox
+// and nginx pods with the expected ones, when given
+func checkImageDigests(r *reporter, w *workloads) bool {
+	success := true
+	if r.cfg.ExpectBusyboxDigest != "" && !checkImageDigest(r, w, "kuberang-busybox", "BusyBox", r.cfg.ExpectBusyboxDigest) {
+		success = false
+	}
+	if r.cfg.ExpectNginxDigest != "" && !checkImageDigest(r, w, "kuberang-nginx", "Nginx", r.cfg.ExpectNginxDigest) {
+		success = false
+	}
+	return success
+}
+
+func checkImageDigest(r *reporter, w *workloads, app, title, expected string) bool {
+	id := strings.ToLower(title) + "-image-digest"
+	name := title + " pods run image " + expected
+	ko := r.kube.run("get", "pods", "-l", w.labels(app), "-o", "json")
+	if !ko.Success {
+		r.err(id, name, ko.CombinedOut)
+		return false
+	}
+	if mismatches := digestMismatches(ko.ImageIDs(), expected); len(mismatches) > 0 {
+		r.err(id, name, strings.Join(mismatches, "\n")+"\n")
+		return false
+	}
+	r.ok(id, name)
+	return true
+}
+
+// digestMismatches describes the pods running an image other than the one
+// with the expected digest. Image IDs take the form
+// docker-pullable://busybox@sha256:..., so only their digest is compared.
+func digestMismatches(imageIDs map[string][]string, expected string) []string {
+	pods := make([]string, 0, len(imageIDs))
+	for pod := range imageIDs {
+		pods = append(pods, pod)
+	}
+	sort.Strings(pods)
+	mismatches := []string{}
+	for _, pod := range pods {
+		for _, imageID := range imageIDs[pod] {
+			if imageDigest(imageID) != imageDigest(expected) {
+				mismatches = append(mismatches, fmt.Sprintf("Pod %s runs image %s", pod, imageID))
+			}
+		}
+	}
+	return mismatches
+}
+
+// imageDigest returns the digest part of an image reference or ID
+func imageDigest(ref string) string {
+	if i := strings.LastIndex(ref, "@"); i >= 0 {
+		return ref[i+1:]
+	}
+	return ref
+}
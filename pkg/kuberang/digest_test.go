@@ -0,0 +1,17 @@
+package kuberang
+
+import "testing"
+
+func TestDigestMismatches(t *testing.T) {
+	ko := KubeOutput{RawOut: []byte(`{"items": [
+		{"metadata": {"name": "kuberang-nginx-1"}, "status": {"containerStatuses": [{"imageID": "docker-pullable://nginx@sha256:aaa"}]}},
+		{"metadata": {"name": "kuberang-nginx-2"}, "status": {"containerStatuses": [{"imageID": "docker.io/library/nginx@sha256:bbb"}]}}
+	]}`)}
+	mismatches := digestMismatches(ko.ImageIDs(), "sha256:aaa")
+	if len(mismatches) != 1 || mismatches[0] != "Pod kuberang-nginx-2 runs image docker.io/library/nginx@sha256:bbb" {
+		t.Errorf("Unexpected mismatches: %v", mismatches)
+	}
+	if mismatches := digestMismatches(ko.ImageIDs(), "nginx@sha256:bbb"); len(mismatches) != 1 {
+		t.Errorf("Expected a full image reference to be compared by digest, got %v", mismatches)
+	}
+}
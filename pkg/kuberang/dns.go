@@ -0,0 +1,241 @@
+package kuberang
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/apprenda/kuberang/pkg/config"
+	"github.com/apprenda/kuberang/pkg/util"
+)
+
+// dnsFindings are the outcomes of the DNS diagnostics the diagnosis is
+// drawn from
+type dnsFindings struct {
+	resolvConfOK bool
+	// serviceNamesOK is set when the names resolved through the pod's
+	// resolver, and so through the kube-dns service VIP, all resolved
+	serviceNamesOK bool
+	upstreamOK     bool
+	corednsPods    int
+	badPods        []string
+	nodeLocal      nodeLocalDNSFindings
+}
+
+// diagnosis names the failing part of the DNS chain
+func (f dnsFindings) diagnosis() string {
+	switch {
+	case !f.resolvConfOK:
+		return "The pod resolver configuration does not point at the cluster DNS service"
+	case f.nodeLocal.detected && !f.nodeLocal.cacheOK && f.nodeLocal.serviceOK:
+		return f.nodeLocal.diagnosis()
+	case f.corednsPods > 0 && len(f.badPods) == f.corednsPods:
+		return "No CoreDNS pod answers: CoreDNS itself is broken"
+	case len(f.badPods) > 0:
+		return fmt.Sprintf("CoreDNS pod(s) %s do not answer, the lookups sent to them through the service VIP fail", strings.Join(f.badPods, ", "))
+	case !f.serviceNamesOK:
+		return "The CoreDNS pods answer directly but not through the kube-dns service VIP: check kube-proxy and the kube-dns endpoints"
+	case !f.upstreamOK:
+		return "Cluster names resolve but external names do not: check the upstream forwarding of CoreDNS"
+	}
+	return "DNS is healthy"
+}
+
+// DNS deploys BusyBox, and a probe pod on every node when perNode is set,
+// and runs a battery of DNS diagnostics ending with a diagnosis of the
+// failing part of the DNS chain. Latency is measured over the given number
+// of lookups.
+func DNS(ctx context.Context, cfg *config.Config, out io.Writer, lookups int, perNode bool) error {
+	return run(ctx, cfg, out, func(r *reporter, w *workloads) error {
+		return runDNS(r, w, lookups, perNode)
+	})
+}
+
+func runDNS(r *reporter, w *workloads, lookups int, perNode bool) (err error) {
+	r.startPhase(PhasePrecheck)
+	if !precheckKubectl(r) {
+		return errors.New("Kubectl must be configured on this machine before running kuberang")
+	}
+	r.ok("kubectl-configured", "Kubectl configured on this node")
+	if err := discoverNodes(r, w); err != nil {
+		return err
+	}
+
+	deployed := false
+	defer func() {
+		if deployed && !r.cfg.SkipCleanup {
+			defer cleanupContext(r)()
+			r.startPhase(PhaseCleanup)
+			removeDNSWorkloads(r, w)
+		}
+	}()
+	deployed = true
+	r.startPhase(PhaseDeploy)
+	if !deployBusybox(r, w) || !waitForBusybox(r, w) {
+		return errors.New("Failed to deploy test workloads")
+	}
+	ko := r.kube.run("get", "pods", "-l", w.labels("kuberang-busybox"), "-o", "json")
+	busyboxPodName := ko.FirstPodName()
+	if busyboxPodName == "" {
+		r.err("busybox-pod-name", "Grab BusyBox pod name", ko.CombinedOut)
+		return errors.New("Failed to get required information from cluster")
+	}
+	r.ok("busybox-pod-name", "Grab BusyBox pod name")
+	if ko := r.kube.run("expose", "deployment", w.bbDeployment, "--name="+w.dnsService, "--port=80", "--labels="+w.labels("kuberang-dns")); !ko.Success {
+		r.err("dns-service-create", "Created service "+w.dnsService+" to resolve", ko.CombinedOut)
+		return errors.New("Failed to deploy test workloads")
+	}
+	r.ok("dns-service-create", "Created service "+w.dnsService+" to resolve")
+
+	r.startPhase(PhaseConnectivity)
+	f := dnsFindings{}
+	dnsIP := r.kube.run("get", "service", "kube-dns", "--namespace="+systemNamespace, "-o", "json").ServiceCluserIP()
+	f.resolvConfOK = checkResolvConf(r, busyboxPodName, dnsIP)
+
+	// Names resolved through the pod's resolver, and so the service VIP
+	fqdn := fmt.Sprintf("%s.%s.svc.%s", w.dnsService, namespace(r.cfg), r.cfg.ClusterDomain)
+	f.serviceNamesOK = true
+	for _, dnsName := range []string{w.dnsService, w.dnsService + "." + namespace(r.cfg), fqdn, "kubernetes.default"} {
+		if !resolveFromPod(r, busyboxPodName, "dns-name-resolution", "Resolved "+dnsName+" from BusyBox", dnsName, "") {
+			f.serviceNamesOK = false
+		}
+	}
+
+	// The same name through NodeLocal DNSCache, when in use
+	f.nodeLocal = checkNodeLocalDNS(r, busyboxPodName, fqdn, dnsIP)
+
+	// Every CoreDNS replica queried directly
+	pko := r.kube.run("get", "pods", "-l", "k8s-app=kube-dns", "--namespace="+systemNamespace, "-o", "json")
+	if pods := pko.Pods(); !pko.Success || len(pods) == 0 {
+		r.skipped("dns-coredns-pod", "Queried every CoreDNS pod from BusyBox", "the CoreDNS pods are not visible")
+	} else {
+		for _, p := range pods {
+			f.corednsPods++
+			if p.IP == "" || !resolveFromPod(r, busyboxPodName, "dns-coredns-pod", "Resolved "+fqdn+" with CoreDNS pod "+p.Name+" at "+p.IP, fqdn, p.IP) {
+				f.badPods = append(f.badPods, p.Name)
+			}
+		}
+	}
+
+	f.upstreamOK = true
+	if r.cfg.Offline {
+		r.skipped("dns-upstream", "Resolved "+externalDNSName+" from BusyBox", "--offline")
+	} else {
+		f.upstreamOK = resolveFromPod(r, busyboxPodName, "dns-upstream", "Resolved "+externalDNSName+" from BusyBox", externalDNSName, "")
+	}
+
+	checkDNSLatency(r, busyboxPodName, fqdn, lookups)
+
+	success := f.resolvConfOK && f.serviceNamesOK && f.upstreamOK && len(f.badPods) == 0
+	if f.nodeLocal.detected && !f.nodeLocal.cacheOK {
+		success = false
+	}
+	if perNode && !checkDNSFromEveryNode(r, w, fqdn) {
+		success = false
+	}
+
+	util.PrintHeader(r.out, "Diagnosis")
+	printLine(r.out, "%s", f.diagnosis())
+	r.result.DNSDiagnosis = f.diagnosis()
+	if !success {
+		return errors.New("One or more DNS checks failed")
+	}
+	return nil
+}
+
+// checkResolvConf verifies that the pod resolver points at the cluster DNS
+// service, or at NodeLocal DNSCache, and searches the service domain of the
+// namespace
+func checkResolvConf(r *reporter, busyboxPodName, dnsIP string) bool {
+	name := "BusyBox resolv.conf points at the cluster DNS"
+	ko := r.kube.run("exec", busyboxPodName, "--", "cat", "/etc/resolv.conf")
+	if !ko.Success {
+		r.err("dns-resolv-conf", name, ko.CombinedOut)
+		return false
+	}
+	problems := ""
+	if nameservers := resolvConfNameservers(ko.CombinedOut); dnsIP != "" && !containsString(nameservers, dnsIP) && !containsString(nameservers, r.cfg.NodeLocalDNSIP) {
+		problems += fmt.Sprintf("The pod uses nameserver(s) %s instead of %s\n", strings.Join(nameservers, ", "), dnsIP)
+	}
+	if domain := namespace(r.cfg) + ".svc." + r.cfg.ClusterDomain; !strings.Contains(ko.CombinedOut, domain) {
+		problems += "The search path does not include " + domain + "\n"
+	}
+	if problems != "" {
+		r.err("dns-resolv-conf", name, problems+ko.CombinedOut)
+		return false
+	}
+	r.ok("dns-resolv-conf", name)
+	return true
+}
+
+// resolveFromPod resolves the name from the BusyBox pod, through the given
+// DNS server or the pod's resolver when empty
+func resolveFromPod(r *reporter, busyboxPodName, id, name, dnsName, server string) bool {
+	args := []string{"exec", busyboxPodName, "--", "nslookup", dnsName}
+	if server != "" {
+		args = append(args, server)
+	}
+	var ko KubeOutput
+	attempt := retryAttempts(r.ctx, 3, func() bool {
+		ko = r.kube.run(args...)
+		return ko.Success
+	})
+	if attempt == 0 {
+		r.err(id, name, ko.CombinedOut)
+		return false
+	}
+	r.okAfter(id, name, attempt, 3)
+	return true
+}
+
+// checkDNSLatency reports the latency distribution of repeated lookups of
+// the name from the BusyBox pod
+func checkDNSLatency(r *reporter, busyboxPodName, dnsName string, lookups int) {
+	name := fmt.Sprintf("Resolved %s %d times from BusyBox", dnsName, lookups)
+	ko := r.kube.run("exec", busyboxPodName, "--", "sh", "-c", dnsStressScript(dnsName, lookups))
+	if !ko.Success {
+		r.err("dns-latency", name, ko.CombinedOut)
+		return
+	}
+	result := dnsStressResult(dnsName, parseTimings(ko.CombinedOut))
+	r.result.DNSStress = append(r.result.DNSStress, result)
+	name = fmt.Sprintf("%s: %.1f%% failed, p50 %.1fms, p95 %.1fms, p99 %.1fms",
+		name, result.FailureRate*100, result.P50Ms, result.P95Ms, result.P99Ms)
+	if result.Failures > 0 {
+		r.warn("dns-latency", name, fmt.Sprintf("%d of %d lookups failed\n", result.Failures, result.Lookups))
+		return
+	}
+	r.ok("dns-latency", name)
+}
+
+// checkDNSFromEveryNode resolves the name from a probe pod on every node
+func checkDNSFromEveryNode(r *reporter, w *workloads, dnsName string) bool {
+	if !deployNodeProbes(r, w) {
+		return false
+	}
+	success := true
+	for _, node := range w.nodes {
+		pod, ok := w.probePods[node]
+		if !ok {
+			continue
+		}
+		if !resolveFromPod(r.onNode(node), pod, "dns-from-node-probe", "Resolved "+dnsName+" from a pod on node "+node, dnsName, "") {
+			success = false
+		}
+	}
+	return success
+}
+
+// removeDNSWorkloads removes what the DNS diagnostics deployed
+func removeDNSWorkloads(r *reporter, w *workloads) {
+	if ko := runDelete(r.kube, "--ignore-not-found=true", "service/"+w.dnsService, "deployment/"+w.bbDeployment); ko.Success {
+		r.ok("cleanup-dns-workloads", "Powered down BusyBox and the DNS test service")
+	} else {
+		r.err("cleanup-dns-workloads", "Powered down BusyBox and the DNS test service", ko.CombinedOut)
+	}
+	if w.probesDeployed {
+		removeNodeProbes(r, w)
+	}
+}
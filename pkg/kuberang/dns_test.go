@@ -0,0 +1,27 @@
+package kuberang
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDNSDiagnosis(t *testing.T) {
+	healthy := dnsFindings{resolvConfOK: true, serviceNamesOK: true, upstreamOK: true, corednsPods: 2}
+	tests := []struct {
+		findings dnsFindings
+		want     string
+	}{
+		{healthy, "DNS is healthy"},
+		{dnsFindings{serviceNamesOK: true, upstreamOK: true, corednsPods: 2}, "resolver configuration"},
+		{dnsFindings{resolvConfOK: true, corednsPods: 2, badPods: []string{"coredns-a", "coredns-b"}}, "No CoreDNS pod answers"},
+		{dnsFindings{resolvConfOK: true, upstreamOK: true, corednsPods: 2, badPods: []string{"coredns-b"}}, "CoreDNS pod(s) coredns-b"},
+		{dnsFindings{resolvConfOK: true, upstreamOK: true, corednsPods: 2}, "service VIP"},
+		{dnsFindings{resolvConfOK: true, serviceNamesOK: true, corednsPods: 2}, "upstream forwarding"},
+		{dnsFindings{resolvConfOK: true, upstreamOK: true, corednsPods: 2, nodeLocal: nodeLocalDNSFindings{ip: "169.254.20.10", detected: true, serviceOK: true}}, "NodeLocal DNSCache at 169.254.20.10"},
+	}
+	for _, test := range tests {
+		if got := test.findings.diagnosis(); !strings.Contains(got, test.want) {
+			t.Errorf("diagnosis of %+v = %q, expected it to mention %q", test.findings, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package kuberang
+
+import (
+	"fmt"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+// The resolution forms of the nginx service name that --dns-require can
+// declare as required
+const (
+	DNSRequireFQDN  = "fqdn"
+	DNSRequireShort = "short"
+	DNSRequireBoth  = "both"
+)
+
+// validateDNSRequire returns an error when --dns-require is not one of the
+// supported forms. Leaving it unset requires both.
+func validateDNSRequire(value string) error {
+	switch value {
+	case "", DNSRequireFQDN, DNSRequireShort, DNSRequireBoth:
+		return nil
+	}
+	return fmt.Errorf("Unsupported --dns-require value %q", value)
+}
+
+// dnsFormRequired tells whether the given form must resolve, as declared
+// with --dns-require
+func dnsFormRequired(cfg *config.Config, form string) bool {
+	return cfg.DNSRequire == "" || cfg.DNSRequire == DNSRequireBoth || cfg.DNSRequire == form
+}
+
+// checkServiceDNSFromPod accesses the nginx service from BusyBox through its
+// short name and through its FQDN. A form that is not required is reported
+// as ignored when it fails, as in clusters that restrict the search domains
+// on purpose.
+func checkServiceDNSFromPod(r *reporter, w *workloads, busyboxPodName string) bool {
+	success := true
+	forms := []struct {
+		form, id, name string
+	}{
+		{DNSRequireShort, "service-dns-from-pod", w.ngService},
+		{DNSRequireFQDN, "service-fqdn-from-pod", w.serviceFQDN()},
+	}
+	for _, f := range forms {
+		var kubeOut KubeOutput
+		attempt := retryAttempts(r.ctx, 6, func() bool {
+			kubeOut = wgetNginx(r.kube, busyboxPodName, f.name)
+			return kubeOut.Success
+		})
+		name := "Accessed Nginx service via DNS " + f.name + " from BusyBox"
+		switch {
+		case attempt > 0:
+			r.okAfter(f.id, name, attempt, 6)
+		case !dnsFormRequired(r.cfg, f.form):
+			r.ignored(f.id, name, "Not required with --dns-require="+r.cfg.DNSRequire+"\n"+kubeOut.CombinedOut)
+		default:
+			r.err(f.id, name, kubeOut.CombinedOut)
+			success = false
+		}
+	}
+	return success
+}
@@ -0,0 +1,28 @@
+package kuberang
+
+import (
+	"testing"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+func TestDNSFormRequired(t *testing.T) {
+	tests := []struct {
+		require     string
+		short, fqdn bool
+	}{
+		{"", true, true},
+		{DNSRequireBoth, true, true},
+		{DNSRequireFQDN, false, true},
+		{DNSRequireShort, true, false},
+	}
+	for _, test := range tests {
+		cfg := &config.Config{DNSRequire: test.require}
+		if dnsFormRequired(cfg, DNSRequireShort) != test.short || dnsFormRequired(cfg, DNSRequireFQDN) != test.fqdn {
+			t.Errorf("Unexpected required forms with --dns-require=%q", test.require)
+		}
+	}
+	if err := validateDNSRequire("search"); err == nil {
+		t.Error("Expected an error for an unsupported form")
+	}
+}
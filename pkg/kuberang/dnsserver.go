@@ -0,0 +1,73 @@
+package kuberang
+
+import (
+	"fmt"
+	"strings"
+)
+
+// checkDNSServer queries the cluster DNS ClusterIP directly from the BusyBox
+// pod, and then through the pod's resolver configuration, so that an
+// unreachable DNS server is told apart from a misconfigured resolv.conf
+func checkDNSServer(r *reporter, busyboxPodName string) bool {
+	fqdn := "kubernetes.default.svc." + r.cfg.ClusterDomain
+	ko := r.kube.run("get", "service", "kube-dns", "--namespace="+systemNamespace, "-o", "json")
+	dnsIP := ko.ServiceCluserIP()
+	if !ko.Success || dnsIP == "" {
+		r.skipped("dns-clusterip-from-pod", "Queried the cluster DNS ClusterIP from BusyBox", "the kube-dns service is not visible")
+		return true
+	}
+
+	name := "Queried the cluster DNS server at " + dnsIP + " from BusyBox"
+	var direct KubeOutput
+	attempt := retryAttempts(r.ctx, 3, func() bool {
+		direct = r.kube.run("exec", busyboxPodName, "--", "nslookup", fqdn, dnsIP)
+		return direct.Success
+	})
+	if attempt == 0 {
+		r.err("dns-clusterip-from-pod", name, "DNS server unreachable: the cluster DNS ClusterIP did not answer\n"+direct.CombinedOut)
+		return false
+	}
+	r.okAfter("dns-clusterip-from-pod", name, attempt, 3)
+
+	name = "Resolved " + fqdn + " through the BusyBox resolver configuration"
+	var resolved KubeOutput
+	attempt = retryAttempts(r.ctx, 3, func() bool {
+		resolved = r.kube.run("exec", busyboxPodName, "--", "nslookup", fqdn)
+		return resolved.Success
+	})
+	if attempt == 0 {
+		detail := "resolv.conf misconfigured: the cluster DNS server answers directly but not through the pod's resolver configuration\n"
+		if rc := r.kube.run("exec", busyboxPodName, "--", "cat", "/etc/resolv.conf"); rc.Success {
+			if nameservers := resolvConfNameservers(rc.CombinedOut); !containsString(nameservers, dnsIP) {
+				detail += fmt.Sprintf("The pod uses nameserver(s) %s instead of %s\n", strings.Join(nameservers, ", "), dnsIP)
+			}
+			detail += rc.CombinedOut
+		}
+		r.err("dns-resolver-from-pod", name, detail+resolved.CombinedOut)
+		return false
+	}
+	r.okAfter("dns-resolver-from-pod", name, attempt, 3)
+	return true
+}
+
+// resolvConfNameservers returns the nameservers listed in a resolv.conf
+func resolvConfNameservers(resolvConf string) []string {
+	nameservers := []string{}
+	for _, line := range strings.Split(resolvConf, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			nameservers = append(nameservers, fields[1])
+		}
+	}
+	return nameservers
+}
+
+// containsString returns true if the list contains the string
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,15 @@
+package kuberang
+
+import "testing"
+
+func TestResolvConfNameservers(t *testing.T) {
+	resolvConf := `search default.svc.cluster.local svc.cluster.local cluster.local
+nameserver 10.3.0.10
+nameserver  169.254.20.10
+options ndots:5
+`
+	nameservers := resolvConfNameservers(resolvConf)
+	if len(nameservers) != 2 || nameservers[0] != "10.3.0.10" || nameservers[1] != "169.254.20.10" {
+		t.Errorf("Unexpected nameservers: %v", nameservers)
+	}
+}
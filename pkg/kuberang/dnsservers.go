@@ -0,0 +1,90 @@
+package kuberang
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// checkDNSServers resolves a name against each of the configured DNS servers
+// from the BusyBox pod, so that the broken resolver of the chain stands out.
+// Servers answering with different addresses are reported as a warning.
+func checkDNSServers(r *reporter, w *workloads, busyboxPodName string) bool {
+	fqdn := r.cfg.DNSServersName
+	if fqdn == "" {
+		fqdn = w.serviceFQDN()
+	}
+	success := true
+	answers := map[string][]string{}
+	for _, server := range r.cfg.DNSServers {
+		name := "Resolved " + fqdn + " with DNS server " + server + " from BusyBox"
+		var ko KubeOutput
+		attempt := retryAttempts(r.ctx, 3, func() bool {
+			ko = r.kube.run("exec", busyboxPodName, "--", "nslookup", fqdn, server)
+			return ko.Success && len(nslookupAddresses(ko.CombinedOut)) > 0
+		})
+		if attempt == 0 {
+			r.err("dns-server-resolution", name, ko.CombinedOut)
+			success = false
+			continue
+		}
+		answers[server] = nslookupAddresses(ko.CombinedOut)
+		r.okAfter("dns-server-resolution", name, attempt, 3)
+	}
+	if len(answers) > 1 {
+		name := "DNS servers agree on the addresses of " + fqdn
+		if mismatch := dnsAnswerMismatch(r.cfg.DNSServers, answers); mismatch != "" {
+			r.warn("dns-servers-agree", name, mismatch)
+		} else {
+			r.ok("dns-servers-agree", name)
+		}
+	}
+	return success
+}
+
+// nslookupAddresses returns the sorted addresses of the name resolved by
+// BusyBox nslookup, leaving out the address of the server that answered
+func nslookupAddresses(out string) []string {
+	addresses := []string{}
+	inAnswer := false
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "Name:") {
+			inAnswer = true
+			continue
+		}
+		if !inAnswer || !strings.HasPrefix(line, "Address") {
+			continue
+		}
+		// Older BusyBox prints "Address 1: 10.0.0.1 name", newer
+		// "Address: 10.0.0.1"
+		i := strings.Index(line, ":")
+		if i < 0 {
+			continue
+		}
+		if fields := strings.Fields(line[i+1:]); len(fields) > 0 {
+			addresses = append(addresses, fields[0])
+		}
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+// dnsAnswerMismatch describes the answers of the servers when they do not
+// all agree, or returns an empty string
+func dnsAnswerMismatch(servers []string, answers map[string][]string) string {
+	distinct := map[string]bool{}
+	detail := ""
+	for _, server := range servers {
+		addresses, ok := answers[server]
+		if !ok {
+			continue
+		}
+		distinct[strings.Join(addresses, ",")] = true
+		detail += fmt.Sprintf("%s answered %s\n", server, strings.Join(addresses, ", "))
+	}
+	if len(distinct) < 2 {
+		return ""
+	}
+	return detail
+}
@@ -0,0 +1,49 @@
+package kuberang
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNslookupAddresses(t *testing.T) {
+	tests := map[string]string{
+		`Server:    10.96.0.10
+Address 1: 10.96.0.10 kube-dns.kube-system.svc.cluster.local
+
+Name:      kuberang-nginx.default.svc.cluster.local
+Address 1: 10.100.2.3 kuberang-nginx.default.svc.cluster.local
+`: "10.100.2.3",
+		`Server:		8.8.8.8
+Address:	8.8.8.8:53
+
+Non-authoritative answer:
+Name:	example.com
+Address: 93.184.216.34
+Name:	example.com
+Address: 93.184.216.35
+`: "93.184.216.34 93.184.216.35",
+		`Server:		8.8.8.8
+Address:	8.8.8.8:53
+
+** server can't find kuberang-nginx: NXDOMAIN
+`: "",
+	}
+	for out, want := range tests {
+		got := nslookupAddresses(out)
+		if s := strings.Join(got, " "); s != want {
+			t.Errorf("Got %q, want %q from:\n%s", s, want, out)
+		}
+	}
+}
+
+func TestDNSAnswerMismatch(t *testing.T) {
+	servers := []string{"10.96.0.10", "169.254.20.10", "8.8.8.8"}
+	agree := map[string][]string{"10.96.0.10": {"10.100.2.3"}, "169.254.20.10": {"10.100.2.3"}}
+	if m := dnsAnswerMismatch(servers, agree); m != "" {
+		t.Errorf("Expected no mismatch, got %q", m)
+	}
+	disagree := map[string][]string{"10.96.0.10": {"10.100.2.3"}, "8.8.8.8": {"93.184.216.34"}}
+	if m := dnsAnswerMismatch(servers, disagree); m != "10.96.0.10 answered 10.100.2.3\n8.8.8.8 answered 93.184.216.34\n" {
+		t.Errorf("Unexpected mismatch %q", m)
+	}
+}
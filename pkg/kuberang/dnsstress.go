@@ -0,0 +1,79 @@
+package kuberang
+
+import (
+	"fmt"
+)
+
+// externalDNSName is the external name resolved by the DNS stress check
+const externalDNSName = "google.com"
+
+// DNSStressResult is the outcome of the DNS stress check for a single name.
+// Latencies are in milliseconds.
+type DNSStressResult struct {
+	Name        string  `json:"name"`
+	Lookups     int     `json:"lookups"`
+	Failures    int     `json:"failures"`
+	FailureRate float64 `json:"failureRate"`
+	P50Ms       float64 `json:"p50Ms"`
+	P95Ms       float64 `json:"p95Ms"`
+	P99Ms       float64 `json:"p99Ms"`
+}
+
+// checkDNSStress resolves the nginx service name, and an external name unless
+// offline, many times in quick succession from the BusyBox pod. Failure rates
+// above the threshold fail the check for the service name, and are ignored
+// for the external name.
+func checkDNSStress(r *reporter, w *workloads, busyboxPodName string) bool {
+	success := true
+	names := []string{w.ngService}
+	if !r.cfg.Offline {
+		names = append(names, externalDNSName)
+	}
+	for _, dnsName := range names {
+		name := fmt.Sprintf("Resolved %s %d times from BusyBox", dnsName, r.cfg.DNSStress)
+		ko := r.kube.run("exec", busyboxPodName, "--", "sh", "-c", dnsStressScript(dnsName, r.cfg.DNSStress))
+		if !ko.Success {
+			r.err("dns-stress", name, ko.CombinedOut)
+			success = false
+			continue
+		}
+		result := dnsStressResult(dnsName, parseTimings(ko.CombinedOut))
+		r.result.DNSStress = append(r.result.DNSStress, result)
+		name = fmt.Sprintf("%s: %.1f%% failed, p50 %.1fms, p95 %.1fms, p99 %.1fms",
+			name, result.FailureRate*100, result.P50Ms, result.P95Ms, result.P99Ms)
+		switch {
+		case result.FailureRate <= r.cfg.DNSStressMaxFailureRate:
+			r.ok("dns-stress", name)
+		case dnsName == externalDNSName:
+			r.ignored("dns-stress", name, fmt.Sprintf("%d of %d lookups failed\n", result.Failures, result.Lookups))
+		default:
+			r.err("dns-stress", name, fmt.Sprintf("%d of %d lookups failed, above the %.1f%% threshold\n", result.Failures, result.Lookups, r.cfg.DNSStressMaxFailureRate*100))
+			success = false
+		}
+	}
+	return success
+}
+
+// dnsStressScript returns the shell script resolving the name the given
+// number of times, printing the outcome of every lookup
+func dnsStressScript(name string, lookups int) string {
+	return fmt.Sprintf("for i in $(seq %d); do s=$(date +%%s%%N); if nslookup %s >/dev/null 2>&1; then echo ok $(($(date +%%s%%N)-s)); else echo err; fi; done", lookups, name)
+}
+
+// dnsStressResult summarizes the timings of the lookups of a name
+func dnsStressResult(name string, t timings) DNSStressResult {
+	result := DNSStressResult{
+		Name:     name,
+		Lookups:  len(t.latencies) + t.errors,
+		Failures: t.errors,
+	}
+	if result.Lookups > 0 {
+		result.FailureRate = float64(result.Failures) / float64(result.Lookups)
+	}
+	if len(t.latencies) > 0 {
+		result.P50Ms = percentile(t.latencies, 50)
+		result.P95Ms = percentile(t.latencies, 95)
+		result.P99Ms = percentile(t.latencies, 99)
+	}
+	return result
+}
@@ -0,0 +1,10 @@
+package kuberang
+
+import "testing"
+
+func TestDNSStressResult(t *testing.T) {
+	result := dnsStressResult("kuberang-nginx-1", parseTimings("ok 1000000\nerr\nok 2000000\nok 3000000\n"))
+	if result.Lookups != 4 || result.Failures != 1 || result.FailureRate != 0.25 || result.P50Ms != 2 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+}
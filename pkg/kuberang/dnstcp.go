@@ -0,0 +1,60 @@
+package kuberang
+
+import (
+	"fmt"
+	"strings"
+)
+
+// checkDNSOverTCP resolves the nginx service FQDN over TCP from a short-lived
+// probe pod running dig. BusyBox's nslookup cannot force TCP, so the check is
+// skipped when no probe image is configured.
+func checkDNSOverTCP(r *reporter, w *workloads) bool {
+	fqdn := w.serviceFQDN()
+	name := "Resolved Nginx service " + fqdn + " over TCP"
+	if r.cfg.DNSProbeImage == "" {
+		r.skipped("service-dns-tcp-from-pod", name, "BusyBox cannot force TCP lookups, set --dns-probe-image to an image providing dig")
+		return true
+	}
+	podName := fmt.Sprintf("kuberang-dns-probe-%d", w.testID)
+	image := w.image(r.cfg.DNSProbeImage)
+	command := []string{"dig", "+tcp", "+short", fqdn}
+	spec := map[string]interface{}{}
+	serviceAccountSpec(r.cfg, spec)
+	container := map[string]interface{}{
+		"name":      podName,
+		"image":     image,
+		"args":      command,
+		"stdin":     true,
+		"stdinOnce": true,
+	}
+	defaultFields := len(container)
+	podSecuritySpec(r.cfg, spec, container, false)
+	// The containers list is replaced as a whole by the overrides, so it is
+	// only set when the probe container needs more than the defaults
+	if len(container) > defaultFields {
+		spec["containers"] = []interface{}{container}
+	}
+	args := []string{"run", podName, "--rm", "-i", "--restart=Never", "--image=" + image, "--labels=" + w.labels("kuberang-dns-probe")}
+	args = append(args, podOverrides(spec)...)
+	args = append(args, "--")
+	args = append(args, command...)
+	ko := r.kube.run(args...)
+	if !ko.Success || !digAnswered(ko.CombinedOut) {
+		r.err("service-dns-tcp-from-pod", name, ko.CombinedOut)
+		return false
+	}
+	r.ok("service-dns-tcp-from-pod", name)
+	return true
+}
+
+// digAnswered returns true if the output of `dig +short` holds an answer.
+// Failed lookups print nothing, or a comment explaining the failure.
+func digAnswered(out string) bool {
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, ";") && !strings.HasPrefix(line, "pod ") {
+			return true
+		}
+	}
+	return false
+}
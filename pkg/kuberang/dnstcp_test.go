@@ -0,0 +1,17 @@
+package kuberang
+
+import "testing"
+
+func TestDigAnswered(t *testing.T) {
+	tests := map[string]bool{
+		"10.3.0.82\npod \"kuberang-dns-probe-1\" deleted\n":      true,
+		"pod \"kuberang-dns-probe-1\" deleted\n":                 false,
+		";; connection timed out; no servers could be reached\n": false,
+		"": false,
+	}
+	for out, answered := range tests {
+		if digAnswered(out) != answered {
+			t.Errorf("Expected digAnswered(%q) to be %v", out, answered)
+		}
+	}
+}
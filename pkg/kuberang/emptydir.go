@@ -0,0 +1,87 @@
+package kuberang
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// scratchMountPath is where the emptyDir volume is mounted in BusyBox
+	scratchMountPath = "/scratch"
+	// scratchSizeMB is the size of the file written to the emptyDir volume
+	scratchSizeMB = 4
+	// scratchWriteFailed is printed by the script when the file cannot be
+	// written to the emptyDir volume
+	scratchWriteFailed = "kuberang: write to the emptyDir volume failed"
+)
+
+// scratchVolumeSpec adds an emptyDir volume mounted at scratchMountPath to
+// the given BusyBox pod spec
+func scratchVolumeSpec(podSpec, container map[string]interface{}) {
+	appendToList(podSpec, "volumes", map[string]interface{}{
+		"name":     "kuberang-scratch",
+		"emptyDir": map[string]interface{}{},
+	})
+	appendToList(container, "volumeMounts", map[string]interface{}{
+		"name":      "kuberang-scratch",
+		"mountPath": scratchMountPath,
+	})
+}
+
+// checkEmptyDir writes a file to the emptyDir volume of BusyBox, reads it
+// back and compares the checksums of the data written and read
+func checkEmptyDir(r *reporter, w *workloads, busyboxPodName string) bool {
+	node := "unknown node"
+	if ko := r.kube.run("get", "pods", "-l", w.labels("kuberang-busybox"), "-o", "json"); ko.Success {
+		for _, p := range ko.Pods() {
+			if p.Name == busyboxPodName && p.NodeName != "" {
+				node = "node " + p.NodeName
+			}
+		}
+	}
+	name := fmt.Sprintf("Wrote and read back %dMB in an emptyDir volume on %s", scratchSizeMB, node)
+	ko := r.kube.run("exec", busyboxPodName, "--", "sh", "-c", emptyDirScript())
+	written, read, ok := parseChecksums(ko.CombinedOut)
+	switch {
+	case strings.Contains(ko.CombinedOut, scratchWriteFailed):
+		r.err("emptydir-write-read", name, "Could not write to the emptyDir volume, check the free space of the kubelet root directory on the node:\n"+ko.CombinedOut)
+		return false
+	case !ko.Success || !ok:
+		r.err("emptydir-write-read", name, ko.CombinedOut)
+		return false
+	case written != read:
+		r.err("emptydir-write-read", name, fmt.Sprintf("The data read back (md5 %s) differs from the data written (md5 %s), check the disk of the node\n", read, written))
+		return false
+	}
+	r.ok("emptydir-write-read", name)
+	return true
+}
+
+// emptyDirScript returns the shell script generating random data, writing
+// it to the emptyDir volume and printing the checksums of the data and of
+// the file read back. The write is a step of its own, so that its failure is
+// not hidden by the exit status of a later command.
+func emptyDirScript() string {
+	file := scratchMountPath + "/kuberang"
+	data := "/tmp/kuberang-scratch"
+	return fmt.Sprintf("set -e; trap 'rm -f %[1]s %[2]s' EXIT; "+
+		"dd if=/dev/urandom of=%[2]s bs=1048576 count=%[3]d 2>/dev/null; md5sum < %[2]s; "+
+		"tee %[1]s < %[2]s > /dev/null || { echo '%[4]s'; exit 1; }; md5sum < %[1]s",
+		file, data, scratchSizeMB, scratchWriteFailed)
+}
+
+// parseChecksums returns the two checksums printed by md5sum, the first for
+// the data written and the second for the data read back
+func parseChecksums(out string) (written, read string, ok bool) {
+	sums := []string{}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[1] == "-" && len(fields[0]) == 32 {
+			sums = append(sums, fields[0])
+		}
+	}
+	if len(sums) != 2 {
+		return "", "", false
+	}
+	return sums[0], sums[1], true
+}
@@ -0,0 +1,41 @@
+package kuberang
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestParseChecksums(t *testing.T) {
+	out := "d41d8cd98f00b204e9800998ecf8427e  -\n0cc175b9c0f1b6a831c399e269772661  -\n"
+	written, read, ok := parseChecksums(out)
+	if !ok || written != "d41d8cd98f00b204e9800998ecf8427e" || read != "0cc175b9c0f1b6a831c399e269772661" {
+		t.Errorf("Unexpected checksums: %q %q %v", written, read, ok)
+	}
+	if _, _, ok := parseChecksums("tee: /scratch/kuberang: No space left on device\nd41d8cd98f00b204e9800998ecf8427e  -\n"); ok {
+		t.Error("Expected a failed write to be detected")
+	}
+}
+
+func TestCheckEmptyDirWriteFailure(t *testing.T) {
+	defer func(c func(context.Context, []byte, ...string) ([]byte, error)) { kubectlCommand = c }(kubectlCommand)
+	kubectlCommand = func(ctx context.Context, input []byte, args ...string) ([]byte, error) {
+		if args[0] != "exec" {
+			return []byte(`{"items": []}`), nil
+		}
+		if !strings.Contains(args[len(args)-1], "set -e") {
+			t.Errorf("Expected the script to stop at the first failure, got %q", args[len(args)-1])
+		}
+		return []byte("d41d8cd98f00b204e9800998ecf8427e  -\ntee: /scratch/kuberang: No space left on device\n" + scratchWriteFailed + "\n"), errors.New("exit status 1")
+	}
+	result := &CheckResult{}
+	r := newReporter(ioutil.Discard, result)
+	if checkEmptyDir(r, newWorkloads(r.cfg, 1), "busybox") {
+		t.Fatal("Expected the check to fail")
+	}
+	if c := result.Checks[0]; c.Status != StatusError || !strings.Contains(c.Detail, "free space") {
+		t.Errorf("Expected the free space hint, got %+v", c)
+	}
+}
@@ -0,0 +1,99 @@
+package kuberang
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ephemeralNamespace returns the name of the namespace created for the run
+// when --ephemeral-namespace is set
+func ephemeralNamespace(testID int64) string {
+	return fmt.Sprintf("kuberang-%d", testID)
+}
+
+// isEphemeralNamespace returns true if name is the name of a namespace
+// created by --ephemeral-namespace
+func isEphemeralNamespace(name string) bool {
+	id := strings.TrimPrefix(name, kuberangPrefix)
+	if id == name || id == "" {
+		return false
+	}
+	_, err := strconv.ParseInt(id, 10, 64)
+	return err == nil
+}
+
+// createNamespace creates the ephemeral namespace of the run, labelled with
+// the test ID like the other objects of the run
+func createNamespace(r *reporter, w *workloads) bool {
+	name := "Created namespace " + r.cfg.Namespace
+	manifest, _ := json.Marshal(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Namespace",
+		"metadata": map[string]interface{}{
+			"name": r.cfg.Namespace,
+			"labels": map[string]interface{}{
+				"app":       "kuberang-namespace",
+				testIDLabel: fmt.Sprintf("%d", w.testID),
+			},
+		},
+	})
+	if ko := r.kube.runWithInput(manifest, "create", "-f", "-"); !ko.Success {
+		r.err("namespace-create", name, ko.CombinedOut)
+		return false
+	}
+	w.namespaceCreated = true
+	r.ok("namespace-create", name)
+	return true
+}
+
+// deleteNamespace deletes the ephemeral namespace of the run and waits for
+// it to be gone. A namespace stuck terminating points at finalizers that no
+// controller removes, so the objects left in it are listed along with their
+// finalizers.
+func deleteNamespace(r *reporter) bool {
+	name := "Deleted namespace " + r.cfg.Namespace
+	if ko := r.kube.run("delete", "namespace", r.cfg.Namespace, "--wait=false"); !ko.Success {
+		r.err("namespace-delete", name, ko.CombinedOut)
+		return false
+	}
+	start := time.Now()
+	for time.Since(start) < r.cfg.NamespaceDeletionTimeout {
+		if ko := r.kube.getNamespace(r.cfg.Namespace); !ko.Success && strings.Contains(ko.CombinedOut, "NotFound") {
+			r.ok("namespace-delete", fmt.Sprintf("%s in %v", name, time.Since(start)))
+			return true
+		}
+		if !sleep(r.ctx, 1*time.Second) {
+			break
+		}
+	}
+	r.err("namespace-delete", name, fmt.Sprintf("Namespace still terminating after %v\n%s", r.cfg.NamespaceDeletionTimeout, remainingObjects(r.kube)))
+	return false
+}
+
+// remainingObjects describes the objects left in the namespace along with
+// their finalizers
+func remainingObjects(k *kubectl) string {
+	ko := k.run("api-resources", "--verbs=list", "--namespaced", "-o", "name")
+	if !ko.Success {
+		return ko.CombinedOut
+	}
+	kinds := strings.Fields(ko.CombinedOut)
+	if ko = k.run("get", strings.Join(kinds, ","), "-o", "json", "--ignore-not-found"); !ko.Success {
+		return ko.CombinedOut
+	}
+	lines := []string{}
+	for _, o := range ko.Objects() {
+		line := fmt.Sprintf("%s %s", o.Kind, o.Name)
+		if len(o.Finalizers) > 0 {
+			line += " (finalizers: " + strings.Join(o.Finalizers, ", ") + ")"
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return "No objects left in the namespace, check the finalizers of the namespace itself\n"
+	}
+	return "Objects left in the namespace:\n" + strings.Join(lines, "\n") + "\n"
+}
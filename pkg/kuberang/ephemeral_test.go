@@ -0,0 +1,71 @@
+package kuberang
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeTerminatingNamespace simulates the deletion of a namespace, gone after
+// the given number of gets, or stuck terminating behind a PVC and a custom
+// resource with finalizers when negative
+func fakeTerminatingNamespace(gets int) func(context.Context, []byte, ...string) ([]byte, error) {
+	return func(ctx context.Context, input []byte, args ...string) ([]byte, error) {
+		// The global flags come first
+		for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+			args = args[1:]
+		}
+		command := strings.Join(args, " ")
+		switch {
+		case strings.HasPrefix(command, "delete namespace "):
+			return []byte(`namespace "kuberang-42" deleted` + "\n"), nil
+		case strings.HasPrefix(command, "get namespace "):
+			if gets == 0 {
+				return []byte(`Error from server (NotFound): namespaces "kuberang-42" not found` + "\n"), errors.New("exit status 1")
+			}
+			gets--
+			return []byte(`{"status": {"phase": "Terminating"}}`), nil
+		case strings.HasPrefix(command, "api-resources "):
+			return []byte("persistentvolumeclaims\nwidgets.example.com\n"), nil
+		case strings.HasPrefix(command, "get persistentvolumeclaims,widgets.example.com "):
+			return []byte(`{"items": [
+				{"kind": "PersistentVolumeClaim", "metadata": {"name": "data", "finalizers": ["kubernetes.io/pvc-protection"]}},
+				{"kind": "Widget", "metadata": {"name": "w1", "finalizers": ["example.com/cleanup", "example.com/audit"]}}
+			]}`), nil
+		}
+		return nil, errors.New("unexpected kubectl " + command)
+	}
+}
+
+func TestDeleteNamespace(t *testing.T) {
+	defer func(c func(context.Context, []byte, ...string) ([]byte, error)) { kubectlCommand = c }(kubectlCommand)
+	r := newReporter(ioutil.Discard, &CheckResult{})
+	r.cfg.Namespace = "kuberang-42"
+	r.cfg.NamespaceDeletionTimeout = time.Minute
+
+	kubectlCommand = fakeTerminatingNamespace(1)
+	if !deleteNamespace(r) || r.result.Checks[0].Status != StatusOK {
+		t.Errorf("Expected the namespace to be deleted, got %v", r.result.Checks)
+	}
+
+	kubectlCommand = fakeTerminatingNamespace(-1)
+	r = newReporter(ioutil.Discard, &CheckResult{})
+	r.cfg.Namespace = "kuberang-42"
+	r.cfg.NamespaceDeletionTimeout = time.Millisecond
+	if deleteNamespace(r) {
+		t.Fatal("Expected a namespace stuck terminating to fail the deletion")
+	}
+	c := r.result.Checks[0]
+	for _, line := range []string{
+		"Namespace still terminating after 1ms",
+		"PersistentVolumeClaim data (finalizers: kubernetes.io/pvc-protection)",
+		"Widget w1 (finalizers: example.com/cleanup, example.com/audit)",
+	} {
+		if c.Status != StatusError || !strings.Contains(c.Detail, line) {
+			t.Errorf("Expected %q in the failure detail, got %+v", line, c)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package kuberang
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseTaint parses a taint given as key[=value][:effect], the value and
+// effect matching any when left out
+func parseTaint(taint string) (Taint, error) {
+	t := Taint{}
+	s := taint
+	if i := strings.LastIndex(s, ":"); i >= 0 {
+		s, t.Effect = s[:i], s[i+1:]
+		switch t.Effect {
+		case "NoSchedule", "PreferNoSchedule", "NoExecute":
+		default:
+			return t, fmt.Errorf("Invalid --forbidden-taints effect %q, expected NoSchedule, PreferNoSchedule or NoExecute", t.Effect)
+		}
+	}
+	if i := strings.Index(s, "="); i >= 0 {
+		s, t.Value = s[:i], s[i+1:]
+	}
+	if s == "" {
+		return t, fmt.Errorf("Invalid --forbidden-taints taint %q, expected key[=value][:effect]", taint)
+	}
+	t.Key = s
+	return t, nil
+}
+
+// matchesTaint returns true if the taint of a node matches the forbidden
+// one, whose empty value and effect match any
+func matchesTaint(forbidden, t Taint) bool {
+	return forbidden.Key == t.Key &&
+		(forbidden.Value == "" || forbidden.Value == t.Value) &&
+		(forbidden.Effect == "" || forbidden.Effect == t.Effect)
+}
+
+// String returns the taint as key[=value]:effect
+func (t Taint) String() string {
+	s := t.Key
+	if t.Value != "" {
+		s += "=" + t.Value
+	}
+	if t.Effect != "" {
+		s += ":" + t.Effect
+	}
+	return s
+}
+
+// checkForbiddenPlacement verifies that none of the nginx and BusyBox pods
+// was scheduled onto a cordoned node or a node carrying one of the taints
+// given with --forbidden-taints, as broad tolerations allow. The node probes
+// are left out, as they tolerate every taint by design.
+func checkForbiddenPlacement(r *reporter, w *workloads) bool {
+	name := "No test pod landed on a cordoned node or a node with a forbidden taint"
+	forbidden := []Taint{}
+	for _, s := range r.cfg.ForbiddenTaints {
+		// Rejected by validateConfig
+		if t, err := parseTaint(s); err == nil {
+			forbidden = append(forbidden, t)
+		}
+	}
+	nko := r.kube.getNodes()
+	pko := r.kube.run("get", "pods", "-l", fmt.Sprintf("app in (kuberang-nginx,kuberang-busybox),kuberang/testid=%d", w.testID), "-o", "json")
+	if !nko.Success || !pko.Success {
+		r.err("forbidden-placement", name, nko.CombinedOut+pko.CombinedOut)
+		return false
+	}
+	if violations := forbiddenPlacements(pko.Pods(), nko.Nodes(), forbidden); len(violations) > 0 {
+		r.err("forbidden-placement", name, strings.Join(violations, ""))
+		return false
+	}
+	r.ok("forbidden-placement", name)
+	return true
+}
+
+// forbiddenPlacements describes the pods running on a cordoned node or on a
+// node carrying one of the forbidden taints
+func forbiddenPlacements(pods []Pod, nodes []Node, forbidden []Taint) []string {
+	byName := map[string]Node{}
+	for _, n := range nodes {
+		byName[n.Name] = n
+	}
+	violations := []string{}
+	for _, p := range pods {
+		n, ok := byName[p.NodeName]
+		if !ok {
+			continue
+		}
+		reasons := []string{}
+		if n.Unschedulable {
+			reasons = append(reasons, "is cordoned")
+		}
+		for _, t := range n.Taints {
+			for _, f := range forbidden {
+				if matchesTaint(f, t) {
+					reasons = append(reasons, "carries forbidden taint "+t.String())
+					break
+				}
+			}
+		}
+		if len(reasons) > 0 {
+			violations = append(violations, fmt.Sprintf("Pod %s runs on node %s, which %s\n", p.Name, p.NodeName, strings.Join(reasons, " and ")))
+		}
+	}
+	return violations
+}
@@ -0,0 +1,48 @@
+package kuberang
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTaint(t *testing.T) {
+	tests := map[string]Taint{
+		"dedicated":                  {Key: "dedicated"},
+		"dedicated=gpu":              {Key: "dedicated", Value: "gpu"},
+		"dedicated=gpu:NoSchedule":   {Key: "dedicated", Value: "gpu", Effect: "NoSchedule"},
+		"example.com/spot:NoExecute": {Key: "example.com/spot", Effect: "NoExecute"},
+	}
+	for s, expected := range tests {
+		if taint, err := parseTaint(s); err != nil || taint != expected {
+			t.Errorf("Expected %q to parse as %+v, got %+v (%v)", s, expected, taint, err)
+		}
+	}
+	for _, s := range []string{"", "=gpu", "dedicated:Never"} {
+		if _, err := parseTaint(s); err == nil {
+			t.Errorf("Expected %q to be rejected", s)
+		}
+	}
+}
+
+func TestForbiddenPlacements(t *testing.T) {
+	nodes := []Node{
+		{Name: "node1"},
+		{Name: "node2", Unschedulable: true},
+		{Name: "node3", Taints: []Taint{{Key: "dedicated", Value: "gpu", Effect: "NoSchedule"}}},
+		{Name: "node4", Taints: []Taint{{Key: "dedicated", Value: "infra", Effect: "NoSchedule"}}},
+	}
+	pods := []Pod{
+		{Name: "nginx-a", NodeName: "node1"},
+		{Name: "nginx-b", NodeName: "node2"},
+		{Name: "nginx-c", NodeName: "node3"},
+		{Name: "busybox", NodeName: "node4"},
+	}
+	forbidden := []Taint{{Key: "dedicated", Value: "gpu"}}
+	expected := []string{
+		"Pod nginx-b runs on node node2, which is cordoned\n",
+		"Pod nginx-c runs on node node3, which carries forbidden taint dedicated=gpu:NoSchedule\n",
+	}
+	if violations := forbiddenPlacements(pods, nodes, forbidden); !reflect.DeepEqual(violations, expected) {
+		t.Errorf("Expected %q, got %q", expected, violations)
+	}
+}
@@ -0,0 +1,100 @@
+package kuberang
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+// nodeDescription names the node from which the node-side checks run, as
+// used in check names
+func nodeDescription(cfg *config.Config) string {
+	if cfg.FromNode != "" {
+		return "node " + cfg.FromNode
+	}
+	return "this node"
+}
+
+// deployFromNodePod runs a privileged host network BusyBox pod on the node
+// given with --from-node, from which the node-side checks are run, and waits
+// for it to be ready
+func deployFromNodePod(r *reporter, w *workloads) bool {
+	name := "Node-side check pod ready on node " + r.cfg.FromNode
+	manifest, _ := json.Marshal(fromNodePod(w))
+	if ko := r.kube.runWithInput(manifest, "create", "-f", "-"); !ko.Success {
+		r.err("from-node-pod-ready", name, ko.CombinedOut)
+		return false
+	}
+	w.fromNodeDeployed = true
+
+	var ko KubeOutput
+	start := time.Now()
+	for time.Since(start) < deploymentTimeout {
+		if ko = r.kube.run("get", "pods", "-l", w.labels("kuberang-from-node"), "-o", "json"); ko.Success {
+			if pods := ko.Pods(); len(pods) > 0 && pods[0].Ready {
+				r.ok("from-node-pod-ready", name)
+				return true
+			}
+		}
+		if !sleep(r.ctx, 1*time.Second) {
+			break
+		}
+	}
+	r.err("from-node-pod-ready", name, "The pod did not become ready, make sure the node accepts privileged pods\n"+ko.CombinedOut)
+	return false
+}
+
+// removeFromNodePod deletes the pod running the node-side checks
+func removeFromNodePod(r *reporter, w *workloads) {
+	if ko := runDelete(r.kube, "pod", w.fromNode); ko.Success {
+		r.ok("cleanup-from-node-pod", "Powered down node-side check pod")
+	} else {
+		r.err("cleanup-from-node-pod", "Powered down node-side check pod", ko.CombinedOut)
+	}
+}
+
+// fromNodePod returns the pod bound to the node given with --from-node. It
+// shares the network namespace of the node and tolerates every taint, so
+// that control plane nodes can be chosen as well.
+func fromNodePod(w *workloads) map[string]interface{} {
+	spec := map[string]interface{}{
+		"nodeName":    w.cfg.FromNode,
+		"hostNetwork": true,
+		"dnsPolicy":   "Default",
+		"tolerations": []interface{}{
+			map[string]interface{}{"operator": "Exists"},
+		},
+		"containers": []interface{}{
+			map[string]interface{}{
+				"name":            "node",
+				"image":           w.image("busybox:latest"),
+				"imagePullPolicy": "IfNotPresent",
+				"command":         []string{"sleep", "3600"},
+				"securityContext": map[string]interface{}{"privileged": true},
+			},
+		},
+	}
+	serviceAccountSpec(w.cfg, spec)
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]interface{}{
+			"name": w.fromNode,
+			"labels": map[string]interface{}{
+				"app":             "kuberang-from-node",
+				"kuberang/testid": fmt.Sprintf("%d", w.testID),
+			},
+		},
+		"spec": spec,
+	}
+}
+
+// getFromNodePod accesses the URL from the node-side check pod
+func getFromNodePod(k *kubectl, w *workloads, url string) error {
+	if ko := k.run("exec", w.fromNode, "--", "wget", "-T", wgetTimeoutSeconds, "-qO-", url); !ko.Success {
+		return fmt.Errorf("%s", ko.CombinedOut)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package kuberang
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+func TestFromNodePod(t *testing.T) {
+	w := newWorkloads(&config.Config{FromNode: "node2"}, 42)
+	pod := fromNodePod(w)
+	metadata := pod["metadata"].(map[string]interface{})
+	if metadata["name"] != w.fromNode || metadata["labels"].(map[string]interface{})["kuberang/testid"] != "42" {
+		t.Errorf("Unexpected metadata %v", metadata)
+	}
+	spec := pod["spec"].(map[string]interface{})
+	if spec["nodeName"] != "node2" || spec["hostNetwork"] != true {
+		t.Errorf("Expected a host network pod bound to node2, got %v", spec)
+	}
+	tolerations := spec["tolerations"].([]interface{})
+	if len(tolerations) != 1 || tolerations[0].(map[string]interface{})["operator"] != "Exists" {
+		t.Errorf("Expected every taint to be tolerated, got %v", tolerations)
+	}
+	container := spec["containers"].([]interface{})[0].(map[string]interface{})
+	if container["securityContext"].(map[string]interface{})["privileged"] != true {
+		t.Errorf("Expected a privileged container, got %v", container)
+	}
+}
+
+func TestGetFromNode(t *testing.T) {
+	defer func(c func(context.Context, []byte, ...string) ([]byte, error)) { kubectlCommand = c }(kubectlCommand)
+	var execed []string
+	kubectlCommand = func(ctx context.Context, input []byte, args ...string) ([]byte, error) {
+		execed = args
+		if strings.HasSuffix(args[len(args)-1], "/down") {
+			return []byte("wget: can't connect to remote host\n"), errors.New("exit status 1")
+		}
+		return []byte("ok\n"), nil
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	r := newReporter(ioutil.Discard, &CheckResult{})
+	w := newWorkloads(r.cfg, 1)
+
+	if err := getFromNode(r.kube, w, http.DefaultClient, server.URL); err != nil || execed != nil {
+		t.Errorf("Expected the URL to be accessed from this node, got %v and %q", err, execed)
+	}
+
+	w.fromNodeDeployed = true
+	if err := getFromNode(r.kube, w, http.DefaultClient, "http://10.0.0.10/"); err != nil || execed[0] != "exec" || execed[1] != w.fromNode {
+		t.Errorf("Expected the URL to be accessed from the node-side check pod, got %v and %q", err, execed)
+	}
+	if err := getFromNode(r.kube, w, http.DefaultClient, "http://10.0.0.10/down"); err == nil || !strings.Contains(err.Error(), "can't connect") {
+		t.Errorf("Expected the wget output as error, got %v", err)
+	}
+}
+
+func TestDiscoverNodesFromNode(t *testing.T) {
+	defer func(c func(context.Context, []byte, ...string) ([]byte, error)) { kubectlCommand = c }(kubectlCommand)
+	kubectlCommand = func(ctx context.Context, input []byte, args ...string) ([]byte, error) {
+		return []byte(SampleNodeRespones), nil
+	}
+
+	r := newReporter(ioutil.Discard, &CheckResult{})
+	r.cfg.FromNode = "bogus"
+	if err := discoverNodes(r, newWorkloads(r.cfg, 1)); err == nil || !strings.Contains(err.Error(), "bogus") {
+		t.Errorf("Expected an error naming the unknown node, got %v", err)
+	}
+	if c, ok := checkOf(r.result, "from-node-exists"); !ok || c.Status != StatusError {
+		t.Errorf("Expected the from-node-exists check to fail, got %v", r.result.Checks)
+	}
+
+	r = newReporter(ioutil.Discard, &CheckResult{})
+	r.cfg.FromNode = "node2"
+	r.cfg.RequireAllNodes = true
+	if err := discoverNodes(r, newWorkloads(r.cfg, 1)); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if c, ok := checkOf(r.result, "from-node-exists"); !ok || c.Status != StatusOK {
+		t.Errorf("Expected the from-node-exists check to pass, got %v", r.result.Checks)
+	}
+}
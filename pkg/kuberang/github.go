@@ -0,0 +1,61 @@
+package kuberang
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+// OutputGitHub prints the checks along with GitHub Actions workflow
+// commands, so that failures surface as annotations of the workflow run
+const OutputGitHub = "github"
+
+// githubEscapeData escapes the message of a workflow command
+var githubEscapeData = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+
+// githubEscapeProperty escapes a property of a workflow command, such as
+// its title
+var githubEscapeProperty = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+
+// firstLine returns the first non-empty line of s
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// renderGitHub prints every check, then an error annotation for every
+// failed check and a warning annotation for every warned or ignored one,
+// titled with the check name and carrying the first line of its detail. The
+// full detail goes in a collapsible group. The usual summary comes last.
+func renderGitHub(cfg *config.Config, out io.Writer, result *CheckResult) error {
+	for _, c := range result.Checks {
+		printCheckLine(cfg, out, "", c)
+		command := ""
+		switch c.Status {
+		case StatusError:
+			command = "error"
+		case StatusWarning, StatusIgnored:
+			command = "warning"
+		default:
+			continue
+		}
+		reason := firstLine(c.Detail)
+		if reason == "" {
+			reason = c.ID + " " + c.Status
+		}
+		fmt.Fprintf(out, "::%s title=%s::%s\n", command, githubEscapeProperty.Replace(c.Name), githubEscapeData.Replace(reason))
+		if c.Detail != "" {
+			fmt.Fprintf(out, "::group::Output of %s\n", c.ID)
+			fmt.Fprint(out, strings.TrimRight(c.Detail, "\n")+"\n")
+			fmt.Fprintln(out, "::endgroup::")
+		}
+	}
+	printSummary(cfg, out, result)
+	return nil
+}
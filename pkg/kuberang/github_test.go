@@ -0,0 +1,36 @@
+package kuberang
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+func TestRenderGitHub(t *testing.T) {
+	result := &CheckResult{
+		Checks: []Check{
+			{ID: "service-ip-from-pod", Name: "Accessed Nginx service at 10.96.0.12 from BusyBox", Status: StatusOK},
+			{ID: "pod-ip-from-pod", Name: "Accessed Nginx pod at 172.16.0.5 from BusyBox", Status: StatusError, Detail: "\nwget: download timed out\ncommand terminated with exit code 1\n"},
+			{ID: "internet-from-node", Name: "Accessed Google.com from this node", Status: StatusIgnored},
+		},
+	}
+	var out bytes.Buffer
+	if err := renderGitHub(&config.Config{}, &out, result); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"::error title=Accessed Nginx pod at 172.16.0.5 from BusyBox::wget: download timed out\n",
+		"::group::Output of pod-ip-from-pod\n\nwget: download timed out\ncommand terminated with exit code 1\n::endgroup::\n",
+		"::warning title=Accessed Google.com from this node::internet-from-node ignored\n",
+		"1 check failed: pod-ip-from-pod\n",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Missing %q in:\n%s", expected, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "::error title=Accessed Nginx service") {
+		t.Error("Unexpected annotation for a check that passed")
+	}
+}
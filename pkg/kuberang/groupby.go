@@ -0,0 +1,86 @@
+package kuberang
+
+import (
+	"io"
+
+	"github.com/apprenda/kuberang/pkg/config"
+	"github.com/apprenda/kuberang/pkg/util"
+)
+
+// GroupByNode nests the checks pertaining to a node under that node
+const GroupByNode = "node"
+
+// renderGroupedByNode prints the checks that pertain to no node in order,
+// then the checks of every node under that node. Nodes whose checks all
+// passed are collapsed to a single line.
+func renderGroupedByNode(cfg *config.Config, out io.Writer, result *CheckResult) error {
+	nodes := []string{}
+	byNode := map[string][]Check{}
+	for _, c := range result.Checks {
+		if c.Node == "" {
+			printCheck(cfg, out, "", c)
+			continue
+		}
+		if _, ok := byNode[c.Node]; !ok {
+			nodes = append(nodes, c.Node)
+		}
+		byNode[c.Node] = append(byNode[c.Node], c)
+	}
+	for _, node := range nodes {
+		checks := byNode[node]
+		if allPassed(checks) {
+			if cfg.Verbosity >= 0 {
+				util.PrettyPrintOk(out, "Node %s: %s passed", node, countChecks(len(checks)))
+			}
+			continue
+		}
+		printLine(out, "Node %s:", node)
+		for _, c := range checks {
+			printCheck(cfg, out, "  ", c)
+		}
+	}
+	printSummary(cfg, out, result)
+	return nil
+}
+
+// allPassed returns true if none of the checks failed or warned
+func allPassed(checks []Check) bool {
+	for _, c := range checks {
+		if c.Status != StatusOK && c.Status != StatusSkipped {
+			return false
+		}
+	}
+	return true
+}
+
+// printCheck pretty prints a recorded check the way the reporter printed it
+// when it ran
+func printCheck(cfg *config.Config, out io.Writer, indent string, c Check) {
+	printCheckLine(cfg, out, indent, c)
+	if (c.Status == StatusError || c.Status == StatusWarning) && c.Detail != "" {
+		printFailureDetail(out, c.Detail)
+	}
+}
+
+// printCheckLine pretty prints the status line of a recorded check, leaving
+// out the checks that did not fail when only failures are printed
+func printCheckLine(cfg *config.Config, out io.Writer, indent string, c Check) {
+	switch c.Status {
+	case StatusError:
+		util.PrettyPrintErr(out, "%s%s", indent, c.Name)
+	case StatusWarning:
+		util.PrettyPrintWarn(out, "%s%s", indent, c.Name)
+	case StatusIgnored:
+		if cfg.Verbosity >= 0 {
+			util.PrettyPrintErrorIgnored(out, "%s%s", indent, c.Name)
+		}
+	case StatusSkipped:
+		if cfg.Verbosity >= 0 {
+			util.PrettyPrintSkipped(out, "%s%s", indent, c.Name)
+		}
+	default:
+		if cfg.Verbosity >= 0 {
+			util.PrettyPrintOk(out, "%s%s", indent, c.Name)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package kuberang
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+func TestRenderGroupedByNode(t *testing.T) {
+	result := &CheckResult{Checks: []Check{
+		{ID: "kubectl-configured", Name: "Kubectl configured on this node", Status: StatusOK},
+		{ID: "pod-ip-from-pod", Name: "Accessed Nginx pod at 10.2.0.4 from BusyBox", Status: StatusOK, Node: "node1"},
+		{ID: "pod-ip-from-pod", Name: "Accessed Nginx pod at 10.2.1.4 from BusyBox", Status: StatusError, Node: "node2", Detail: "wget: download timed out\n"},
+		{ID: "pod-ip-from-node", Name: "Accessed Nginx pod at 10.2.0.4 from this node", Status: StatusOK, Node: "node1"},
+		{ID: "pod-ip-from-node", Name: "Accessed Nginx pod at 10.2.1.4 from this node", Status: StatusOK, Node: "node2"},
+	}}
+	out := &bytes.Buffer{}
+	renderGroupedByNode(&config.Config{}, out, result)
+	s := out.String()
+	if !strings.Contains(s, "Node node1: 2 checks passed") {
+		t.Errorf("Expected node1 to be collapsed, got:\n%s", s)
+	}
+	if strings.Contains(s, "10.2.0.4") {
+		t.Errorf("Expected the checks of node1 to be hidden, got:\n%s", s)
+	}
+	node2 := strings.Index(s, "Node node2:")
+	if node2 < 0 || !strings.Contains(s[node2:], "  Accessed Nginx pod at 10.2.1.4 from BusyBox") || !strings.Contains(s[node2:], "wget: download timed out") {
+		t.Errorf("Expected the checks of node2 to be listed under it, got:\n%s", s)
+	}
+	if strings.Index(s, "Kubectl configured") > strings.Index(s, "Node node1") {
+		t.Errorf("Expected the checks without a node to come first, got:\n%s", s)
+	}
+}
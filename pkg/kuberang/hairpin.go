@@ -0,0 +1,75 @@
+package kuberang
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+// maxHairpinRequests bounds the requests a backend sends to its own service
+// until one of them is load-balanced back to it
+const maxHairpinRequests = 12
+
+// runHairpinCheck returns true if the hairpin check is enabled, either
+// explicitly or through the full profile, along with --identify-backends
+// which tells the backend answering every request
+func runHairpinCheck(cfg *config.Config) bool {
+	return cfg.IdentifyBackends && (cfg.HairpinCheck || cfg.Profile == ProfileFull)
+}
+
+// checkHairpin accesses the nginx service from one of its own backend pods
+// until a request is load-balanced back to the pod it comes from, which the
+// backends identified with --identify-backends tell. Such hairpin traffic
+// only works when the kubelet hairpin mode and the CNI bridge let a pod
+// reach itself through a service IP, which nothing else exercises. When the
+// service works from BusyBox, failing requests are called out as a hairpin
+// problem.
+func checkHairpin(r *reporter, nginxPods []Pod, serviceIP string, serviceOK bool) bool {
+	name := "Accessed Nginx service at " + serviceIP + " from its own backend (hairpin, pod→own service)"
+	backends := []Pod{}
+	for _, p := range nginxPods {
+		if p.Ready {
+			backends = append(backends, p)
+		}
+	}
+	if len(backends) == 0 {
+		r.skipped("hairpin-from-pod", name, "no nginx pod is Ready")
+		return true
+	}
+	pod := backends[0]
+	name = "Accessed Nginx service at " + serviceIP + " from its own backend " + pod.Name + " (hairpin, pod→own service)"
+	// Every backend being equally likely, enough requests for one of them
+	// to come back to the pod. Failed requests are not retried, as a retry
+	// landing on another backend would hide the failure.
+	requests := 3 * len(backends)
+	if requests > maxHairpinRequests {
+		requests = maxHairpinRequests
+	}
+	failures := []string{}
+	for i := 1; i <= requests; i++ {
+		ko := r.kube.run("exec", pod.Name, "--", "wget", "-T", wgetTimeoutSeconds, "-qO-", "http://"+serviceIP+"/")
+		switch {
+		case !ko.Success:
+			failures = append(failures, fmt.Sprintf("Request %d of %d failed: %s", i, requests, ko.CombinedOut))
+		case strings.TrimSpace(ko.CombinedOut) == pod.Name:
+			r.onNode(pod.NodeName).ok("hairpin-from-pod", name)
+			return true
+		}
+	}
+	if len(failures) == 0 {
+		r.onNode(pod.NodeName).skipped("hairpin-from-pod", name, fmt.Sprintf("not exercised, none of the %d requests was load-balanced back to %s", requests, pod.Name))
+		return true
+	}
+	detail := fmt.Sprintf("None of the %d requests was answered by %s\n", requests, pod.Name)
+	if serviceOK {
+		detail = fmt.Sprintf("BusyBox reaches the service but %s never got an answer from itself through it: check the hairpin mode of the kubelet (--hairpin-mode) and the hairpinMode of the CNI bridge plugin\n", pod.Name)
+	}
+	detail += strings.Join(failures, "")
+	if r.cfg.IgnorePodIPAccessibilityCheck {
+		r.onNode(pod.NodeName).ignored("hairpin-from-pod", name, detail)
+		return true
+	}
+	r.onNode(pod.NodeName).err("hairpin-from-pod", name, detail)
+	return false
+}
@@ -0,0 +1,97 @@
+package kuberang
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+func TestCheckHairpin(t *testing.T) {
+	defer func(c func(context.Context, []byte, ...string) ([]byte, error)) { kubectlCommand = c }(kubectlCommand)
+	// answers are the backends answering the successive requests, failing
+	// when empty
+	var answers []string
+	calls := 0
+	kubectlCommand = func(ctx context.Context, input []byte, args ...string) ([]byte, error) {
+		if args[0] != "exec" || args[1] != "nginx-a" {
+			t.Errorf("Expected the service to be accessed from the first ready nginx pod, got %q", args)
+		}
+		answer := answers[calls%len(answers)]
+		calls++
+		if answer == "" {
+			return []byte("wget: download timed out\n"), errors.New("exit status 1")
+		}
+		return []byte(answer + "\n"), nil
+	}
+	pods := []Pod{
+		{Name: "nginx-c", NodeName: "node1"},
+		{Name: "nginx-a", NodeName: "node2", Ready: true},
+		{Name: "nginx-b", NodeName: "node3", Ready: true},
+	}
+	answers, calls = []string{"nginx-b", "nginx-a"}, 0
+	r := newReporter(ioutil.Discard, &CheckResult{})
+	if !checkHairpin(r, pods, "10.96.0.42", true) || calls != 2 || r.result.Checks[0].Status != StatusOK {
+		t.Errorf("Expected the check to pass once nginx-a answered itself, got %d requests and %v", calls, r.result.Checks)
+	}
+
+	answers, calls = []string{"nginx-b"}, 0
+	r = newReporter(ioutil.Discard, &CheckResult{})
+	if !checkHairpin(r, pods, "10.96.0.42", true) || calls != 6 || r.result.Checks[0].Status != StatusSkipped {
+		t.Errorf("Expected the check not to be exercised after 3 requests per ready backend, got %d requests and %v", calls, r.result.Checks)
+	}
+
+	answers, calls = []string{"nginx-b", ""}, 0
+	r = newReporter(ioutil.Discard, &CheckResult{})
+	if checkHairpin(r, pods, "10.96.0.42", true) || calls != 6 {
+		t.Fatalf("Expected the check to fail, got %d requests", calls)
+	}
+	c := r.result.Checks[0]
+	if c.Node != "node2" || !strings.Contains(c.Detail, "hairpin mode") {
+		t.Errorf("Expected a hairpin failure on node2, got %+v", c)
+	}
+
+	answers, calls = []string{""}, 0
+	r = newReporter(ioutil.Discard, &CheckResult{})
+	r.cfg.IgnorePodIPAccessibilityCheck = true
+	if !checkHairpin(r, pods, "10.96.0.42", true) || r.result.Checks[0].Status != StatusIgnored {
+		t.Errorf("Expected the failure to be ignored, got %v", r.result.Checks)
+	}
+}
+
+func TestRunHairpinCheck(t *testing.T) {
+	tests := []struct {
+		cfg  config.Config
+		want bool
+	}{
+		{config.Config{Profile: ProfileStandard}, false},
+		{config.Config{Profile: ProfileFull}, false},
+		{config.Config{Profile: ProfileFull, IdentifyBackends: true}, true},
+		{config.Config{Profile: ProfileStandard, HairpinCheck: true, IdentifyBackends: true}, true},
+		{config.Config{Profile: ProfileStandard, IdentifyBackends: true}, false},
+	}
+	for _, test := range tests {
+		if got := runHairpinCheck(&test.cfg); got != test.want {
+			t.Errorf("runHairpinCheck(%+v) = %v, want %v", test.cfg, got, test.want)
+		}
+	}
+
+	cfg := &config.Config{
+		MinReadyFraction: 1,
+		ServiceAccount:   defaultServiceAccount,
+		Parallelism:      1,
+		Profile:          ProfileStandard,
+		NodeChecks:       NodeChecksIgnored,
+		HairpinCheck:     true,
+	}
+	if err := validateConfig(cfg); err == nil {
+		t.Error("Expected --hairpin-check to be rejected without --identify-backends")
+	}
+	cfg.IdentifyBackends = true
+	if err := validateConfig(cfg); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package kuberang
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+// requiredPermissions are the verbs and resources kuberang needs in the
+// namespace for its regular checks
+var requiredPermissions = [][2]string{
+	{"create", "deployments"},
+	{"delete", "deployments"},
+	{"create", "services"},
+	{"delete", "services"},
+	{"list", "pods"},
+	{"create", "pods/exec"},
+	{"list", "nodes"},
+}
+
+// impersonationArgs returns the kubectl arguments impersonating the user and
+// groups given with --as and --as-group
+func impersonationArgs(cfg *config.Config) []string {
+	args := []string{}
+	if cfg.As != "" {
+		args = append(args, "--as="+cfg.As)
+	}
+	for _, g := range cfg.AsGroups {
+		args = append(args, "--as-group="+g)
+	}
+	return args
+}
+
+// impersonatedIdentity describes the impersonated identity, or returns an
+// empty string when kubectl runs with its own credentials
+func impersonatedIdentity(cfg *config.Config) string {
+	if cfg.As == "" && len(cfg.AsGroups) == 0 {
+		return ""
+	}
+	identity := "user " + cfg.As
+	if cfg.As == "" {
+		identity = "the current user"
+	}
+	if len(cfg.AsGroups) > 0 {
+		identity += " in groups " + strings.Join(cfg.AsGroups, ", ")
+	}
+	return identity
+}
+
+// precheckPermissions verifies with `kubectl auth can-i` that the identity
+// kuberang runs as may do what the checks need. It is skipped when kubectl
+// cannot answer.
+func precheckPermissions(r *reporter) bool {
+	name := "Allowed to deploy and exec into the test workloads"
+	if identity := impersonatedIdentity(r.cfg); identity != "" {
+		name += " as " + identity
+	}
+	denied := []string{}
+	for _, p := range requiredPermissions {
+		ko := r.kube.run("auth", "can-i", p[0], p[1])
+		answer := strings.TrimSpace(ko.CombinedOut)
+		switch {
+		case ko.Success:
+		case strings.HasPrefix(answer, "no"):
+			denied = append(denied, p[0]+" "+p[1])
+		default:
+			r.skipped("rbac-permissions", name, "kubectl auth can-i failed: "+answer)
+			return true
+		}
+	}
+	if len(denied) > 0 {
+		r.err("rbac-permissions", name, fmt.Sprintf("Not allowed to %s\n", strings.Join(denied, ", ")))
+		return false
+	}
+	r.ok("rbac-permissions", name)
+	return true
+}
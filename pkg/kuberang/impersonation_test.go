@@ -0,0 +1,23 @@
+package kuberang
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+func TestImpersonation(t *testing.T) {
+	cfg := &config.Config{}
+	if len(impersonationArgs(cfg)) != 0 || impersonatedIdentity(cfg) != "" {
+		t.Errorf("Expected no impersonation by default")
+	}
+	cfg.As = "jane"
+	cfg.AsGroups = []string{"developers", "qa"}
+	if args := impersonationArgs(cfg); !reflect.DeepEqual(args, []string{"--as=jane", "--as-group=developers", "--as-group=qa"}) {
+		t.Errorf("Unexpected impersonation arguments: %v", args)
+	}
+	if identity := impersonatedIdentity(cfg); identity != "user jane in groups developers, qa" {
+		t.Errorf("Unexpected identity: %s", identity)
+	}
+}
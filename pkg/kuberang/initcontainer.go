@@ -0,0 +1,125 @@
+package kuberang
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// initContainerTimeout bounds the wait for the init container check pod
+const initContainerTimeout = 120 * time.Second
+
+// checkInitContainer runs a pod whose init container writes a sentinel file
+// to a shared emptyDir volume, and whose main container only keeps running
+// if it finds the file. The pod must become ready with its init container
+// completed.
+func checkInitContainer(r *reporter, w *workloads) bool {
+	name := "Init container ran before the main container"
+	manifest, _ := json.Marshal(initContainerPod(w))
+	if ko := r.kube.runWithInput(manifest, "create", "-f", "-"); !ko.Success {
+		r.err("init-container", name, ko.CombinedOut)
+		return false
+	}
+	w.initPodDeployed = true
+
+	var pod Pod
+	start := time.Now()
+	for time.Since(start) < initContainerTimeout {
+		if ko := r.kube.run("get", "pods", "-l", w.labels("kuberang-init"), "-o", "json"); ko.Success {
+			if pods := ko.Pods(); len(pods) > 0 {
+				pod = pods[0]
+			}
+		}
+		if pod.Ready || pod.Phase == "Failed" {
+			break
+		}
+		if !sleep(r.ctx, 1*time.Second) {
+			break
+		}
+	}
+	if pod.Ready && initContainerCompleted(pod) {
+		r.ok("init-container", name)
+		return true
+	}
+	detail := fmt.Sprintf("Pod %s is %s (ready: %v), init container %s\n", w.initPod, pod.Phase, pod.Ready, initContainerState(pod))
+	if ko := r.kube.run("logs", w.initPod, "-c", "write-sentinel"); ko.CombinedOut != "" {
+		detail += "Init container logs:\n" + ko.CombinedOut
+	}
+	r.err("init-container", name, detail)
+	return false
+}
+
+// initContainerCompleted returns true if every init container of the pod
+// terminated successfully
+func initContainerCompleted(pod Pod) bool {
+	if len(pod.InitContainers) == 0 {
+		return false
+	}
+	for _, s := range pod.InitContainers {
+		if s.State.Terminated == nil || s.State.Terminated.ExitCode != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// initContainerState describes the state of the first init container
+func initContainerState(pod Pod) string {
+	if len(pod.InitContainers) == 0 {
+		return "has no recorded status"
+	}
+	s := pod.InitContainers[0]
+	switch {
+	case s.State.Terminated != nil:
+		return fmt.Sprintf("terminated with reason %s and exit code %d", s.State.Terminated.Reason, s.State.Terminated.ExitCode)
+	case s.State.Waiting != nil:
+		return "waiting with reason " + s.State.Waiting.Reason
+	}
+	return "running"
+}
+
+// initContainerPod returns the pod of the init container check. It never
+// restarts, so that a failing container fails the pod.
+func initContainerPod(w *workloads) map[string]interface{} {
+	mounts := []interface{}{
+		map[string]interface{}{"name": "shared", "mountPath": "/shared"},
+	}
+	container := map[string]interface{}{
+		"name":            "check-sentinel",
+		"image":           w.image("busybox:latest"),
+		"imagePullPolicy": "IfNotPresent",
+		"command":         []string{"sh", "-c", "test -f /shared/sentinel && sleep 3600"},
+		"volumeMounts":    mounts,
+	}
+	spec := map[string]interface{}{
+		"restartPolicy": "Never",
+		"affinity":      nodeAffinity(w.cfg, w.nodes),
+		"volumes": []interface{}{
+			map[string]interface{}{"name": "shared", "emptyDir": map[string]interface{}{}},
+		},
+		"initContainers": []interface{}{
+			map[string]interface{}{
+				"name":            "write-sentinel",
+				"image":           w.image("busybox:latest"),
+				"imagePullPolicy": "IfNotPresent",
+				"command":         []string{"sh", "-c", "echo kuberang > /shared/sentinel"},
+				"volumeMounts":    mounts,
+			},
+		},
+		"containers": []interface{}{container},
+	}
+	serviceAccountSpec(w.cfg, spec)
+	podSecuritySpec(w.cfg, spec, container, false)
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]interface{}{
+			"name": w.initPod,
+			"labels": map[string]interface{}{
+				"app":             "kuberang-init",
+				"kuberang/testid": fmt.Sprintf("%d", w.testID),
+			},
+		},
+		"spec": spec,
+	}
+}
@@ -0,0 +1,18 @@
+package kuberang
+
+import "testing"
+
+func TestInitContainerState(t *testing.T) {
+	ko := KubeOutput{RawOut: []byte(`{"items": [{"metadata": {"name": "kuberang-init-1"}, "status": {"phase": "Failed",
+		"initContainerStatuses": [{"name": "write-sentinel", "state": {"terminated": {"reason": "Error", "exitCode": 1}}}]}}]}`)}
+	pod := ko.Pods()[0]
+	if initContainerCompleted(pod) {
+		t.Error("Expected a failed init container not to be completed")
+	}
+	if s := initContainerState(pod); s != "terminated with reason Error and exit code 1" {
+		t.Errorf("Unexpected state: %s", s)
+	}
+	if initContainerCompleted(Pod{}) {
+		t.Error("Expected a pod without init container status not to be completed")
+	}
+}
@@ -0,0 +1,89 @@
+package kuberang
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// jobTimeout bounds the wait for the job to complete
+const jobTimeout = 120 * time.Second
+
+// checkJob runs a job whose single pod exits successfully right away and
+// waits for the job controller to record its completion
+func checkJob(r *reporter, w *workloads) bool {
+	name := "Job ran to completion"
+	manifest, _ := json.Marshal(jobManifest(w))
+	if ko := r.kube.runWithInput(manifest, "create", "-f", "-"); !ko.Success {
+		r.err("job-completion", name, ko.CombinedOut)
+		return false
+	}
+	w.jobDeployed = true
+
+	start := time.Now()
+	for time.Since(start) < jobTimeout {
+		if ko := r.kube.run("get", "job", w.job, "-o", "json"); ko.Success {
+			if succeeded, _ := ko.JobCompletions(); succeeded == 1 {
+				r.ok("job-completion", fmt.Sprintf("%s in %v", name, time.Since(start)))
+				return true
+			}
+		}
+		if !sleep(r.ctx, 1*time.Second) {
+			break
+		}
+	}
+	detail := fmt.Sprintf("Job %s did not complete within %v, check the job controller\n", w.job, jobTimeout)
+	if ko := r.kube.run("describe", "pods", "-l", "job-name="+w.job); ko.Success {
+		detail += ko.CombinedOut
+	}
+	r.err("job-completion", name, detail)
+	return false
+}
+
+// removeJob deletes the job, waiting for the garbage collector to delete its
+// pods first
+func removeJob(r *reporter, w *workloads) {
+	if ko := runDelete(r.kube, "job", w.job, "--cascade=foreground"); ko.Success {
+		r.ok("cleanup-job", "Powered down job")
+	} else {
+		r.err("cleanup-job", "Powered down job", ko.CombinedOut)
+	}
+}
+
+// jobManifest returns a job running `true` in a BusyBox pod, without retries
+func jobManifest(w *workloads) map[string]interface{} {
+	labels := map[string]interface{}{
+		"app":             "kuberang-job",
+		"kuberang/testid": fmt.Sprintf("%d", w.testID),
+	}
+	container := map[string]interface{}{
+		"name":            "true",
+		"image":           w.image("busybox:latest"),
+		"imagePullPolicy": "IfNotPresent",
+		"command":         []string{"true"},
+	}
+	spec := map[string]interface{}{
+		"restartPolicy": "Never",
+		"affinity":      nodeAffinity(w.cfg, w.nodes),
+		"containers":    []interface{}{container},
+	}
+	serviceAccountSpec(w.cfg, spec)
+	podSecuritySpec(w.cfg, spec, container, false)
+	return map[string]interface{}{
+		"apiVersion": "batch/v1",
+		"kind":       "Job",
+		"metadata": map[string]interface{}{
+			"name":   w.job,
+			"labels": labels,
+		},
+		"spec": map[string]interface{}{
+			"backoffLimit": 0,
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"labels": labels,
+				},
+				"spec": spec,
+			},
+		},
+	}
+}
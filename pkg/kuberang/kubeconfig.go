@@ -0,0 +1,134 @@
+package kuberang
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apprenda/kuberang/pkg/config"
+	"gopkg.in/yaml.v2"
+)
+
+// kubeconfigFile holds the parts of a kubeconfig file that tell which
+// cluster kubectl talks to
+type kubeconfigFile struct {
+	CurrentContext string `yaml:"current-context"`
+	Contexts       []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster string `yaml:"cluster"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+	Clusters []struct {
+		Name string `yaml:"name"`
+	} `yaml:"clusters"`
+}
+
+// kubeconfigPaths returns the kubeconfig files kubectl reads, from
+// --kubeconfig, the KUBECONFIG variable or the default location
+func kubeconfigPaths(cfg *config.Config) []string {
+	if cfg.Kubeconfig != "" {
+		return []string{cfg.Kubeconfig}
+	}
+	paths := []string{}
+	for _, path := range filepath.SplitList(os.Getenv("KUBECONFIG")) {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) > 0 {
+		return paths
+	}
+	return []string{filepath.Join(os.Getenv("HOME"), ".kube", "config")}
+}
+
+// kubectlArgValue returns the value of a --flag=value given with
+// --kubectl-arg, or an empty string
+func kubectlArgValue(cfg *config.Config, flag string) string {
+	for _, arg := range cfg.KubectlArgs {
+		if strings.HasPrefix(arg, flag+"=") {
+			return strings.TrimPrefix(arg, flag+"=")
+		}
+	}
+	return ""
+}
+
+// checkKubeconfig validates the kubeconfig files without contacting the
+// cluster, and returns the ones found. Like kubectl, it ignores the missing
+// files listed in KUBECONFIG as long as one exists, lets the first file
+// setting a context or cluster win, and falls back to the in-cluster
+// configuration when running in a pod without a kubeconfig.
+func checkKubeconfig(cfg *config.Config) ([]string, error) {
+	found := []string{}
+	merged := kubeconfigFile{}
+	contexts := map[string]string{}
+	clusters := map[string]bool{}
+	paths := kubeconfigPaths(cfg)
+	for _, path := range paths {
+		b, err := ioutil.ReadFile(path)
+		if os.IsNotExist(err) && cfg.Kubeconfig == "" {
+			continue
+		}
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("kubeconfig not found: %s", path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("kubeconfig %s could not be read: %v", path, err)
+		}
+		found = append(found, path)
+		kc := kubeconfigFile{}
+		if err := yaml.Unmarshal(b, &kc); err != nil {
+			return nil, fmt.Errorf("kubeconfig %s could not be parsed: %v", path, err)
+		}
+		if merged.CurrentContext == "" {
+			merged.CurrentContext = kc.CurrentContext
+		}
+		for _, c := range kc.Contexts {
+			if _, ok := contexts[c.Name]; !ok {
+				contexts[c.Name] = c.Context.Cluster
+			}
+		}
+		for _, c := range kc.Clusters {
+			clusters[c.Name] = true
+		}
+	}
+	if len(found) == 0 {
+		if os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+			return found, nil
+		}
+		return nil, fmt.Errorf("kubeconfig not found: %s", strings.Join(paths, ", "))
+	}
+
+	current := merged.CurrentContext
+	if context := kubectlArgValue(cfg, "--context"); context != "" {
+		current = context
+	}
+	if current == "" {
+		return found, fmt.Errorf("no current context set in kubeconfig %s", strings.Join(found, ", "))
+	}
+	cluster, ok := contexts[current]
+	if !ok {
+		return found, fmt.Errorf("context %q not found in kubeconfig %s", current, strings.Join(found, ", "))
+	}
+	if !clusters[cluster] {
+		return found, fmt.Errorf("context %q references missing cluster %q", current, cluster)
+	}
+	return found, nil
+}
+
+// precheckKubeconfig reports on the validity of the kubeconfig kubectl uses
+func precheckKubeconfig(r *reporter) bool {
+	paths, err := checkKubeconfig(r.cfg)
+	if err != nil {
+		r.err("kubeconfig-valid", "Kubeconfig is valid", err.Error()+"\n")
+		return false
+	}
+	if len(paths) == 0 {
+		r.ok("kubeconfig-valid", "Using the in-cluster configuration")
+	} else {
+		r.ok("kubeconfig-valid", "Using kubeconfig "+strings.Join(paths, ", "))
+	}
+	return true
+}
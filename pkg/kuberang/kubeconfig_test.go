@@ -0,0 +1,93 @@
+package kuberang
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+const sampleKubeconfig = `apiVersion: v1
+kind: Config
+current-context: admin@prod
+contexts:
+- name: admin@prod
+  context:
+    cluster: prod
+    user: admin
+- name: admin@staging
+  context:
+    cluster: staging
+    user: admin
+clusters:
+- name: prod
+  cluster:
+    server: https://prod.example.com
+`
+
+// writeKubeconfig writes a kubeconfig file in dir and returns its path
+func writeKubeconfig(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCheckKubeconfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kuberang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	valid := writeKubeconfig(t, dir, "valid", sampleKubeconfig)
+	noContext := writeKubeconfig(t, dir, "no-context", strings.Replace(sampleKubeconfig, "current-context: admin@prod\n", "", 1))
+	broken := writeKubeconfig(t, dir, "broken", "contexts: [\n")
+
+	tests := []struct {
+		kubeconfig string
+		context    string
+		err        string
+	}{
+		{kubeconfig: valid},
+		{kubeconfig: filepath.Join(dir, "missing"), err: "kubeconfig not found"},
+		{kubeconfig: broken, err: "could not be parsed"},
+		{kubeconfig: noContext, err: "no current context"},
+		{kubeconfig: valid, context: "admin@dev", err: `context "admin@dev" not found`},
+		{kubeconfig: valid, context: "admin@staging", err: `context "admin@staging" references missing cluster "staging"`},
+	}
+	for _, test := range tests {
+		cfg := &config.Config{Kubeconfig: test.kubeconfig}
+		if test.context != "" {
+			cfg.KubectlArgs = []string{"--context=" + test.context}
+		}
+		paths, err := checkKubeconfig(cfg)
+		if test.err == "" {
+			if err != nil || len(paths) != 1 || paths[0] != test.kubeconfig {
+				t.Errorf("Expected %s to be valid, got %v (%v)", test.kubeconfig, paths, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Expected an error containing %q for %s, got %v", test.err, test.kubeconfig, err)
+		}
+	}
+}
+
+func TestCheckKubeconfigList(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kuberang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Setenv("KUBECONFIG", os.Getenv("KUBECONFIG"))
+	// The cluster of the current context is defined in the second file
+	clusters := writeKubeconfig(t, dir, "clusters", "clusters:\n- name: staging\n")
+	contexts := writeKubeconfig(t, dir, "contexts", strings.Replace(sampleKubeconfig, "current-context: admin@prod", "current-context: admin@staging", 1))
+	os.Setenv("KUBECONFIG", strings.Join([]string{filepath.Join(dir, "missing"), contexts, clusters}, string(filepath.ListSeparator)))
+	paths, err := checkKubeconfig(&config.Config{})
+	if err != nil || len(paths) != 2 {
+		t.Errorf("Expected the existing files to be merged, got %v (%v)", paths, err)
+	}
+}
@@ -1,9 +1,13 @@
 package kuberang
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os/exec"
+	"strings"
+	"time"
 
 	"github.com/apprenda/kuberang/pkg/config"
 )
@@ -14,43 +18,147 @@ type KubeOutput struct {
 	RawOut      []byte
 }
 
+// RunKubectl runs kubectl with the configuration of the package globals.
+//
+// Deprecated: the checks run kubectl with the configuration of their run;
+// RunKubectl will be removed along with the config globals.
 func RunKubectl(args ...string) KubeOutput {
-	if config.Kubeconfig != "" {
-		args = append([]string{"--kubeconfig=" + config.Kubeconfig}, args...)
+	return newKubectl(context.Background(), config.Globals()).run(args...)
+}
+
+// RunKubectlWithInput runs kubectl with the given input on its stdin, such as
+// a manifest passed to `kubectl create -f -`
+//
+// Deprecated: see RunKubectl.
+func RunKubectlWithInput(input []byte, args ...string) KubeOutput {
+	return newKubectl(context.Background(), config.Globals()).runWithInput(input, args...)
+}
+
+// kubectl runs the kubectl commands of a run with its configuration. Its
+// context is the context of the run, whose cancellation kills the kubectl
+// processes; the cleanup uses a copy with a context of its own.
+type kubectl struct {
+	cfg *config.Config
+	ctx context.Context
+	// tracer records the invocations when the run is traced
+	tracer *kubectlTrace
+}
+
+func newKubectl(ctx context.Context, cfg *config.Config) *kubectl {
+	return &kubectl{cfg: cfg, ctx: ctx}
+}
+
+// withContext returns a copy of k whose commands are killed when ctx is done
+func (k *kubectl) withContext(ctx context.Context) *kubectl {
+	c := *k
+	c.ctx = ctx
+	return &c
+}
+
+func (k *kubectl) run(args ...string) KubeOutput {
+	return k.runWithInput(nil, args...)
+}
+
+func (k *kubectl) runWithInput(input []byte, args ...string) KubeOutput {
+	if k.tracer == nil {
+		return k.runUntraced(input, args...)
+	}
+	start := time.Now()
+	ko := k.runUntraced(input, args...)
+	k.tracer.record(kubectlCall{args: args, start: start, end: time.Now(), success: ko.Success})
+	return ko
+}
+
+// kubectlCommand runs kubectl and returns its combined output, killing it
+// when ctx is done. It is used unless the configuration of the run sets a
+// KubectlRunner.
+var kubectlCommand = func(ctx context.Context, input []byte, args ...string) ([]byte, error) {
+	kubeCmd := exec.CommandContext(ctx, "kubectl", args...)
+	if input != nil {
+		kubeCmd.Stdin = bytes.NewReader(input)
 	}
+	return kubeCmd.CombinedOutput()
+}
 
-	if config.Namespace != "" {
-		args = append([]string{"--namespace=" + config.Namespace}, args...)
+func (k *kubectl) runUntraced(input []byte, args ...string) KubeOutput {
+	cfg := k.cfg
+	args = append(impersonationArgs(cfg), args...)
+	args = append(append([]string{}, cfg.KubectlArgs...), args...)
+	if cfg.Kubeconfig != "" {
+		args = append([]string{"--kubeconfig=" + cfg.Kubeconfig}, args...)
 	}
 
-	kubeCmd := exec.Command("kubectl", args...)
-	bytes, err := kubeCmd.CombinedOutput()
+	if cfg.Namespace != "" {
+		args = append([]string{"--namespace=" + cfg.Namespace}, args...)
+	}
+
+	logf(cfg.Verbosity, logCommands, "$ kubectl %s", strings.Join(args, " "))
+	start := time.Now()
+	command := kubectlCommand
+	if cfg.KubectlRunner != nil {
+		command = cfg.KubectlRunner
+	}
+	out, err := command(k.ctx, input, args...)
+	logf(cfg.Verbosity, logDebug, "kubectl finished in %v (error: %v)", time.Since(start), err)
+	logf(cfg.Verbosity, logOutput, "%s", out)
 	if err != nil {
+		combinedOut := string(out)
+		if identity := impersonatedIdentity(cfg); identity != "" && isForbidden(combinedOut) {
+			combinedOut += "(while impersonating " + identity + ")\n"
+		}
 		return KubeOutput{
 			Success:     false,
-			CombinedOut: string(bytes),
-			RawOut:      bytes,
+			CombinedOut: combinedOut,
+			RawOut:      out,
 		}
 	}
 	return KubeOutput{
 		Success:     true,
-		CombinedOut: string(bytes),
-		RawOut:      bytes,
+		CombinedOut: string(out),
+		RawOut:      out,
 	}
 }
 
+func (k *kubectl) getService(name string) KubeOutput {
+	return k.run("get", "service", name, "-o", "json")
+}
+
+func (k *kubectl) getDeployment(name string) KubeOutput {
+	return k.run("get", "deployment", name, "-o", "json")
+}
+
+func (k *kubectl) getNamespace(name string) KubeOutput {
+	return k.run("get", "namespace", name, "-o", "json")
+}
+
+func (k *kubectl) getNodes() KubeOutput {
+	return k.run("get", "nodes", "-o", "json")
+}
+
+// RunGetService gets a service as JSON.
+//
+// Deprecated: see RunKubectl.
 func RunGetService(svcName string) KubeOutput {
 	return RunKubectl("get", "service", svcName, "-o", "json")
 }
 
+// RunGetDeployment gets a deployment as JSON.
+//
+// Deprecated: see RunKubectl.
 func RunGetDeployment(name string) KubeOutput {
 	return RunKubectl("get", "deployment", name, "-o", "json")
 }
 
+// RunGetNamespace gets a namespace as JSON.
+//
+// Deprecated: see RunKubectl.
 func RunGetNamespace(name string) KubeOutput {
 	return RunKubectl("get", "namespace", name, "-o", "json")
 }
 
+// RunGetNodes gets the nodes as JSON.
+//
+// Deprecated: see RunKubectl.
 func RunGetNodes() KubeOutput {
 	return RunKubectl("get", "nodes", "-o", "json")
 }
@@ -62,11 +170,102 @@ func (ko KubeOutput) ObservedReplicaCount() int64 {
 }
 
 type DeploymentResponse struct {
+	Metadata struct {
+		Generation int64 `json:"generation"`
+	} `json:"metadata"`
+	Spec struct {
+		Replicas int64 `json:"replicas"`
+		Selector struct {
+			MatchLabels map[string]string `json:"matchLabels"`
+		} `json:"selector"`
+	} `json:"spec"`
+	Status struct {
+		AvaiableReplicas   int64 `json:"availableReplicas"`
+		ReadyReplicas      int64 `json:"readyReplicas"`
+		Replicas           int64 `json:"replicas"`
+		UpdatedReplicas    int64 `json:"updatedReplicas"`
+		ObservedGeneration int64 `json:"observedGeneration"`
+	} `json:"status"`
+}
+
+// DeploymentReadiness returns the number of ready and desired replicas of a
+// deployment
+func (ko KubeOutput) DeploymentReadiness() (ready int64, desired int64) {
+	resp := DeploymentResponse{}
+	json.Unmarshal(ko.RawOut, &resp)
+	return resp.Status.ReadyReplicas, resp.Spec.Replicas
+}
+
+type DaemonSetResponse struct {
+	Status struct {
+		NumberReady            int64 `json:"numberReady"`
+		DesiredNumberScheduled int64 `json:"desiredNumberScheduled"`
+	} `json:"status"`
+}
+
+// DaemonSetReadiness returns the number of ready and desired pods of a
+// daemonset
+func (ko KubeOutput) DaemonSetReadiness() (ready int64, desired int64) {
+	resp := DaemonSetResponse{}
+	json.Unmarshal(ko.RawOut, &resp)
+	return resp.Status.NumberReady, resp.Status.DesiredNumberScheduled
+}
+
+// DeploymentRolledOut returns true once the deployment controller has
+// observed the latest spec of a deployment and replaced all of its pods with
+// available pods of the latest template
+func (ko KubeOutput) DeploymentRolledOut() bool {
+	resp := DeploymentResponse{}
+	json.Unmarshal(ko.RawOut, &resp)
+	s := resp.Status
+	return s.ObservedGeneration >= resp.Metadata.Generation &&
+		s.UpdatedReplicas == resp.Spec.Replicas &&
+		s.Replicas == resp.Spec.Replicas &&
+		s.AvaiableReplicas == resp.Spec.Replicas
+}
+
+// DeploymentSelector returns the labels a deployment selects its pods by
+func (ko KubeOutput) DeploymentSelector() map[string]string {
+	resp := DeploymentResponse{}
+	json.Unmarshal(ko.RawOut, &resp)
+	return resp.Spec.Selector.MatchLabels
+}
+
+type JobResponse struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
 	Status struct {
-		AvaiableReplicas int64 `json:"availableReplicas"`
+		Succeeded int64 `json:"succeeded"`
+		Failed    int64 `json:"failed"`
 	} `json:"status"`
 }
 
+// JobCompletions returns the number of succeeded and failed pods of a job
+func (ko KubeOutput) JobCompletions() (succeeded int64, failed int64) {
+	resp := JobResponse{}
+	json.Unmarshal(ko.RawOut, &resp)
+	return resp.Status.Succeeded, resp.Status.Failed
+}
+
+type JobsResponse struct {
+	Items []JobResponse `json:"items"`
+}
+
+// SucceededJobs returns the names of the jobs of a list with at least one
+// succeeded pod
+func (ko KubeOutput) SucceededJobs() []string {
+	resp := JobsResponse{}
+	json.Unmarshal(ko.RawOut, &resp)
+	names := []string{}
+	for _, item := range resp.Items {
+		if item.Status.Succeeded > 0 {
+			names = append(names, item.Metadata.Name)
+		}
+	}
+	return names
+}
+
 func (ko KubeOutput) ServiceCluserIP() string {
 	resp := ServiceResponse{}
 	json.Unmarshal(ko.RawOut, &resp)
@@ -75,15 +274,75 @@ func (ko KubeOutput) ServiceCluserIP() string {
 
 type ServiceResponse struct {
 	Spec struct {
-		ClusterIP string `json:"clusterIP"`
+		ClusterIP string            `json:"clusterIP"`
+		Selector  map[string]string `json:"selector"`
+		Ports     []struct {
+			Name       string      `json:"name"`
+			Port       int64       `json:"port"`
+			TargetPort interface{} `json:"targetPort"`
+		} `json:"ports"`
 	} `json:"spec"`
 }
 
+// ServicePort is a port of a service along with the pod port it maps to
+type ServicePort struct {
+	Port int64
+	// TargetPort is either a port number or the name of a container port
+	TargetPort string
+}
+
+// ServicePorts returns the ports of a service
+func (ko KubeOutput) ServicePorts() []ServicePort {
+	resp := ServiceResponse{}
+	json.Unmarshal(ko.RawOut, &resp)
+	ports := make([]ServicePort, len(resp.Spec.Ports))
+	for i, p := range resp.Spec.Ports {
+		ports[i] = ServicePort{Port: p.Port, TargetPort: fmt.Sprint(p.TargetPort)}
+		if n, ok := p.TargetPort.(float64); ok {
+			ports[i].TargetPort = fmt.Sprintf("%d", int64(n))
+		}
+	}
+	return ports
+}
+
+// ServiceSelector returns the labels a service selects its endpoints by
+func (ko KubeOutput) ServiceSelector() map[string]string {
+	resp := ServiceResponse{}
+	json.Unmarshal(ko.RawOut, &resp)
+	return resp.Spec.Selector
+}
+
+type EndpointsResponse struct {
+	Subsets []struct {
+		Addresses []struct {
+			IP string `json:"ip"`
+		} `json:"addresses"`
+		Ports []struct {
+			Port int64 `json:"port"`
+		} `json:"ports"`
+	} `json:"subsets"`
+}
+
+// Endpoints returns the ready endpoints of a service as ip:port pairs
+func (ko KubeOutput) Endpoints() []string {
+	resp := EndpointsResponse{}
+	json.Unmarshal(ko.RawOut, &resp)
+	endpoints := []string{}
+	for _, s := range resp.Subsets {
+		for _, a := range s.Addresses {
+			for _, p := range s.Ports {
+				endpoints = append(endpoints, fmt.Sprintf("%s:%d", a.IP, p.Port))
+			}
+		}
+	}
+	return endpoints
+}
+
 func (ko KubeOutput) PodIPs() []string {
 	//In Scala, this code would be gorgeous. In Golang, it's a blood blister
 	resp := PodsResponse{}
 	if err := json.Unmarshal(ko.RawOut, &resp); err != nil {
-		fmt.Println(err)
+		return nil
 	}
 	podIPs := make([]string, len(resp.Items))
 	for i, item := range resp.Items {
@@ -92,12 +351,25 @@ func (ko KubeOutput) PodIPs() []string {
 	return podIPs
 }
 
+// ImageIDs returns the image IDs of the containers of every pod, by pod name.
+// Init containers are left out.
+func (ko KubeOutput) ImageIDs() map[string][]string {
+	resp := PodsResponse{}
+	json.Unmarshal(ko.RawOut, &resp)
+	ids := map[string][]string{}
+	for _, item := range resp.Items {
+		for _, s := range item.Status.ContainerStatuses {
+			ids[item.Metadata.Name] = append(ids[item.Metadata.Name], s.ImageID)
+		}
+	}
+	return ids
+}
+
 func (ko KubeOutput) FirstPodName() string {
 	resp := PodsResponse{}
 	if err := json.Unmarshal(ko.RawOut, &resp); err != nil {
-		fmt.Println(err)
+		return ""
 	}
-	json.Unmarshal(ko.RawOut, &resp)
 	if len(resp.Items) < 1 {
 		return ""
 	}
@@ -107,28 +379,233 @@ func (ko KubeOutput) FirstPodName() string {
 type PodsResponse struct {
 	Items []struct {
 		Metadata struct {
-			Name string `json:"name"`
+			Name   string            `json:"name"`
+			Labels map[string]string `json:"labels"`
 		} `json:"metadata"`
+		Spec struct {
+			NodeName   string `json:"nodeName"`
+			Containers []struct {
+				Ports []ContainerPort `json:"ports"`
+			} `json:"containers"`
+		} `json:"spec"`
 		Status struct {
-			PodIP string `json:"podIP"`
+			PodIP                 string            `json:"podIP"`
+			Phase                 string            `json:"phase"`
+			Conditions            []Condition       `json:"conditions"`
+			InitContainerStatuses []ContainerStatus `json:"initContainerStatuses"`
+			ContainerStatuses     []ContainerStatus `json:"containerStatuses"`
 		} `json:"status"`
 	} `json:"items"`
 }
 
+// Pod is the subset of a kubernetes pod that kuberang cares about
+type Pod struct {
+	Name     string
+	IP       string
+	NodeName string
+	Phase    string
+	Ready    bool
+	// WaitingReason is the reason the first waiting container, init
+	// containers included, is waiting for, such as ErrImagePull
+	WaitingReason string
+	// Restarts is the number of container restarts, init containers included
+	Restarts   int64
+	Conditions []Condition
+	Labels     map[string]string
+	// InitContainers holds the status of every init container
+	InitContainers []ContainerStatus
+	// Ports are the ports declared by the containers of the pod
+	Ports []ContainerPort
+}
+
+// ContainerPort is a port declared by a container
+type ContainerPort struct {
+	Name          string `json:"name"`
+	ContainerPort int64  `json:"containerPort"`
+}
+
+// ContainerStatus is the status of a single container of a pod
+type ContainerStatus struct {
+	Name         string `json:"name"`
+	ImageID      string `json:"imageID"`
+	RestartCount int64  `json:"restartCount"`
+	State        struct {
+		Waiting *struct {
+			Reason string `json:"reason"`
+		} `json:"waiting"`
+		Terminated *struct {
+			Reason   string `json:"reason"`
+			ExitCode int64  `json:"exitCode"`
+		} `json:"terminated"`
+	} `json:"state"`
+}
+
+// restartCount returns the total number of restarts of the containers
+func restartCount(statuses ...[]ContainerStatus) int64 {
+	var restarts int64
+	for _, ss := range statuses {
+		for _, s := range ss {
+			restarts += s.RestartCount
+		}
+	}
+	return restarts
+}
+
+// waitingReason returns the reason of the first waiting container
+func waitingReason(statuses ...[]ContainerStatus) string {
+	for _, ss := range statuses {
+		for _, s := range ss {
+			if s.State.Waiting != nil && s.State.Waiting.Reason != "" {
+				return s.State.Waiting.Reason
+			}
+		}
+	}
+	return ""
+}
+
+func (ko KubeOutput) Pods() []Pod {
+	resp := PodsResponse{}
+	json.Unmarshal(ko.RawOut, &resp)
+	pods := make([]Pod, len(resp.Items))
+	for i, item := range resp.Items {
+		pods[i] = Pod{
+			Name:     item.Metadata.Name,
+			IP:       item.Status.PodIP,
+			NodeName: item.Spec.NodeName,
+			Phase:    item.Status.Phase,
+			Ready:    isConditionTrue(item.Status.Conditions, "Ready"),
+
+			WaitingReason: waitingReason(item.Status.InitContainerStatuses, item.Status.ContainerStatuses),
+			Restarts:      restartCount(item.Status.InitContainerStatuses, item.Status.ContainerStatuses),
+			Conditions:    item.Status.Conditions,
+			Labels:        item.Metadata.Labels,
+
+			InitContainers: item.Status.InitContainerStatuses,
+		}
+		for _, c := range item.Spec.Containers {
+			pods[i].Ports = append(pods[i].Ports, c.Ports...)
+		}
+	}
+	return pods
+}
+
 type NodeResponse struct {
 	Items []struct {
+		Metadata struct {
+			Name   string            `json:"name"`
+			Labels map[string]string `json:"labels"`
+		} `json:"metadata"`
 		Spec struct {
-			Unschedulable bool `json:"unschedulable,omitempty"`
+			Taints        []Taint  `json:"taints,omitempty"`
+			Unschedulable bool     `json:"unschedulable,omitempty"`
+			PodCIDR       string   `json:"podCIDR,omitempty"`
+			PodCIDRs      []string `json:"podCIDRs,omitempty"`
 		} `json:"spec"`
+		Status struct {
+			Conditions []Condition   `json:"conditions"`
+			Addresses  []NodeAddress `json:"addresses"`
+		} `json:"status"`
 	} `json:"items"`
 }
 
-func (ko KubeOutput) NodeCount() int {
+// Taint is a taint of a node, repelling the pods that do not tolerate it
+type Taint struct {
+	Key    string `json:"key"`
+	Value  string `json:"value,omitempty"`
+	Effect string `json:"effect"`
+}
+
+// NodeAddress is one of the addresses of a node
+type NodeAddress struct {
+	Type    string `json:"type"`
+	Address string `json:"address"`
+}
+
+// Condition is a status condition of a kubernetes object
+type Condition struct {
+	Type               string    `json:"type"`
+	Status             string    `json:"status"`
+	Reason             string    `json:"reason,omitempty"`
+	Message            string    `json:"message,omitempty"`
+	LastTransitionTime time.Time `json:"lastTransitionTime"`
+}
+
+// findCondition returns the condition of the given type, or nil if the
+// object does not have it
+func findCondition(conditions []Condition, conditionType string) *Condition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// isConditionTrue returns true if the condition of the given type is true
+func isConditionTrue(conditions []Condition, conditionType string) bool {
+	c := findCondition(conditions, conditionType)
+	return c != nil && c.Status == "True"
+}
+
+// Node is the subset of a kubernetes node that kuberang cares about
+type Node struct {
+	Name          string
+	Unschedulable bool
+	Ready         bool
+	InternalIP    string
+	// PodCIDRs are the ranges assigned to the pods of the node, empty when
+	// the cluster does not allocate per-node ranges
+	PodCIDRs []string
+	Labels   map[string]string
+	Taints   []Taint
+}
+
+func (ko KubeOutput) Nodes() []Node {
 	resp := NodeResponse{}
 	json.Unmarshal(ko.RawOut, &resp)
+	nodes := make([]Node, len(resp.Items))
+	for i, item := range resp.Items {
+		nodes[i] = Node{
+			Name:          item.Metadata.Name,
+			Unschedulable: item.Spec.Unschedulable,
+			Ready:         isConditionTrue(item.Status.Conditions, "Ready"),
+			InternalIP:    nodeAddress(item.Status.Addresses, "InternalIP"),
+			PodCIDRs:      item.Spec.PodCIDRs,
+			Labels:        item.Metadata.Labels,
+			Taints:        item.Spec.Taints,
+		}
+		if len(nodes[i].PodCIDRs) == 0 && item.Spec.PodCIDR != "" {
+			nodes[i].PodCIDRs = []string{item.Spec.PodCIDR}
+		}
+	}
+	return nodes
+}
+
+// nodeAddress returns the first address of the given type
+func nodeAddress(addresses []NodeAddress, addressType string) string {
+	for _, a := range addresses {
+		if a.Type == addressType {
+			return a.Address
+		}
+	}
+	return ""
+}
+
+// NodeCount returns the number of nodes that accept the test workloads with
+// the configuration of the package globals.
+//
+// Deprecated: use NodeCountFor with the configuration of the run; NodeCount
+// will be removed along with the config globals.
+func (ko KubeOutput) NodeCount() int {
+	return ko.NodeCountFor(config.Globals())
+}
+
+// NodeCountFor returns the number of nodes that accept the test workloads
+// with the given configuration
+func (ko KubeOutput) NodeCountFor(cfg *config.Config) int {
 	count := 0
-	for _, item := range resp.Items {
-		if item.Spec.Unschedulable == false {
+	for _, n := range ko.Nodes() {
+		if acceptsTestPods(cfg, n) {
 			count++
 		}
 	}
@@ -146,3 +623,146 @@ type NamespaceResponse struct {
 		Phase string `json:"phase"`
 	} `json:"status"`
 }
+
+type ObjectsResponse struct {
+	Items []struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Name              string            `json:"name"`
+			Namespace         string            `json:"namespace"`
+			Labels            map[string]string `json:"labels"`
+			CreationTimestamp time.Time         `json:"creationTimestamp"`
+			Finalizers        []string          `json:"finalizers"`
+			OwnerReferences   []struct {
+				Kind string `json:"kind"`
+			} `json:"ownerReferences"`
+		} `json:"metadata"`
+		Spec struct {
+			Type      string `json:"type"`
+			ClusterIP string `json:"clusterIP"`
+		} `json:"spec"`
+		Status struct {
+			Phase                  string `json:"phase"`
+			Replicas               int64  `json:"replicas"`
+			ReadyReplicas          int64  `json:"readyReplicas"`
+			NumberReady            int64  `json:"numberReady"`
+			DesiredNumberScheduled int64  `json:"desiredNumberScheduled"`
+			Succeeded              int64  `json:"succeeded"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// Object is a kubernetes object of any kind, with a short status summary
+type Object struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Labels    map[string]string
+	Created   time.Time
+	// Owned is set when the object is managed by another object
+	Owned      bool
+	Status     string
+	Finalizers []string
+}
+
+func (ko KubeOutput) Objects() []Object {
+	resp := ObjectsResponse{}
+	json.Unmarshal(ko.RawOut, &resp)
+	objects := make([]Object, len(resp.Items))
+	for i, item := range resp.Items {
+		o := Object{
+			Kind:      item.Kind,
+			Namespace: item.Metadata.Namespace,
+			Name:      item.Metadata.Name,
+			Labels:    item.Metadata.Labels,
+			Created:   item.Metadata.CreationTimestamp,
+			Owned:     len(item.Metadata.OwnerReferences) > 0,
+
+			Finalizers: item.Metadata.Finalizers,
+		}
+		switch item.Kind {
+		case "Pod":
+			o.Status = item.Status.Phase
+		case "Deployment", "ReplicaSet":
+			o.Status = fmt.Sprintf("%d/%d ready", item.Status.ReadyReplicas, item.Status.Replicas)
+		case "DaemonSet":
+			o.Status = fmt.Sprintf("%d/%d ready", item.Status.NumberReady, item.Status.DesiredNumberScheduled)
+		case "Service":
+			o.Status = item.Spec.Type + " " + item.Spec.ClusterIP
+		case "Job":
+			o.Status = fmt.Sprintf("%d succeeded", item.Status.Succeeded)
+		}
+		objects[i] = o
+	}
+	return objects
+}
+
+type APIServicesResponse struct {
+	Items []struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Spec struct {
+			Service *struct {
+				Namespace string `json:"namespace"`
+				Name      string `json:"name"`
+			} `json:"service"`
+		} `json:"spec"`
+		Status struct {
+			Conditions []Condition `json:"conditions"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// APIService is a registered API group version, served by the API server
+// itself or by an aggregated API server behind a service
+type APIService struct {
+	Name string
+	// Service is the namespace/name of the service of an aggregated API,
+	// empty for the APIs served locally
+	Service string
+	// Available is the Available condition, nil if not reported yet
+	Available *Condition
+}
+
+func (ko KubeOutput) APIServices() []APIService {
+	resp := APIServicesResponse{}
+	json.Unmarshal(ko.RawOut, &resp)
+	services := make([]APIService, len(resp.Items))
+	for i, item := range resp.Items {
+		services[i] = APIService{
+			Name:      item.Metadata.Name,
+			Available: findCondition(item.Status.Conditions, "Available"),
+		}
+		if s := item.Spec.Service; s != nil {
+			services[i].Service = s.Namespace + "/" + s.Name
+		}
+	}
+	return services
+}
+
+type ConditionsResponse struct {
+	Status struct {
+		Conditions []Condition `json:"conditions"`
+	} `json:"status"`
+}
+
+// StatusConditions returns the status conditions of an object of any kind
+func (ko KubeOutput) StatusConditions() []Condition {
+	resp := ConditionsResponse{}
+	json.Unmarshal(ko.RawOut, &resp)
+	return resp.Status.Conditions
+}
+
+type ObjectResponse struct {
+	Metadata struct {
+		Labels map[string]string `json:"labels"`
+	} `json:"metadata"`
+}
+
+// ObjectLabels returns the labels of an object of any kind
+func (ko KubeOutput) ObjectLabels() map[string]string {
+	resp := ObjectResponse{}
+	json.Unmarshal(ko.RawOut, &resp)
+	return resp.Metadata.Labels
+}
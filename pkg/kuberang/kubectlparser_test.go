@@ -1,6 +1,12 @@
 package kuberang
 
-import "testing"
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
 
 func TestNodeCount(t *testing.T) {
 
@@ -9,9 +15,33 @@ func TestNodeCount(t *testing.T) {
 		CombinedOut: SampleNodeRespones,
 		RawOut:      []byte(SampleNodeRespones),
 	}
-	if nodesCount := ko.NodeCount(); nodesCount != 3 {
+	if nodesCount := ko.NodeCountFor(&config.Config{}); nodesCount != 3 {
 		t.Errorf("Wrong number of nodes, expeted 3, got %d", nodesCount)
 	}
+	if nodesCount := ko.NodeCount(); nodesCount != 3 {
+		t.Errorf("Wrong number of nodes with the config globals, expeted 3, got %d", nodesCount)
+	}
+}
+
+func TestNodes(t *testing.T) {
+	ko := KubeOutput{
+		Success:     true,
+		CombinedOut: SampleNodeRespones,
+		RawOut:      []byte(SampleNodeRespones),
+	}
+	nodes := ko.Nodes()
+	if len(nodes) != 4 {
+		t.Fatalf("Wrong number of nodes, expected 4, got %d", len(nodes))
+	}
+	if nodes[0].Name != "node1" || !nodes[0].Unschedulable || nodes[0].InternalIP != "192.168.205.11" {
+		t.Errorf("Expected node1 to be unschedulable, got %+v", nodes[0])
+	}
+	if nodes[1].Name != "node2" || nodes[1].Unschedulable {
+		t.Errorf("Expected node2 to be schedulable, got %+v", nodes[1])
+	}
+	if len(nodes[1].PodCIDRs) != 1 || nodes[1].PodCIDRs[0] != "172.16.3.0/24" {
+		t.Errorf("Expected node2 to have pod CIDR 172.16.3.0/24, got %v", nodes[1].PodCIDRs)
+	}
 }
 
 const SampleNodeRespones = `
@@ -482,3 +512,271 @@ const SampleNamespaceResponse = `
     }
 }
 `
+
+func TestPods(t *testing.T) {
+	ko := KubeOutput{
+		Success:     true,
+		CombinedOut: SamplePodsResponse,
+		RawOut:      []byte(SamplePodsResponse),
+	}
+	pods := ko.Pods()
+	if len(pods) != 2 {
+		t.Fatalf("Wrong number of pods, expected 2, got %d", len(pods))
+	}
+	if pods[0].Name != "kuberang-nginx-3294355564-1rvn5" || pods[0].IP != "172.16.3.5" || pods[0].NodeName != "node2" || !pods[0].Ready || pods[0].Phase != "Running" {
+		t.Errorf("Unexpected first pod: %+v", pods[0])
+	}
+	if pods[1].Restarts != 3 || pods[1].WaitingReason != "" {
+		t.Errorf("Expected the second pod to have restarted 3 times, got %+v", pods[1])
+	}
+	if podIPs := ko.PodIPs(); len(podIPs) != 2 || podIPs[1] != "172.16.4.7" {
+		t.Errorf("Unexpected pod IPs: %v", podIPs)
+	}
+}
+
+const SamplePodsResponse = `
+{
+    "kind": "List",
+    "apiVersion": "v1",
+    "metadata": {},
+    "items": [
+        {
+            "kind": "Pod",
+            "apiVersion": "v1",
+            "metadata": {
+                "name": "kuberang-nginx-3294355564-1rvn5",
+                "namespace": "default",
+                "labels": {
+                    "app": "kuberang-nginx",
+                    "kuberang/testid": "1479311348283746187",
+                    "pod-template-hash": "3294355564"
+                }
+            },
+            "spec": {
+                "containers": [
+                    {
+                        "name": "kuberang-nginx",
+                        "image": "nginx:stable-alpine",
+                        "imagePullPolicy": "IfNotPresent"
+                    }
+                ],
+                "restartPolicy": "Always",
+                "nodeName": "node2"
+            },
+            "status": {
+                "phase": "Running",
+                "conditions": [
+                    {
+                        "type": "Ready",
+                        "status": "True"
+                    }
+                ],
+                "hostIP": "192.168.205.12",
+                "podIP": "172.16.3.5",
+                "containerStatuses": [
+                    {
+                        "name": "kuberang-nginx",
+                        "ready": true,
+                        "restartCount": 0,
+                        "image": "nginx:stable-alpine",
+                        "imageID": "docker-pullable://nginx@sha256:5aadb68304a38a8e2719605e4e180413f390cd6647602bee9bdedd59753c3590"
+                    }
+                ]
+            }
+        },
+        {
+            "kind": "Pod",
+            "apiVersion": "v1",
+            "metadata": {
+                "name": "kuberang-nginx-3294355564-x8k2p",
+                "namespace": "default",
+                "labels": {
+                    "app": "kuberang-nginx",
+                    "kuberang/testid": "1479311348283746187",
+                    "pod-template-hash": "3294355564"
+                }
+            },
+            "spec": {
+                "containers": [
+                    {
+                        "name": "kuberang-nginx",
+                        "image": "nginx:stable-alpine",
+                        "imagePullPolicy": "IfNotPresent"
+                    }
+                ],
+                "restartPolicy": "Always",
+                "nodeName": "node3"
+            },
+            "status": {
+                "phase": "Running",
+                "conditions": [
+                    {
+                        "type": "Ready",
+                        "status": "True"
+                    }
+                ],
+                "hostIP": "192.168.205.13",
+                "podIP": "172.16.4.7",
+                "containerStatuses": [
+                    {
+                        "name": "kuberang-nginx",
+                        "ready": true,
+                        "restartCount": 3,
+                        "image": "nginx:stable-alpine",
+                        "imageID": "docker-pullable://nginx@sha256:5aadb68304a38a8e2719605e4e180413f390cd6647602bee9bdedd59753c3590"
+                    }
+                ]
+            }
+        }
+    ]
+}
+`
+
+func TestObjects(t *testing.T) {
+	ko := KubeOutput{
+		Success:     true,
+		CombinedOut: SampleObjectsResponse,
+		RawOut:      []byte(SampleObjectsResponse),
+	}
+	objects := ko.Objects()
+	if len(objects) != 3 {
+		t.Fatalf("Wrong number of objects, expected 3, got %d", len(objects))
+	}
+	if o := objects[0]; o.Kind != "Deployment" || o.Namespace != "team-a" || o.Status != "0/1 ready" || o.Owned {
+		t.Errorf("Unexpected deployment: %+v", o)
+	}
+	if o := objects[1]; o.Kind != "Pod" || o.Status != "Pending" || !o.Owned {
+		t.Errorf("Unexpected pod: %+v", o)
+	}
+	if o := objects[2]; o.Kind != "Service" || o.Status != "ClusterIP 10.3.0.82" {
+		t.Errorf("Unexpected service: %+v", o)
+	}
+	if objects[2].Created.IsZero() {
+		t.Errorf("Expected the creation timestamp to be parsed")
+	}
+}
+
+const SampleObjectsResponse = `
+{
+    "kind": "List",
+    "apiVersion": "v1",
+    "metadata": {},
+    "items": [
+        {
+            "kind": "Deployment",
+            "apiVersion": "apps/v1",
+            "metadata": {
+                "name": "kuberang-nginx",
+                "namespace": "team-a",
+                "creationTimestamp": "2016-11-16T13:48:57Z"
+            },
+            "spec": {
+                "replicas": 1
+            },
+            "status": {
+                "replicas": 1,
+                "unavailableReplicas": 1
+            }
+        },
+        {
+            "kind": "Pod",
+            "apiVersion": "v1",
+            "metadata": {
+                "name": "kuberang-nginx-3294355564-1rvn5",
+                "namespace": "team-a",
+                "creationTimestamp": "2016-11-16T13:48:58Z",
+                "ownerReferences": [
+                    {
+                        "kind": "ReplicaSet",
+                        "name": "kuberang-nginx-3294355564"
+                    }
+                ]
+            },
+            "spec": {
+                "nodeName": "node2"
+            },
+            "status": {
+                "phase": "Pending"
+            }
+        },
+        {
+            "kind": "Service",
+            "apiVersion": "v1",
+            "metadata": {
+                "name": "kuberang-nginx-1479311348283746187",
+                "namespace": "default",
+                "creationTimestamp": "2016-11-16T13:49:02Z"
+            },
+            "spec": {
+                "type": "ClusterIP",
+                "clusterIP": "10.3.0.82"
+            }
+        }
+    ]
+}
+`
+
+func TestEndpoints(t *testing.T) {
+	ko := KubeOutput{
+		Success:     true,
+		CombinedOut: SampleEndpointsResponse,
+		RawOut:      []byte(SampleEndpointsResponse),
+	}
+	endpoints := ko.Endpoints()
+	if len(endpoints) != 2 || endpoints[0] != "172.16.3.5:80" || endpoints[1] != "172.16.4.7:80" {
+		t.Errorf("Unexpected endpoints: %v", endpoints)
+	}
+}
+
+const SampleEndpointsResponse = `
+{
+    "kind": "Endpoints",
+    "apiVersion": "v1",
+    "metadata": {
+        "name": "kuberang-nginx-1476913134",
+        "namespace": "default"
+    },
+    "subsets": [
+        {
+            "addresses": [
+                {
+                    "ip": "172.16.3.5",
+                    "nodeName": "node2"
+                },
+                {
+                    "ip": "172.16.4.7",
+                    "nodeName": "node3"
+                }
+            ],
+            "ports": [
+                {
+                    "port": 80,
+                    "protocol": "TCP"
+                }
+            ]
+        }
+    ]
+}
+`
+
+func TestPodsOfFailedOutput(t *testing.T) {
+	f, err := ioutil.TempFile("", "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	defer func(stdout *os.File) { os.Stdout = stdout }(os.Stdout)
+	os.Stdout = f
+
+	ko := KubeOutput{CombinedOut: "Unable to connect to the server\n", RawOut: []byte("Unable to connect to the server\n")}
+	if name := ko.FirstPodName(); name != "" {
+		t.Errorf("Expected no pod name, got %q", name)
+	}
+	if ips := ko.PodIPs(); len(ips) != 0 {
+		t.Errorf("Expected no pod IPs, got %v", ips)
+	}
+	if b, _ := ioutil.ReadFile(f.Name()); len(b) > 0 {
+		t.Errorf("Unexpected output on os.Stdout: %q", b)
+	}
+}
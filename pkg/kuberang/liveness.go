@@ -0,0 +1,129 @@
+package kuberang
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+// livenessFailAfter is how long after starting the liveness check pod
+// deletes the page hit by its liveness probe
+const livenessFailAfter = 10 * time.Second
+
+// livenessTimeout bounds the wait for the liveness check pod to restart. It
+// is a variable so that tests can shorten it.
+var livenessTimeout = 60 * time.Second
+
+// runLivenessCheck returns true if the liveness probe restart check is
+// enabled, either explicitly or through the full profile
+func runLivenessCheck(cfg *config.Config) bool {
+	return cfg.LivenessCheck || cfg.Profile == ProfileFull
+}
+
+// checkLivenessRestart runs an nginx pod that deletes the page hit by its
+// liveness probe shortly after starting, and verifies that the kubelet
+// restarts its container in time
+func checkLivenessRestart(r *reporter, w *workloads) bool {
+	name := "Container restarted after failing its liveness probe"
+	manifest, _ := json.Marshal(livenessPod(w))
+	if ko := r.kube.runWithInput(manifest, "create", "-f", "-"); !ko.Success {
+		r.err("liveness-restart", name, ko.CombinedOut)
+		return false
+	}
+	w.livenessPodDeployed = true
+
+	var pod Pod
+	var ko KubeOutput
+	var started time.Time
+	start := time.Now()
+	for time.Since(start) < deploymentTimeout+livenessTimeout {
+		if ko = r.kube.run("get", "pods", "-l", w.labels("kuberang-liveness"), "-o", "json"); ko.Success {
+			if pods := ko.Pods(); len(pods) > 0 {
+				pod = pods[0]
+			}
+		}
+		if started.IsZero() && pod.Ready {
+			started = time.Now()
+		}
+		if pod.Restarts > 0 {
+			break
+		}
+		if !started.IsZero() && time.Since(started) > livenessTimeout {
+			break
+		}
+		if !sleep(r.ctx, 1*time.Second) {
+			break
+		}
+	}
+	switch {
+	case pod.Restarts == 0 && r.ctx.Err() != nil:
+		r.err("liveness-restart", name, fmt.Sprintf("Run cancelled while waiting for pod %s to restart: %v\n", w.livenessPod, r.ctx.Err()))
+		return false
+	case started.IsZero() && pod.Restarts == 0:
+		r.err("liveness-restart", name, fmt.Sprintf("Pod %s did not become ready\n%s", w.livenessPod, ko.CombinedOut))
+		return false
+	case pod.Restarts == 0:
+		r.err("liveness-restart", name, fmt.Sprintf("The container of pod %s was not restarted within %v of failing its liveness probe, check the kubelet logs of its node\n", w.livenessPod, livenessTimeout))
+		return false
+	}
+	latency := "restarted before becoming ready"
+	if !started.IsZero() {
+		latency = fmt.Sprintf("restarted %v after the probe started failing", time.Since(started)-livenessFailAfter)
+	}
+	r.ok("liveness-restart", fmt.Sprintf("%s (%s)", name, latency))
+	return true
+}
+
+// removeLivenessPod deletes the pod of the liveness probe restart check
+func removeLivenessPod(r *reporter, w *workloads) {
+	if ko := runDelete(r.kube, "pod", w.livenessPod); ko.Success {
+		r.ok("cleanup-liveness-pod", "Powered down liveness check pod")
+	} else {
+		r.err("cleanup-liveness-pod", "Powered down liveness check pod", ko.CombinedOut)
+	}
+}
+
+// livenessPod returns an nginx pod that removes the page probed for liveness
+// after livenessFailAfter. The page is served from an emptyDir volume, so
+// that the container can remove it without running as root, and the
+// restarted container writes it anew and fails again later on.
+func livenessPod(w *workloads) map[string]interface{} {
+	script := fmt.Sprintf("echo kuberang > /usr/share/nginx/html/index.html; (sleep %d; rm /usr/share/nginx/html/index.html) & exec nginx -g 'daemon off;'", int(livenessFailAfter.Seconds()))
+	container := map[string]interface{}{
+		"name":            "nginx",
+		"image":           w.image("nginx:stable-alpine"),
+		"imagePullPolicy": "IfNotPresent",
+		"command":         []string{"sh", "-c", script},
+		"livenessProbe": map[string]interface{}{
+			"httpGet":          map[string]interface{}{"path": "/index.html", "port": 80},
+			"periodSeconds":    2,
+			"failureThreshold": 1,
+		},
+		"volumeMounts": []interface{}{
+			map[string]interface{}{"name": "html", "mountPath": "/usr/share/nginx/html"},
+		},
+	}
+	spec := map[string]interface{}{
+		"affinity": nodeAffinity(w.cfg, w.nodes),
+		"volumes": []interface{}{
+			map[string]interface{}{"name": "html", "emptyDir": map[string]interface{}{}},
+		},
+		"containers": []interface{}{container},
+	}
+	serviceAccountSpec(w.cfg, spec)
+	podSecuritySpec(w.cfg, spec, container, true)
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]interface{}{
+			"name": w.livenessPod,
+			"labels": map[string]interface{}{
+				"app":             "kuberang-liveness",
+				"kuberang/testid": fmt.Sprintf("%d", w.testID),
+			},
+		},
+		"spec": spec,
+	}
+}
@@ -0,0 +1,46 @@
+package kuberang
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckLivenessRestart(t *testing.T) {
+	defer func(c func(context.Context, []byte, ...string) ([]byte, error)) { kubectlCommand = c }(kubectlCommand)
+	defer func(d time.Duration) { livenessTimeout = d }(livenessTimeout)
+	livenessTimeout = time.Millisecond
+	// pod is the liveness check pod returned by kubectl get
+	var pod string
+	kubectlCommand = func(ctx context.Context, input []byte, args ...string) ([]byte, error) {
+		if args[0] == "get" {
+			return []byte(`{"items": [` + pod + `]}`), nil
+		}
+		return nil, nil
+	}
+	ready := `{"kind": "Pod", "metadata": {"name": "kuberang-liveness-1"}, "status": {"conditions": [{"type": "Ready", "status": "True"}], "containerStatuses": [{"restartCount": %d}]}}`
+
+	pod = fmt.Sprintf(ready, 1)
+	r := newReporter(ioutil.Discard, &CheckResult{})
+	if !checkLivenessRestart(r, newWorkloads(r.cfg, 1)) || r.result.Checks[0].Status != StatusOK {
+		t.Errorf("Expected the restart to be detected, got %v", r.result.Checks)
+	}
+
+	pod = fmt.Sprintf(ready, 0)
+	r = newReporter(ioutil.Discard, &CheckResult{})
+	if checkLivenessRestart(r, newWorkloads(r.cfg, 1)) || !strings.Contains(r.result.Checks[0].Detail, "was not restarted") {
+		t.Errorf("Expected the check to fail without a restart, got %v", r.result.Checks)
+	}
+
+	pod = `{"kind": "Pod", "metadata": {"name": "kuberang-liveness-1"}}`
+	r = newReporter(ioutil.Discard, &CheckResult{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r.ctx = ctx
+	if checkLivenessRestart(r, newWorkloads(r.cfg, 1)) || !strings.Contains(r.result.Checks[0].Detail, "Run cancelled") {
+		t.Errorf("Expected the cancellation to be reported, got %v", r.result.Checks)
+	}
+}
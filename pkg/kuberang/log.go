@@ -0,0 +1,24 @@
+package kuberang
+
+import (
+	"fmt"
+	"os"
+)
+
+// Verbosity levels at which diagnostic messages are printed
+const (
+	// logCommands prints every kubectl command that is run
+	logCommands = 1
+	// logOutput also prints the output of every kubectl command
+	logOutput = 2
+	// logDebug also prints retry and timing information
+	logDebug = 3
+)
+
+// logf prints a diagnostic message to stderr, keeping stdout free for the
+// results, when the configured verbosity is at least level
+func logf(verbosity int, level int, format string, a ...interface{}) {
+	if verbosity >= level {
+		fmt.Fprintf(os.Stderr, format+"\n", a...)
+	}
+}
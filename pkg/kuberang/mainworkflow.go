@@ -1,10 +1,15 @@
 package kuberang
 
 import (
+	"context"
 	"fmt"
 	"io"
-	"net/http"
+	"io/ioutil"
+	"math"
+	"net"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"errors"
@@ -14,59 +19,442 @@ import (
 )
 
 const (
-	deploymentTimeout  = 300 * time.Second
-	httpTimeout        = 3000 * time.Millisecond
-	wgetTimeoutSeconds = "3"
+	deploymentTimeout = 300 * time.Second
+	// minReadyGracePeriod is how long to wait for the remaining nginx replicas
+	// once the minimum ready fraction has been reached
+	minReadyGracePeriod = 30 * time.Second
+	httpTimeout         = 3000 * time.Millisecond
+	wgetTimeoutSeconds  = "3"
+	// cleanupTimeout bounds the cleanup of the test workloads, which runs
+	// even after the run was cancelled
+	cleanupTimeout = 2 * time.Minute
 )
 
-// CheckKubernetes runs checks against a cluster. It expects to find
-// a configured `kubectl` binary in the path.
-func CheckKubernetes() error {
+// CheckKubernetes runs checks against a cluster with the given
+// configuration, printing their outcome to out. It expects to find a
+// configured `kubectl` binary in the path. Cancelling ctx kills the running
+// kubectl processes and stops the checks, after which the test workloads are
+// still cleaned up.
+func CheckKubernetes(ctx context.Context, cfg *config.Config, out io.Writer) error {
+	return run(ctx, cfg, out, runChecks)
+}
+
+// run performs the checks and reports their outcome to stdout in the
+// configured output format. The run works on a copy of the configuration, so
+// that concurrent runs do not share any state.
+func run(ctx context.Context, cfg *config.Config, stdout io.Writer, checks func(*reporter, *workloads) error) error {
+	c := *cfg
+	cfg = &c
+	// Catch configuration errors before touching the cluster
+	if err := validateConfig(cfg); err != nil {
+		return err
+	}
+	render, err := resultRenderer(cfg)
+	if err != nil {
+		return err
+	}
+	if cfg.OutputDir != "" {
+		if err := os.MkdirAll(cfg.OutputDir, 0755); err != nil {
+			return fmt.Errorf("error creating output directory: %v", err)
+		}
+	}
+
 	testID := time.Now().UnixNano()
-	out := os.Stdout
-	bbDeploymentName := "kuberang-busybox"
-	ngDeploymentName := "kuberang-nginx"
-	ngServiceName := fmt.Sprintf("kuberang-nginx-%d", testID)
+	if cfg.EphemeralNamespace {
+		cfg.Namespace = ephemeralNamespace(testID)
+	}
+	kube := newKubectl(ctx, cfg)
+	// Record the kubectl invocations when the run is traced
+	tracesURL := otelTracesURL(cfg)
+	if tracesURL != "" {
+		kube.tracer = &kubectlTrace{}
+	}
+	settings := retrySettings{verbosity: cfg.Verbosity}
+	if cfg.MaxTotalRetries > 0 {
+		settings.budget = &retryBudget{left: cfg.MaxTotalRetries}
+	}
+	ctx = withRetrySettings(ctx, settings)
+	kube.ctx = ctx
+	result := &CheckResult{
+		TestID:    testID,
+		Namespace: namespace(cfg),
+		Context:   currentContext(kube),
+		FromNode:  cfg.FromNode,
+		As:        cfg.As,
+		AsGroups:  cfg.AsGroups,
+		StartTime: time.Now(),
+	}
+	// Deferred so that the textfile is rewritten even when the run aborts
+	if cfg.PromTextfile != "" {
+		defer func() {
+			if perr := writePromTextfile(cfg.PromTextfile, result); perr != nil {
+				fmt.Fprintln(os.Stderr, perr)
+			}
+		}()
+	}
+	out := stdout
+	if render != nil {
+		out = ioutil.Discard
+	}
+	// Keep a copy of the printed output, without colors, along with the
+	// report
+	var outputLog io.Writer = ioutil.Discard
+	if cfg.OutputDir != "" && render == nil {
+		logPath := filepath.Join(cfg.OutputDir, "output.log")
+		f, err := os.Create(logPath)
+		if err != nil {
+			return fmt.Errorf("error creating output log: %v", err)
+		}
+		defer f.Close()
+		outputLog = f
+		out = util.NewTee(stdout, f)
+		result.Artifacts = append(result.Artifacts, Artifact{Name: "output-log", Path: logPath})
+	}
+	// Checks running concurrently print whole lines
+	out = util.NewSyncWriter(out)
+
+	if identity := impersonatedIdentity(cfg); identity != "" {
+		printLine(out, "Running as %s", identity)
+	}
+	r := newReporter(out, result)
+	r.cfg, r.ctx, r.kube = cfg, ctx, kube
+	// The live view replaces the line by line output on terminals
+	var view *tui
+	// TeamCity follows the checks as they run
+	if cfg.OutputFormat == OutputTeamCity {
+		tc := newTeamCityReporter(stdout)
+		r.listener = tc.check
+		render = tc.finish
+	}
+	if cfg.Plain && render == nil {
+		r.out = ioutil.Discard
+		r.listener = plainPrinter(cfg, out)
+	} else if f, ok := stdout.(*os.File); ok && cfg.TUI && render == nil && util.IsTerminal(f) {
+		view = newTUI(stdout)
+		r.out = outputLog
+		r.listener = view.check
+	}
+	err = checks(r, newWorkloads(cfg, testID))
+	r.endPhase()
+	result.RetryBudgetExhausted = settings.budget.isExhausted()
+	if kube.tracer != nil {
+		result.kubectlCalls = kube.tracer.calls
+	}
+	if view != nil {
+		view.close()
+	}
+	result.EndTime = time.Now()
+	result.DurationMs = int64(result.EndTime.Sub(result.StartTime) / time.Millisecond)
+	result.Success = err == nil
+	summary := result.Summarize()
+	result.Summary = &summary
+	if err != nil {
+		result.Error = err.Error()
+	}
+	// Failing to store the result does not keep it from being rendered
+	var werr error
+	if cfg.OutputDir != "" {
+		if werr = writeOutputDir(cfg.OutputDir, result); werr != nil {
+			fmt.Fprintln(os.Stderr, werr)
+		}
+	}
+	if tracesURL != "" {
+		if terr := exportTrace(tracesURL, result); terr != nil {
+			fmt.Fprintln(os.Stderr, terr)
+		}
+	}
+	if cfg.StatsDAddr != "" {
+		if serr := emitStatsD(cfg.StatsDAddr, cfg.StatsDPrefix, cfg.StatsDTags, result); serr != nil {
+			fmt.Fprintln(os.Stderr, serr)
+		}
+	}
+	if cfg.ResultConfigMap != "" {
+		if cerr := writeResultConfigMap(kube, cfg.ResultConfigMap, result); cerr != nil {
+			fmt.Fprintln(os.Stderr, cerr)
+			if werr == nil {
+				werr = cerr
+			}
+		}
+	}
+	if render != nil {
+		if rerr := render(stdout, result); rerr != nil {
+			return rerr
+		}
+	} else if cfg.Plain {
+		printPlainSummary(out, result)
+	} else {
+		printSummary(cfg, out, result)
+	}
+	printArtifacts(os.Stderr, result.Artifacts)
+	if err != nil {
+		return err
+	}
+	return werr
+}
+
+// cleanupContext gives the cleanup a fresh context, so that it still runs
+// once the run is cancelled, bounded by cleanupTimeout along with the time
+// allowed to verify the cleanup and to delete the namespace. It returns the
+// function restoring the context of the run.
+func cleanupContext(r *reporter) func() {
+	timeout := cleanupTimeout
+	if r.cfg.VerifyCleanup {
+		timeout += r.cfg.VerifyCleanupTimeout
+	}
+	if r.cfg.EphemeralNamespace {
+		timeout += r.cfg.NamespaceDeletionTimeout
+	}
+	// The retry settings of the run carry over to the cleanup
+	ctx, cancel := context.WithTimeout(withRetrySettings(context.Background(), retrySettingsFrom(r.ctx)), timeout)
+	previous, previousKubectl := r.ctx, r.kube
+	r.ctx, r.kube = ctx, r.kube.withContext(ctx)
+	return func() {
+		cancel()
+		r.ctx, r.kube = previous, previousKubectl
+	}
+}
+
+// currentContext returns the kubeconfig context kubectl talks to, or an
+// empty string if it cannot be determined
+func currentContext(k *kubectl) string {
+	ko := k.run("config", "current-context")
+	if !ko.Success {
+		return ""
+	}
+	return strings.TrimSpace(ko.CombinedOut)
+}
+
+// validateConfig returns an error describing the first invalid option
+func validateConfig(cfg *config.Config) error {
+	if cfg.MinReadyFraction <= 0 || cfg.MinReadyFraction > 1 {
+		return errors.New("--min-ready-fraction must be greater than 0 and at most 1")
+	}
+	if cfg.Throughput && cfg.ThroughputSizeMB <= 0 {
+		return errors.New("--throughput-size-mb must be greater than 0")
+	}
+	if cfg.Benchmark != "" {
+		if _, err := parseBenchmarkSpec(cfg.Benchmark); err != nil {
+			return err
+		}
+	}
+	if cfg.DNSStressMaxFailureRate < 0 || cfg.DNSStressMaxFailureRate > 1 {
+		return errors.New("--dns-stress-max-failure-rate must be between 0 and 1")
+	}
+	if cfg.SampleMinRatio < 0 || cfg.SampleMinRatio > 1 {
+		return errors.New("--sample-min-ratio must be between 0 and 1")
+	}
+	if cfg.MaxTotalRetries < 0 {
+		return errors.New("--max-total-retries must not be negative")
+	}
+	if err := validateKubectlArgs(cfg.KubectlArgs); err != nil {
+		return err
+	}
+	if cfg.TargetNamespace != "" && cfg.TargetService == "" {
+		return errors.New("--target-namespace requires --target-service")
+	}
+	if cfg.EphemeralNamespace && cfg.Namespace != "" {
+		return errors.New("--ephemeral-namespace and --namespace are mutually exclusive")
+	}
+	if cfg.EphemeralNamespace && cfg.ResultConfigMap != "" {
+		return errors.New("--write-result-configmap cannot be used with --ephemeral-namespace, the namespace is deleted before the result is written")
+	}
+	for _, server := range cfg.DNSServers {
+		if net.ParseIP(server) == nil {
+			return fmt.Errorf("Invalid --dns-servers address %q", server)
+		}
+	}
+	if cfg.NodeLocalDNSIP != "" && net.ParseIP(cfg.NodeLocalDNSIP) == nil {
+		return fmt.Errorf("Invalid --nodelocal-dns-ip address %q", cfg.NodeLocalDNSIP)
+	}
+	for _, s := range cfg.ForbiddenTaints {
+		if _, err := parseTaint(s); err != nil {
+			return err
+		}
+	}
+	for _, s := range cfg.SystemServices {
+		if _, err := parseSystemService(s); err != nil {
+			return err
+		}
+	}
+	if err := validateProbePath(cfg.ProbePath); err != nil {
+		return err
+	}
+	if err := validateCustomProbes(cfg); err != nil {
+		return err
+	}
+	if err := validatePodSecurity(cfg); err != nil {
+		return err
+	}
+	if err := validateDNSRequire(cfg.DNSRequire); err != nil {
+		return err
+	}
+	if cfg.HairpinCheck && !cfg.IdentifyBackends {
+		return errors.New("--hairpin-check requires --identify-backends, which tells the backend answering every request")
+	}
+	if cfg.CrossNode && cfg.TargetService != "" {
+		return errors.New("--cross-node checks the nginx pods, it cannot be used with --target-service")
+	}
+	if cfg.ServiceAccount == "" {
+		return errors.New("--service-account cannot be empty")
+	}
+	if cfg.Parallelism < 1 {
+		return errors.New("--parallelism must be at least 1")
+	}
+	switch cfg.GroupBy {
+	case "":
+	case GroupByNode:
+		if cfg.OutputFormat != "" && cfg.OutputFormat != OutputSimple {
+			return errors.New("--group-by only applies to the simple output format, the structured output carries the node of every check")
+		}
+	default:
+		return fmt.Errorf("Unsupported --group-by value %q", cfg.GroupBy)
+	}
+	if cfg.Plain && (cfg.GroupBy != "" || (cfg.OutputFormat != "" && cfg.OutputFormat != OutputSimple)) {
+		return errors.New("--plain only applies to the simple output format, without --group-by")
+	}
+	switch cfg.Profile {
+	case ProfileStandard, ProfileFull:
+	default:
+		return fmt.Errorf("Unsupported --profile value %q", cfg.Profile)
+	}
+	switch cfg.DeploymentStrategy {
+	case "", StrategyRollingUpdate, StrategyRecreate:
+	default:
+		return fmt.Errorf("Unsupported --deployment-strategy value %q", cfg.DeploymentStrategy)
+	}
+	switch cfg.NodeChecks {
+	case NodeChecksRequired, NodeChecksIgnored, NodeChecksOff:
+	default:
+		return fmt.Errorf("Unsupported --node-checks value %q", cfg.NodeChecks)
+	}
+	return nil
+}
+
+func runChecks(r *reporter, w *workloads) (err error) {
 	success := true
-	registryURL := ""
-	if config.RegistryURL != "" {
-		registryURL = config.RegistryURL + "/"
+	deployed := false
+	client, err := nodeHTTPClient(r.cfg)
+	if err != nil {
+		return err
 	}
+	// Diagnostics are collected before cleaning up so that they include the
+	// test workloads
+	defer func() {
+		r.startPhase(PhaseCleanup)
+		if err != nil && artifactPath(r.cfg, r.cfg.CollectDiagnostics, ".") != "" {
+			restore := cleanupContext(r)
+			collectDiagnostics(r, w)
+			restore()
+		}
+		cleanup := !r.cfg.SkipCleanup
+		if cleanup && err != nil && r.cfg.PauseBeforeCleanup && (deployed || w.namespaceCreated) {
+			cleanup = !keepWorkloads(r, w)
+		}
+		// Started once the pause is over, so that the whole budget is left
+		// for the cleanup
+		defer cleanupContext(r)()
+		if deployed && cleanup {
+			powerDown(r, w)
+			if r.cfg.VerifyCleanup && !verifyCleanup(r, w) && err == nil {
+				err = errors.New("Test objects lingered after cleanup")
+			}
+		}
+		if w.namespaceCreated && cleanup && !deleteNamespace(r) && err == nil {
+			err = errors.New("Failed to delete the namespace")
+		}
+	}()
 
 	// If kubectl doesn't exist, don't bother doing anything
-	if !precheckKubectl() {
+	r.startPhase(PhasePrecheck)
+	if !precheckKubectl(r) {
 		return errors.New("Kubectl must be configured on this machine before running kuberang")
 	}
-	util.PrettyPrintOk(os.Stdout, "Kubectl configured on this node")
+	r.ok("kubectl-configured", "Kubectl configured on this node")
+
+	// Run in a namespace of our own, deleted along with everything in it
+	if r.cfg.EphemeralNamespace && !createNamespace(r, w) {
+		return errors.New("Failed to create the namespace")
+	}
 
-	// Ensure any pre-existing kuberang deployments are cleaned up
-	if err := removeExisting(ngServiceName, bbDeploymentName, ngDeploymentName); err != nil {
+	// Ensure any pre-existing kuberang deployments are cleaned up, unless the
+	// user prefers the preconditions to report them instead
+	if r.cfg.NoPreClean {
+		r.skipped("remove-existing", "Delete existing deployments if they exist", "--no-pre-clean")
+	} else if err := removeExisting(r, w); err != nil {
 		return err
 	}
 
 	// Make sure we have all we need
 	// Quit if we find existing kuberang deployments on the cluster
-	if !checkPreconditions(ngServiceName, bbDeploymentName, ngDeploymentName) {
+	if !checkPreconditions(r, w) {
 		return errors.New("Pre-conditions failed")
 	}
 
-	if !config.SkipCleanup {
-		defer powerDown(ngServiceName, bbDeploymentName, ngDeploymentName)
+	// Figure out which nodes the workloads will land on
+	if err := discoverNodes(r, w); err != nil {
+		return err
+	}
+
+	deployed = true
+
+	if err := runCancelled(r); err != nil {
+		return err
+	}
+
+	// Summarize the health of the cluster's own workloads
+	if r.cfg.NetworkOnly {
+		r.skipped("system-components", "kube-system workloads healthy", "network checks only")
+	} else {
+		checkSystemComponents(r)
+		checkAPIServices(r)
+	}
+
+	// Warm the image caches so pulls don't count against the checks
+	r.startPhase(PhaseDeploy)
+	if r.cfg.PrePull && !prePullImages(r, w) {
+		return errors.New("Failed to pull the test images on every node")
+	}
+
+	// Check a service of the user rather than nginx
+	if r.cfg.TargetService != "" {
+		r.startPhase(PhaseConnectivity)
+		return runTargetServiceChecks(r, w)
 	}
 
 	// Deploy the workloads required for running checks
-	if !deployTestWorkloads(registryURL, out, ngServiceName, bbDeploymentName, ngDeploymentName, testID) {
+	if !deployTestWorkloads(r, w) {
 		return errors.New("Failed to deploy test workloads")
 	}
 
+	// Run the node-side checks from the chosen node rather than this machine
+	if r.cfg.FromNode != "" && r.cfg.NodeChecks != NodeChecksOff && !deployFromNodePod(r, w) {
+		return errors.New("Failed to deploy the node-side check pod")
+	}
+
+	// Run a probe pod on every node for the checks that need one
+	if needsNodeProbes(r.cfg) && !deployNodeProbes(r, w) {
+		return errors.New("Failed to deploy node probes")
+	}
+
+	if err := runCancelled(r); err != nil {
+		return err
+	}
+
 	// Get IPs of all nginx pods
 	// Use a backoff retry as we have seen many cases where one of the pods
 	// fails, and we have to wait for the replicaset to deploy a new one.
-	podIPs := []string{}
 	var ko KubeOutput
-	ok := retryWithBackoff(5, func() bool {
-		if ko = RunKubectl("get", "pods", "-l", fmt.Sprintf("app=kuberang-nginx,kuberang/testid=%d", testID), "-o", "json"); ko.Success {
+	podIPs := []string{}
+	var nginxPods []Pod
+	ok := retryWithBackoff(r.ctx, 5, func() bool {
+		if ko = r.kube.run("get", "pods", "-l", w.labels("kuberang-nginx"), "-o", "json"); ko.Success {
 			podIPs = ko.PodIPs()
+			nginxPods = ko.Pods()
+			// When only a fraction of the pods is required, only check the
+			// pods that actually came up
+			if r.cfg.MinReadyFraction < 1 {
+				podIPs = readyPodIPs(nginxPods)
+			}
 			// check for at least one pod IP
 			if len(podIPs) == 0 {
 				return false
@@ -81,18 +469,56 @@ func CheckKubernetes() error {
 		}
 		return false
 	})
+	for _, p := range nginxPods {
+		r.result.Topology.Pods = append(r.result.Topology.Pods, TopologyPod{Name: p.Name, IP: p.IP, Node: p.NodeName})
+	}
 	if ok {
-		util.PrettyPrintOk(out, "Grab nginx pod ip addresses")
+		r.ok("nginx-pod-ips", "Grab nginx pod ip addresses")
 	} else {
-		util.PrettyPrintErr(out, "Grab nginx pod ip addresses")
-		printFailureDetail(out, ko.CombinedOut)
+		r.err("nginx-pod-ips", "Grab nginx pod ip addresses", ko.CombinedOut)
+		success = false
+	}
+
+	// Make sure every nginx pod is Ready before accessing the service, using
+	// the pods fetched above
+	if ok && !checkNginxPodsReady(r, nginxPods) {
+		success = false
+	}
+
+	// Make sure the pods run the expected images
+	if !checkImageDigests(r, w) {
+		success = false
+	}
+
+	// Make sure the nginx deployment and service select the nginx pods
+	if ok && !checkSelectors(r, w, nginxPods) {
 		success = false
 	}
 
+	// Make sure the CNI gave the pods IPs from the range of their node
+	if ok {
+		checkPodCIDRs(r, nginxPods)
+	}
+
+	// Make sure no test pod landed on a node it is kept off
+	if ok && len(r.cfg.ForbiddenTaints) > 0 && !checkForbiddenPlacement(r, w) {
+		success = false
+	}
+
+	// List the nodes that did not receive an nginx pod
+	if ok && r.cfg.ReportNodesWithoutPods {
+		if uncovered := nodesWithoutPods(w.nodes, nginxPods); len(uncovered) == 0 {
+			r.ok("nodes-without-pods", "Nginx pods landed on every node")
+		} else {
+			r.warn("nodes-without-pods", "Nginx pods landed on every node", "Nodes without an nginx pod: "+strings.Join(uncovered, ", ")+"\n")
+		}
+	}
+
 	// Get the service IP of the nginx service
+	r.startPhase(PhaseConnectivity)
 	var serviceIP string
-	ok = retry(3, func() bool {
-		if ko = RunGetService(ngServiceName); ko.Success {
+	attempt := retryAttempts(r.ctx, 3, func() bool {
+		if ko = r.kube.getService(w.ngService); ko.Success {
 			serviceIP = ko.ServiceCluserIP()
 			if serviceIP != "" {
 				return true
@@ -100,18 +526,22 @@ func CheckKubernetes() error {
 		}
 		return false
 	})
+	ok = attempt > 0
+	r.result.Topology.Service.ClusterIP = serviceIP
+	if eko := r.kube.run("get", "endpoints", w.ngService, "-o", "json"); eko.Success {
+		r.result.Topology.Service.Endpoints = eko.Endpoints()
+	}
 	if ok {
-		util.PrettyPrintOk(out, "Grab nginx service ip address")
+		r.okAfter("nginx-service-ip", "Grab nginx service ip address", attempt, 3)
 	} else {
-		util.PrettyPrintErr(out, "Grab nginx service ip address")
-		printFailureDetail(out, ko.CombinedOut)
+		r.err("nginx-service-ip", "Grab nginx service ip address", ko.CombinedOut)
 		success = false
 	}
 
 	// Get the name of the busybox pod
 	var busyboxPodName string
-	ok = retry(3, func() bool {
-		if ko = RunKubectl("get", "pods", "-l", fmt.Sprintf("app=kuberang-busybox,kuberang/testid=%d", testID), "-o", "json"); ko.Success {
+	attempt = retryAttempts(r.ctx, 3, func() bool {
+		if ko = r.kube.run("get", "pods", "-l", w.labels("kuberang-busybox"), "-o", "json"); ko.Success {
 			busyboxPodName = ko.FirstPodName()
 			if busyboxPodName != "" {
 				return true
@@ -119,276 +549,738 @@ func CheckKubernetes() error {
 		}
 		return false
 	})
+	ok = attempt > 0
 	if ok {
-		util.PrettyPrintOk(out, "Grab BusyBox pod name")
+		r.okAfter("busybox-pod-name", "Grab BusyBox pod name", attempt, 3)
 	} else {
-		util.PrettyPrintErr(out, "Grab BusyBox pod name")
-		printFailureDetail(out, ko.CombinedOut)
+		r.err("busybox-pod-name", "Grab BusyBox pod name", ko.CombinedOut)
 		success = false
 	}
 
 	// Gate on successful acquisition of all the required names / IPs
+	if err := runCancelled(r); err != nil {
+		return err
+	}
 	if !success {
 		return errors.New("Failed to get required information from cluster")
 	}
 
+	// Make sure the services target a port nginx listens on
+	checkTargetPorts(r, w, busyboxPodName, nginxPods)
+
 	// The following checks verify the pod network and the ability for
 	// pods to talk to each other.
 	// 1. Access nginx service via service IP from another pod
 	var kubeOut KubeOutput
-	ok = retry(3, func() bool {
-		kubeOut = RunKubectl("exec", busyboxPodName, "--", "wget", "-T", wgetTimeoutSeconds, "-qO-", serviceIP)
+	attempt = retryAttempts(r.ctx, 3, func() bool {
+		kubeOut = wgetNginx(r.kube, busyboxPodName, serviceIP)
 		return kubeOut.Success
 	})
+	ok = attempt > 0
 	if ok {
-		util.PrettyPrintOk(out, "Accessed Nginx service at "+serviceIP+" from BusyBox")
+		r.okAfter("service-ip-from-pod", "Accessed Nginx service at "+serviceIP+" from BusyBox", attempt, 3)
 	} else {
-		printFailureDetail(out, kubeOut.CombinedOut)
-		util.PrettyPrintErr(out, "Accessed Nginx service at "+serviceIP+" from BusyBox")
+		r.err("service-ip-from-pod", "Accessed Nginx service at "+serviceIP+" from BusyBox", kubeOut.CombinedOut)
 		success = false
 	}
 
-	// 2. Access nginx service via service name (DNS) from another pod
-	if !config.SkipDNSTests {
-		ok = retry(6, func() bool {
-			kubeOut = RunKubectl("exec", busyboxPodName, "--", "wget", "-T", wgetTimeoutSeconds, "-qO-", ngServiceName)
-			return kubeOut.Success
-		})
-		if ok {
-			util.PrettyPrintOk(out, "Accessed Nginx service via DNS "+ngServiceName+" from BusyBox")
-		} else {
-			util.PrettyPrintErr(out, "Accessed Nginx service via DNS "+ngServiceName+" from BusyBox")
-			printFailureDetail(out, kubeOut.CombinedOut)
+	// 1b. Access the nginx service variant whose targetPort is a port name
+	if w.ngNamedService != "" && !checkNamedPort(r, w, busyboxPodName, ok) {
+		success = false
+	}
+
+	// 1c. Access every port of the multi-port nginx service variant
+	if w.ngMultiPortService != "" && !checkMultiPort(r, w, busyboxPodName, podIPs) {
+		success = false
+	}
+
+	// 1d. Access the nginx service from a pod on every node, telling
+	// kube-proxy and SNAT problems apart from pod network problems
+	if r.cfg.PerNodeServiceCheck && !checkServiceFromEveryNode(r, w, serviceIP, podIPs) {
+		success = false
+	}
+
+	// 1e. Access the nginx service from one of its own backends, through
+	// hairpin NAT
+	if runHairpinCheck(r.cfg) && !checkHairpin(r, nginxPods, serviceIP, ok) {
+		success = false
+	}
+
+	if err := runCancelled(r); err != nil {
+		return err
+	}
+
+	// 2. Access nginx service via its short name and its FQDN (DNS) from
+	// another pod
+	if r.cfg.SkipDNSTests {
+		r.skipped("service-dns-from-pod", "Accessed Nginx service via DNS "+w.ngService+" from BusyBox", "--skip-dns-tests")
+		r.skipped("service-fqdn-from-pod", "Accessed Nginx service via DNS "+w.serviceFQDN()+" from BusyBox", "--skip-dns-tests")
+	} else if !checkServiceDNSFromPod(r, w, busyboxPodName) {
+		success = false
+	}
+
+	// 2a. Query the cluster DNS server directly to tell an unreachable server
+	// apart from a misconfigured resolver
+	if r.cfg.SkipDNSTests {
+		r.skipped("dns-clusterip-from-pod", "Queried the cluster DNS ClusterIP from BusyBox", "--skip-dns-tests")
+	} else if !checkDNSServer(r, busyboxPodName) {
+		success = false
+	}
+
+	// 2b. Resolve the nginx service name over TCP
+	if r.cfg.SkipDNSTests {
+		r.skipped("service-dns-tcp-from-pod", "Resolved Nginx service "+w.serviceFQDN()+" over TCP", "--skip-dns-tests")
+	} else if !checkDNSOverTCP(r, w) {
+		success = false
+	}
+
+	// 2c. Reach the kube-system services from BusyBox
+	if r.cfg.CheckSystemServices && !checkSystemServices(r, busyboxPodName) {
+		success = false
+	}
+
+	// 2d. Resolve a name against each of the given DNS servers
+	if len(r.cfg.DNSServers) > 0 && r.cfg.SkipDNSTests {
+		r.skipped("dns-server-resolution", "Resolved a name with the given DNS servers from BusyBox", "--skip-dns-tests")
+	} else if len(r.cfg.DNSServers) > 0 && !checkDNSServers(r, w, busyboxPodName) {
+		success = false
+	}
+
+	// 2e. Resolve the nginx service name through NodeLocal DNSCache, when in
+	// use, and through the cluster DNS service
+	if r.cfg.CheckNodeLocalDNS && r.cfg.SkipDNSTests {
+		r.skipped("dns-nodelocal-cache", "Resolved "+w.serviceFQDN()+" through NodeLocal DNSCache from BusyBox", "--skip-dns-tests")
+	} else if r.cfg.CheckNodeLocalDNS {
+		dnsIP := r.kube.run("get", "service", "kube-dns", "--namespace="+systemNamespace, "-o", "json").ServiceCluserIP()
+		if f := checkNodeLocalDNS(r, busyboxPodName, w.serviceFQDN(), dnsIP); f.detected && !f.cacheOK {
 			success = false
 		}
-	} else {
-		util.PrettyPrintSkipped(out, "Accessed Nginx service via DNS "+ngServiceName+" from BusyBox")
+	}
+
+	// 2f. Reach the API server through the kubernetes service
+	if r.cfg.NetworkOnly && !checkKubernetesService(r, busyboxPodName) {
+		success = false
+	}
+
+	if err := runCancelled(r); err != nil {
+		return err
 	}
 
 	// 3. Access all nginx pods by IP
-	for _, podIP := range podIPs {
-		ok = retry(3, func() bool {
-			kubeOut = RunKubectl("exec", busyboxPodName, "--", "wget", "-T", wgetTimeoutSeconds, "-qO-", podIP)
-			return kubeOut.Success
-		})
-		if ok {
-			util.PrettyPrintOk(out, "Accessed Nginx pod at "+podIP+" from BusyBox")
-		} else if config.IgnorePodIPAccessibilityCheck {
-			util.PrettyPrintErrorIgnored(out, "Accessed Nginx pod at "+podIP+" from BusyBox")
-		} else {
-			util.PrettyPrintErr(out, "Accessed Nginx pod at "+podIP+" from BusyBox")
-			printFailureDetail(out, kubeOut.CombinedOut)
+	if r.cfg.Sample > 1 {
+		if !checkPodsSampled(r, busyboxPodName, podIPs) {
 			success = false
 		}
+	} else {
+		for _, podIP := range podIPs {
+			attempt := retryAttempts(r.ctx, 3, func() bool {
+				kubeOut = wgetNginx(r.kube, busyboxPodName, podIP)
+				return kubeOut.Success
+			})
+			ok = attempt > 0
+			r.onPod(podIP)
+			if ok {
+				r.okAfter("pod-ip-from-pod", "Accessed Nginx pod at "+podIP+" from BusyBox", attempt, 3)
+			} else if r.cfg.IgnorePodIPAccessibilityCheck {
+				r.ignored("pod-ip-from-pod", "Accessed Nginx pod at "+podIP+" from BusyBox", kubeOut.CombinedOut)
+			} else {
+				r.err("pod-ip-from-pod", "Accessed Nginx pod at "+podIP+" from BusyBox", kubeOut.CombinedOut)
+				success = false
+			}
+		}
+	}
+
+	// 3a. Access an nginx pod on every other node, reporting the nodes of
+	// every path
+	if r.cfg.CrossNode && !checkCrossNode(r, w, busyboxPodName, nginxPods) {
+		success = false
+	}
+
+	// 3b. Access BusyBox from an nginx pod, the other way around
+	if runReversePathCheck(r.cfg) && !checkReversePath(r, w, busyboxPodName, nginxPods) {
+		success = false
+	}
+
+	if err := runCancelled(r); err != nil {
+		return err
+	}
+
+	// The remaining checks reach beyond the pod network
+	if r.cfg.NetworkOnly {
+		for _, c := range networkOnlySkipped {
+			r.skipped(c[0], c[1], "network checks only")
+		}
+		if !success {
+			return errors.New("One or more required steps failed")
+		}
+		return nil
 	}
 
 	// 4. Check internet connectivity from pod
-	if ko := RunKubectl("exec", busyboxPodName, "--", "wget", "-T", wgetTimeoutSeconds, "-qO-", "Google.com"); busyboxPodName == "" || ko.Success {
-		util.PrettyPrintOk(out, "Accessed Google.com from BusyBox")
+	if r.cfg.Offline {
+		r.skipped("internet-from-pod", "Accessed Google.com from BusyBox", "--offline")
+	} else if ko := r.kube.run("exec", busyboxPodName, "--", "wget", "-T", wgetTimeoutSeconds, "-qO-", "Google.com"); busyboxPodName == "" || ko.Success {
+		r.ok("internet-from-pod", "Accessed Google.com from BusyBox")
 	} else {
-		util.PrettyPrintErrorIgnored(out, "Accessed Google.com from BusyBox")
+		r.ignored("internet-from-pod", "Accessed Google.com from BusyBox", ko.CombinedOut)
 	}
 
-	client := http.Client{
-		Timeout: httpTimeout,
-	}
 	// 5. Check connectivity from current machine to all nginx pods
-	for _, podIP := range podIPs {
-		if _, err := client.Get("http://" + podIP); err == nil {
-			util.PrettyPrintOk(out, "Accessed Nginx pod at "+podIP+" from this node")
-		} else {
-			util.PrettyPrintErrorIgnored(out, "Accessed Nginx pod at "+podIP+" from this node")
-		}
+	if !checkPodsFromNode(r, w, client, podIPs) {
+		success = false
 	}
 
 	// 6. Check internet connectivity from current machine
-	if _, err := client.Get("http://google.com/"); err == nil {
-		util.PrettyPrintOk(out, "Accessed Google.com from this node")
-	} else {
-		util.PrettyPrintErrorIgnored(out, "Accessed Google.com from this node")
+	if r.cfg.Offline {
+		r.skipped("internet-from-node", "Accessed Google.com from "+nodeDescription(r.cfg), "--offline")
+	} else if !checkFromNode(r, w, client, "internet-from-node", "Accessed Google.com from "+nodeDescription(r.cfg), "http://google.com/") {
+		success = false
+	}
+
+	// 7. Access nginx service via its FQDN from current machine
+	if r.cfg.NodeDNSCheck && !checkServiceFromNode(r, w, client) {
+		success = false
+	}
+
+	// 7a. Write and read back a file in an emptyDir volume of BusyBox
+	if !checkEmptyDir(r, w, busyboxPodName) {
+		success = false
+	}
+
+	// 7b. Run the custom probes in BusyBox
+	if !checkCustomProbes(r, busyboxPodName) {
+		success = false
+	}
+
+	if err := runCancelled(r); err != nil {
+		return err
+	}
+
+	// 8. Measure the pod network throughput by downloading a payload from
+	// every nginx pod
+	if r.cfg.Throughput && !checkThroughput(r, busyboxPodName, podIPs) {
+		success = false
+	}
+
+	// 9. Benchmark the nginx service
+	if r.cfg.Benchmark != "" && !checkBenchmark(r, busyboxPodName, serviceIP) {
+		success = false
+	}
+
+	// 10. Stress the cluster DNS with many lookups in quick succession
+	if r.cfg.DNSStress > 0 && r.cfg.SkipDNSTests {
+		r.skipped("dns-stress", "Resolved the Nginx service name many times from BusyBox", "--skip-dns-tests")
+	} else if r.cfg.DNSStress > 0 && !checkDNSStress(r, w, busyboxPodName) {
+		success = false
+	}
+
+	if err := runCancelled(r); err != nil {
+		return err
+	}
+
+	// 11. Make an nginx pod unready and back, following it in and out of the
+	// service endpoints
+	if r.cfg.ReadinessGateCheck && !checkReadinessGate(r, w, busyboxPodName, nginxPods) {
+		success = false
+	}
+
+	// 12. Compare the clock of every node with the clock of this machine
+	if r.cfg.CheckClockSkew && !checkClockSkew(r, w) {
+		success = false
+	}
+	if r.cfg.CheckClockSpread {
+		checkClockSpread(r, w)
+	}
+
+	if err := runCancelled(r); err != nil {
+		return err
+	}
+
+	// 13. Delete an nginx pod and verify that it is replaced while the
+	// service stays reachable
+	if r.cfg.Profile == ProfileFull && !checkSelfHealing(r, w, busyboxPodName, nginxPods) {
+		success = false
+	}
+
+	// 14. Run a pod whose main container depends on a file written by its
+	// init container
+	if r.cfg.Profile == ProfileFull && !checkInitContainer(r, w) {
+		success = false
+	}
+
+	if err := runCancelled(r); err != nil {
+		return err
+	}
+
+	// 14a. Run a job to completion
+	if r.cfg.Profile == ProfileFull && !checkJob(r, w) {
+		success = false
+	}
+
+	if err := runCancelled(r); err != nil {
+		return err
+	}
+
+	// 14b. Wait for a cronjob to schedule a job that succeeds
+	if r.cfg.CheckCronJob && !checkCronJob(r, w) {
+		success = false
 	}
 
+	if err := runCancelled(r); err != nil {
+		return err
+	}
+
+	// 14c. Round-trip a custom resource through a new
+	// CustomResourceDefinition
+	if r.cfg.CheckCRD && !checkCRD(r, w) {
+		success = false
+	}
+
+	if err := runCancelled(r); err != nil {
+		return err
+	}
+
+	// 15. Make a container fail its liveness probe and wait for the kubelet
+	// to restart it
+	if runLivenessCheck(r.cfg) && !checkLivenessRestart(r, w) {
+		success = false
+	}
+
+	if err := runCancelled(r); err != nil {
+		return err
+	}
+
+	// 16. Roll out a new template of the nginx deployment. This replaces the
+	// nginx pods, so it comes last.
+	if r.cfg.CheckRollout && !checkRollout(r, w) {
+		success = false
+	}
+
+	if err := runCancelled(r); err != nil {
+		return err
+	}
 	if !success {
 		return errors.New("One or more required steps failed")
 	}
+	if r.promoted {
+		return errors.New("One or more checks listed with --fail-on reported a warning")
+	}
 	return nil
 }
 
-func deployTestWorkloads(registryURL string, out io.Writer, ngServiceName string, bbDeploymentName string, ngDeploymentName string, testID int64) bool {
+// runCancelled returns an error once the run is cancelled, so that the
+// remaining checks are skipped and the cleanup starts right away
+func runCancelled(r *reporter) error {
+	if r.ctx.Err() != nil {
+		return fmt.Errorf("Run cancelled, skipping the remaining checks: %v", r.ctx.Err())
+	}
+	return nil
+}
+
+// readyPodIPs returns the IP addresses of the pods that are ready
+func readyPodIPs(pods []Pod) []string {
+	podIPs := []string{}
+	for _, p := range pods {
+		if p.Ready && p.IP != "" {
+			podIPs = append(podIPs, p.IP)
+		}
+	}
+	return podIPs
+}
+
+func deployTestWorkloads(r *reporter, w *workloads) bool {
 	// Scale out busybox
 	busyboxCount := int64(1)
-	if ko := RunKubectl("run", bbDeploymentName, fmt.Sprintf("--image=%sbusybox:latest", registryURL), "--image-pull-policy=IfNotPresent", fmt.Sprintf("--labels=app=kuberang-busybox,kuberang/testid=%d", testID), "--", "sleep", "3600"); !ko.Success {
-		util.PrettyPrintErr(out, "Issued BusyBox start request")
-		printFailureDetail(out, ko.CombinedOut)
+	if r.cfg.CrossNode && len(w.nodes) < 2 {
+		r.err("cross-node-nodes", "At least two nodes are available for cross-node checks", "Nodes under test: "+strings.Join(w.nodes, ", ")+"\n")
+		return false
+	}
+	if !deployBusybox(r, w) {
 		return false
 	}
-	util.PrettyPrintOk(out, "Issued BusyBox start request")
 
 	// Scale out nginx
+	if !deployNginx(r, w) {
+		return false
+	}
+
+	// Wait until deployments are ready
+	return waitForDeployments(r.ctx, r, w, busyboxCount, int64(len(w.nodes)))
+}
+
+// deployNginx issues the requests to run the nginx deployment and to expose
+// it through the nginx service and its variants
+func deployNginx(r *reporter, w *workloads) bool {
+	ngSpec := map[string]interface{}{}
+	if r.cfg.CrossNode {
+		_, ngSpec["affinity"] = crossNodeAffinities(w.nodes)
+	} else if affinity := nodeAffinity(r.cfg, w.nodes); affinity != nil {
+		ngSpec["affinity"] = affinity
+	}
+	serviceAccountSpec(r.cfg, ngSpec)
+	tolerationsSpec(ngSpec, w)
+	busyboxImage := w.image("busybox:latest")
+
 	// Try to run a Pod on each Node,
 	// This scheduling is not guaranteed but it gets close
-	nginxCount := int64(RunGetNodes().NodeCount())
-	if ko := RunKubectl("run", ngDeploymentName, fmt.Sprintf("--image=%snginx:stable-alpine", registryURL), "--image-pull-policy=IfNotPresent", fmt.Sprintf("--replicas=%d", nginxCount), fmt.Sprintf("--labels=app=kuberang-nginx,kuberang/testid=%d", testID), "-o", "json"); !ko.Success {
-		util.PrettyPrintErr(out, "Issued Nginx start request")
-		printFailureDetail(out, ko.CombinedOut)
+	nginxCount := int64(len(w.nodes))
+	nginxImage := w.image("nginx:stable-alpine")
+	ngContainer := map[string]interface{}{
+		"name":            w.ngDeployment,
+		"image":           nginxImage,
+		"imagePullPolicy": "IfNotPresent",
+	}
+	defaultFields := len(ngContainer)
+	if r.cfg.IdentifyBackends {
+		if !createBackendConfigMap(r, w) {
+			return false
+		}
+		identifiableBackendSpec(ngSpec, ngContainer, w.ngConfigMap)
+	}
+	if r.cfg.Throughput {
+		throughputPayloadSpec(r.cfg, ngSpec, ngContainer, busyboxImage)
+	}
+	if w.ngNamedService != "" {
+		namedPortSpec(ngContainer)
+	}
+	if r.cfg.ReadinessGateCheck {
+		readinessProbeSpec(ngContainer)
+	}
+	podSecuritySpec(r.cfg, ngSpec, ngContainer, true)
+	// The containers list is replaced as a whole by the overrides, so it is
+	// only set when the nginx container needs more than the defaults
+	if len(ngContainer) > defaultFields {
+		ngSpec["containers"] = []interface{}{ngContainer}
+	}
+	ngArgs := []string{"run", w.ngDeployment, "--image=" + nginxImage, "--image-pull-policy=IfNotPresent", fmt.Sprintf("--replicas=%d", nginxCount), "--labels=" + w.labels("kuberang-nginx"), "-o", "json"}
+	ngArgs = append(ngArgs, podSpecOverrides(ngSpec)...)
+	if ko := r.kube.run(ngArgs...); !ko.Success {
+		r.err("nginx-start", "Issued Nginx start request", ko.CombinedOut)
 		return false
 	}
-	util.PrettyPrintOk(out, "Issued Nginx start request")
+	r.ok("nginx-start", "Issued Nginx start request")
 
 	// Add service
-	if ko := RunKubectl("expose", "deployment", ngDeploymentName, "--name="+ngServiceName, "--port=80", fmt.Sprintf("--labels=app=kuberang-nginx,kuberang/testid=%d", testID)); !ko.Success {
-		util.PrettyPrintErr(out, "Issued expose Nginx service request")
-		printFailureDetail(out, ko.CombinedOut)
+	if ko := r.kube.run("expose", "deployment", w.ngDeployment, "--name="+w.ngService, "--port=80", "--labels="+w.labels("kuberang-nginx")); !ko.Success {
+		r.err("nginx-expose", "Issued expose Nginx service request", ko.CombinedOut)
 		return false
 	}
-	util.PrettyPrintOk(out, "Issued expose Nginx service request")
+	r.ok("nginx-expose", "Issued expose Nginx service request")
+	if w.ngNamedService != "" && !exposeNamedPort(r, w) {
+		return false
+	}
+	if w.ngMultiPortService != "" && !exposeMultiPort(r, w) {
+		return false
+	}
+	return true
+}
 
-	// Wait until deployments are ready
-	return waitForDeployments(busyboxCount, nginxCount, bbDeploymentName, ngDeploymentName)
+// deployBusybox issues the request to run the BusyBox deployment from which
+// the checks access the services and pods under test
+func deployBusybox(r *reporter, w *workloads) bool {
+	bbSpec := map[string]interface{}{}
+	if r.cfg.CrossNode {
+		bbSpec["affinity"], _ = crossNodeAffinities(w.nodes)
+	} else if affinity := nodeAffinity(r.cfg, w.nodes); affinity != nil {
+		bbSpec["affinity"] = affinity
+	}
+	serviceAccountSpec(r.cfg, bbSpec)
+	tolerationsSpec(bbSpec, w)
+	busyboxImage := w.image("busybox:latest")
+	bbContainer := map[string]interface{}{
+		"name":            w.bbDeployment,
+		"image":           busyboxImage,
+		"imagePullPolicy": "IfNotPresent",
+		"args":            []string{"sleep", "3600"},
+	}
+	scratchVolumeSpec(bbSpec, bbContainer)
+	podSecuritySpec(r.cfg, bbSpec, bbContainer, false)
+	bbSpec["containers"] = []interface{}{bbContainer}
+	bbArgs := []string{"run", w.bbDeployment, "--image=" + busyboxImage, "--image-pull-policy=IfNotPresent", "--labels=" + w.labels("kuberang-busybox")}
+	bbArgs = append(bbArgs, podSpecOverrides(bbSpec)...)
+	bbArgs = append(bbArgs, "--", "sleep", "3600")
+	if ko := r.kube.run(bbArgs...); !ko.Success {
+		r.err("busybox-start", "Issued BusyBox start request", ko.CombinedOut)
+		return false
+	}
+	r.ok("busybox-start", "Issued BusyBox start request")
+	return true
 }
 
-func checkPreconditions(nginxServiceName string, bbDeploymentName string, ngDeploymentName string) bool {
+func checkPreconditions(r *reporter, w *workloads) bool {
 	ok := true
-	if !precheckNamespace() {
+	if !precheckNamespace(r) {
 		ok = false
 	}
-	if !precheckServices(nginxServiceName) {
+	if !precheckPermissions(r) {
 		ok = false
 	}
-	if !precheckDeployments(bbDeploymentName, ngDeploymentName) {
+	if !precheckServiceAccount(r) {
+		ok = false
+	}
+	if r.cfg.TargetService != "" && !precheckTargetService(r) {
+		ok = false
+	}
+	if !precheckServices(r, w) {
+		ok = false
+	}
+	if !precheckDeployments(r, w) {
 		ok = false
 	}
 	return ok
 }
 
-func precheckKubectl() bool {
-	if ko := RunKubectl("version"); !ko.Success {
-		util.PrettyPrintErr(os.Stdout, "Configured kubectl exists")
-		printFailureDetail(os.Stdout, ko.CombinedOut)
+func precheckKubectl(r *reporter) bool {
+	// Broken kubeconfigs are told apart without a round trip to the server
+	if !precheckKubeconfig(r) {
+		return false
+	}
+	if ko := r.kube.run("version"); !ko.Success {
+		r.err("kubectl-configured", "Configured kubectl exists", ko.CombinedOut)
 		return false
 	}
 	return true
 }
 
-func precheckServices(nginxServiceName string) bool {
-	if ko := RunGetService(nginxServiceName); ko.Success {
-		util.PrettyPrintErr(os.Stdout, "Nginx service does not already exist")
-		printFailureDetail(os.Stdout, ko.CombinedOut)
+func precheckServices(r *reporter, w *workloads) bool {
+	if ko := r.kube.getService(w.ngService); ko.Success {
+		r.err("nginx-service-absent", "Nginx service does not already exist", ko.CombinedOut)
 		return false
 	}
-	util.PrettyPrintOk(os.Stdout, "Nginx service does not already exist")
+	r.ok("nginx-service-absent", "Nginx service does not already exist")
 	return true
 }
 
-func precheckDeployments(bbDeploymentName string, ngDeploymentName string) bool {
+func precheckDeployments(r *reporter, w *workloads) bool {
 	ret := true
-	if ko := RunGetDeployment(bbDeploymentName); ko.Success {
-		util.PrettyPrintErr(os.Stdout, "BusyBox service does not already exist")
-		printFailureDetail(os.Stdout, ko.CombinedOut)
+	if ko := r.kube.getDeployment(w.bbDeployment); ko.Success {
+		r.err("busybox-deployment-absent", "BusyBox service does not already exist", ko.CombinedOut)
 		ret = false
 	} else {
-		util.PrettyPrintOk(os.Stdout, "BusyBox service does not already exist")
+		r.ok("busybox-deployment-absent", "BusyBox service does not already exist")
 	}
-	if ko := RunGetDeployment(ngDeploymentName); ko.Success {
-		util.PrettyPrintErr(os.Stdout, "Nginx service does not already exist")
-		printFailureDetail(os.Stdout, ko.CombinedOut)
+	if ko := r.kube.getDeployment(w.ngDeployment); ko.Success {
+		r.err("nginx-deployment-absent", "Nginx service does not already exist", ko.CombinedOut)
 		ret = false
 	} else {
-		util.PrettyPrintOk(os.Stdout, "Nginx service does not already exist")
+		r.ok("nginx-deployment-absent", "Nginx service does not already exist")
 	}
 	return ret
 }
 
-func precheckNamespace() bool {
+func precheckNamespace(r *reporter) bool {
 	ret := true
-	if config.Namespace != "" {
-		ko := RunGetNamespace(config.Namespace)
+	if r.cfg.Namespace != "" {
+		name := "Configured kubernetes namespace `" + r.cfg.Namespace + "` exists"
+		ko := r.kube.getNamespace(r.cfg.Namespace)
 		if !ko.Success {
-			util.PrettyPrintErr(os.Stdout, "Configured kubernetes namespace `"+config.Namespace+"` exists")
-			printFailureDetail(os.Stdout, ko.CombinedOut)
+			r.err("namespace-exists", name, ko.CombinedOut)
 			ret = false
 		} else if ko.NamespaceStatus() != "Active" {
-			util.PrettyPrintErr(os.Stdout, "Configured kubernetes namespace `"+config.Namespace+"` exists")
+			r.err("namespace-exists", name, "")
 			ret = false
 		} else {
-			util.PrettyPrintOk(os.Stdout, "Configured kubernetes namespace `"+config.Namespace+"` exists")
+			r.ok("namespace-exists", name)
 		}
 	}
 	return ret
 }
 
-func checkDeployments(busyboxCount, nginxCount int64, bbDeploymentName string, ngDeploymentName string) bool {
-	ret := true
-	ko := RunGetDeployment(bbDeploymentName)
-	if !ko.Success {
-		ret = false
-	} else if ko.ObservedReplicaCount() != busyboxCount {
-		ret = false
+// deploymentReplicas returns the number of available busybox and nginx replicas
+func deploymentReplicas(k *kubectl, w *workloads) (busybox int64, nginx int64) {
+	if ko := k.getDeployment(w.bbDeployment); ko.Success {
+		busybox = ko.ObservedReplicaCount()
 	}
-	ko = RunGetDeployment(ngDeploymentName)
-	if !ko.Success {
-		ret = false
-	} else if ko.ObservedReplicaCount() != nginxCount {
-		ret = false
+	if ko := k.getDeployment(w.ngDeployment); ko.Success {
+		nginx = ko.ObservedReplicaCount()
 	}
-	return ret
+	return busybox, nginx
+}
+
+// minReadyReplicas returns the number of nginx replicas that must be available
+// for the checks to proceed
+func minReadyReplicas(cfg *config.Config, nginxCount int64) int64 {
+	min := int64(math.Ceil(cfg.MinReadyFraction * float64(nginxCount)))
+	if min < 1 {
+		min = 1
+	}
+	return min
 }
 
-func waitForDeployments(busyboxCount, nginxCount int64, bbDeploymentName string, ngDeploymentName string) bool {
+func waitForDeployments(ctx context.Context, r *reporter, w *workloads, busyboxCount, nginxCount int64) bool {
+	name := "Both deployments completed successfully within timeout"
+	minNginx := minReadyReplicas(r.cfg, nginxCount)
 	start := time.Now()
+	var partialSince time.Time
 	for time.Since(start) < deploymentTimeout {
-		if checkDeployments(busyboxCount, nginxCount, bbDeploymentName, ngDeploymentName) {
-			util.PrettyPrintOk(os.Stdout, "Both deployments completed successfully within timeout")
+		busybox, nginx := deploymentReplicas(r.kube, w)
+		if busybox == busyboxCount && nginx == nginxCount {
+			r.ok("deployments-ready", name)
 			return true
 		}
-		time.Sleep(1 * time.Second)
+		// Once enough nginx replicas are available, only give the stragglers
+		// a short grace period before moving on
+		if busybox == busyboxCount && nginx >= minNginx {
+			if partialSince.IsZero() {
+				partialSince = time.Now()
+			}
+			if time.Since(partialSince) > minReadyGracePeriod {
+				break
+			}
+		}
+		if !sleep(ctx, 1*time.Second) {
+			break
+		}
+	}
+	busybox, nginx := deploymentReplicas(r.kube, w)
+	detail := fmt.Sprintf("%d/%d busybox and %d/%d nginx replicas available\n", busybox, busyboxCount, nginx, nginxCount)
+	if busybox == busyboxCount && nginx >= minNginx {
+		r.warn("deployments-ready", name, detail+unreadyPodsDetail(r.kube, w))
+		return true
 	}
-	util.PrettyPrintErr(os.Stdout, "Both deployments completed successfully within timeout")
+	r.err("deployments-ready", name, detail+unreadyPodsDetail(r.kube, w))
 	return false
 }
 
-func powerDown(nginxServiceName string, bbDeploymentName string, ngDeploymentName string) {
+// unreadyPodsDetail lists the nginx pods that are not ready along with the
+// nodes they were scheduled on
+func unreadyPodsDetail(k *kubectl, w *workloads) string {
+	ko := k.run("get", "pods", "-l", w.labels("kuberang-nginx"), "-o", "json")
+	if !ko.Success {
+		return ko.CombinedOut
+	}
+	return strings.Join(unreadyPods(ko.Pods()), "")
+}
+
+func powerDown(r *reporter, w *workloads) {
+	// Power down the image pre-pull DaemonSet
+	if w.prePullDeployed {
+		removePrePull(r, w)
+	}
+	// Only BusyBox is deployed when checking a service of the user
+	if r.cfg.TargetService != "" {
+		if ko := runDelete(r.kube, "deployments", w.bbDeployment); ko.Success {
+			r.ok("cleanup-busybox-deployment", "Powered down Busybox deployment")
+		} else {
+			r.err("cleanup-busybox-deployment", "Powered down Busybox deployment", ko.CombinedOut)
+		}
+		return
+	}
 	// Power down service
-	if ko := RunKubectl("delete", "service", nginxServiceName); ko.Success {
-		util.PrettyPrintOk(os.Stdout, "Powered down Nginx service")
+	if ko := runDelete(r.kube, "service", w.ngService); ko.Success {
+		r.ok("cleanup-nginx-service", "Powered down Nginx service")
 	} else {
-		util.PrettyPrintErr(os.Stdout, "Powered down Nginx service")
-		printFailureDetail(os.Stdout, ko.CombinedOut)
+		r.err("cleanup-nginx-service", "Powered down Nginx service", ko.CombinedOut)
 	}
 	// Power down bb
-	if ko := RunKubectl("delete", "deployments", bbDeploymentName); ko.Success {
-		util.PrettyPrintOk(os.Stdout, "Powered down Busybox deployment")
+	if ko := runDelete(r.kube, "deployments", w.bbDeployment); ko.Success {
+		r.ok("cleanup-busybox-deployment", "Powered down Busybox deployment")
 	} else {
-		util.PrettyPrintErr(os.Stdout, "Powered down Busybox deployment")
-		printFailureDetail(os.Stdout, ko.CombinedOut)
+		r.err("cleanup-busybox-deployment", "Powered down Busybox deployment", ko.CombinedOut)
 	}
 	// Power down nginx
-	if ko := RunKubectl("delete", "deployments", ngDeploymentName); ko.Success {
-		util.PrettyPrintOk(os.Stdout, "Powered down Nginx deployment")
+	if ko := runDelete(r.kube, "deployments", w.ngDeployment); ko.Success {
+		r.ok("cleanup-nginx-deployment", "Powered down Nginx deployment")
 	} else {
-		util.PrettyPrintErr(os.Stdout, "Powered down Nginx deployment")
-		printFailureDetail(os.Stdout, ko.CombinedOut)
+		r.err("cleanup-nginx-deployment", "Powered down Nginx deployment", ko.CombinedOut)
+	}
+	// Power down the named port service variant
+	if w.ngNamedService != "" {
+		if ko := runDelete(r.kube, "service", w.ngNamedService); ko.Success {
+			r.ok("cleanup-nginx-named-service", "Powered down Nginx named port service")
+		} else {
+			r.err("cleanup-nginx-named-service", "Powered down Nginx named port service", ko.CombinedOut)
+		}
 	}
+	// Power down the multi-port service variant
+	if w.ngMultiPortService != "" {
+		if ko := runDelete(r.kube, "service", w.ngMultiPortService); ko.Success {
+			r.ok("cleanup-nginx-multi-port-service", "Powered down Nginx multi-port service")
+		} else {
+			r.err("cleanup-nginx-multi-port-service", "Powered down Nginx multi-port service", ko.CombinedOut)
+		}
+	}
+	// Power down the node-side check pod
+	if w.fromNodeDeployed {
+		removeFromNodePod(r, w)
+	}
+	// Power down the init container check pod
+	if w.initPodDeployed {
+		if ko := runDelete(r.kube, "pod", w.initPod); ko.Success {
+			r.ok("cleanup-init-container-pod", "Powered down init container check pod")
+		} else {
+			r.err("cleanup-init-container-pod", "Powered down init container check pod", ko.CombinedOut)
+		}
+	}
+	// Power down the job along with its pods
+	if w.jobDeployed {
+		removeJob(r, w)
+	}
+	// Power down the cronjob along with its jobs
+	if w.cronJobDeployed {
+		removeCronJob(r, w)
+	}
+	// Remove the CustomResourceDefinition along with its custom resource
+	if w.crdDeployed {
+		removeCRD(r, w)
+	}
+	// Power down the liveness check pod
+	if w.livenessPodDeployed {
+		removeLivenessPod(r, w)
+	}
+	// Power down the node probes
+	if w.probesDeployed {
+		removeNodeProbes(r, w)
+	}
+	// Remove the nginx backend identity configuration
+	if w.ngConfigMap != "" {
+		if ko := runDelete(r.kube, "configmap", w.ngConfigMap); ko.Success {
+			r.ok("cleanup-nginx-configmap", "Removed Nginx backend identity ConfigMap")
+		} else {
+			r.err("cleanup-nginx-configmap", "Removed Nginx backend identity ConfigMap", ko.CombinedOut)
+		}
+	}
+}
+
+// runDelete deletes the objects, at once rather than after their grace
+// period with --force-delete
+func runDelete(k *kubectl, args ...string) KubeOutput {
+	args = append([]string{"delete"}, args...)
+	if k.cfg.ForceDelete {
+		args = append(args, "--grace-period=0", "--force")
+	}
+	return k.run(args...)
 }
 
-func removeExisting(nginxServiceName string, bbDeploymentName string, ngDeploymentName string) error {
-	ko := RunKubectl("delete", "--ignore-not-found=true",
-		fmt.Sprintf("deployment/%s", bbDeploymentName),
-		fmt.Sprintf("deployment/%s", ngDeploymentName),
-		fmt.Sprintf("service/%s", nginxServiceName),
+func removeExisting(r *reporter, w *workloads) error {
+	ko := runDelete(r.kube, "--ignore-not-found=true",
+		fmt.Sprintf("deployment/%s", w.bbDeployment),
+		fmt.Sprintf("deployment/%s", w.ngDeployment),
+		fmt.Sprintf("service/%s", w.ngService),
 	)
 	if !ko.Success {
-		util.PrettyPrintErr(os.Stdout, "Delete existing deployments if they exist")
-		printFailureDetail(os.Stdout, ko.CombinedOut)
+		r.err("remove-existing", "Delete existing deployments if they exist", ko.CombinedOut)
 		return errors.New("Failure removing existing kuberang deployments")
 	}
-	util.PrettyPrintOk(os.Stdout, "Delete existing deployments if they exist")
+	r.ok("remove-existing", "Delete existing deployments if they exist")
 	return nil
 }
 
-func printFailureDetail(out io.Writer, detail string) {
-	fmt.Fprintln(out, "-------- OUTPUT --------")
-	fmt.Fprintf(out, detail)
-	fmt.Fprintln(out, "------------------------")
-	fmt.Fprintln(out)
+// managedKubectlFlags are the kubectl flags kuberang sets itself, which
+// cannot be given with --kubectl-arg
+var managedKubectlFlags = []string{"-n", "--namespace", "-o", "--output", "--kubeconfig", "--as", "--as-group"}
+
+// validateKubectlArgs rejects the extra kubectl arguments that are not flags
+// or that set a flag managed by kuberang
+func validateKubectlArgs(args []string) error {
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			return fmt.Errorf("--kubectl-arg %q is not a flag, use the --flag=value form", arg)
+		}
+		flag := strings.SplitN(arg, "=", 2)[0]
+		if containsString(managedKubectlFlags, flag) {
+			return fmt.Errorf("--kubectl-arg cannot set %s, which kuberang manages", flag)
+		}
+	}
+	return nil
 }
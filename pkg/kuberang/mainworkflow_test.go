@@ -1,8 +1,20 @@
 package kuberang
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
 	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/apprenda/kuberang/pkg/config"
 )
 
 func TestTimeout(t *testing.T) {
@@ -20,3 +32,281 @@ func TestTimeout(t *testing.T) {
 	}
 
 }
+
+func TestValidateKubectlArgs(t *testing.T) {
+	if err := validateKubectlArgs([]string{"--request-timeout=10s", "--insecure-skip-tls-verify"}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	for _, arg := range []string{"--namespace=kube-system", "-o", "request-timeout"} {
+		if err := validateKubectlArgs([]string{arg}); err == nil {
+			t.Errorf("Expected %s to be rejected", arg)
+		}
+	}
+}
+
+// testConfig returns the defaults set by the command line flags, with a valid
+// kubeconfig written to dir
+func testConfig(t *testing.T, dir string) *config.Config {
+	return &config.Config{
+		Kubeconfig:       writeKubeconfig(t, dir, "kubeconfig", sampleKubeconfig),
+		MinReadyFraction: 1,
+		ServiceAccount:   defaultServiceAccount,
+		Parallelism:      1,
+		Profile:          ProfileStandard,
+		NodeChecks:       NodeChecksIgnored,
+	}
+}
+
+// fakeCluster returns a kubectl runner answering like a cluster of a single
+// node on which the test workloads come up, and the pods answer every exec
+func fakeCluster() func(context.Context, []byte, ...string) ([]byte, error) {
+	var mu sync.Mutex
+	created := map[string]bool{}
+	notFound := func() ([]byte, error) {
+		return []byte("Error from server (NotFound): not found\n"), errors.New("exit status 1")
+	}
+	return func(ctx context.Context, input []byte, args ...string) ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		// The global flags come first
+		for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+			args = args[1:]
+		}
+		command := strings.Join(args, " ")
+		switch {
+		case args[0] == "run":
+			created[args[1]] = true
+			return []byte("{}"), nil
+		case args[0] == "expose":
+			created[strings.TrimPrefix(args[3], "--name=")] = true
+			return nil, nil
+		case command == "config current-context":
+			return []byte("test\n"), nil
+		case args[0] == "exec":
+			return []byte("ok\n"), nil
+		case strings.HasPrefix(command, "get deployment "):
+			if !created[args[2]] {
+				return notFound()
+			}
+			return []byte(`{"spec": {"replicas": 1, "selector": {"matchLabels": {"app": "` + args[2] + `"}}},
+				"status": {"replicas": 1, "updatedReplicas": 1, "availableReplicas": 1, "readyReplicas": 1}}`), nil
+		case strings.HasPrefix(command, "get service "):
+			if !created[args[2]] {
+				return notFound()
+			}
+			return []byte(`{"spec": {"clusterIP": "10.96.0.20", "selector": {"app": "kuberang-nginx"}}}`), nil
+		case strings.HasPrefix(command, "get namespace "):
+			return []byte(`{"metadata": {"name": "` + args[2] + `"}, "status": {"phase": "Active"}}`), nil
+		case strings.HasPrefix(command, "get nodes"):
+			return []byte(`{"items": [{"metadata": {"name": "node1"}, "status": {"conditions": [{"type": "Ready", "status": "True"}]}}]}`), nil
+		case strings.HasPrefix(command, "get pods -l app=kuberang-nginx,"):
+			return []byte(`{"items": [{"metadata": {"name": "kuberang-nginx-a", "labels": {"app": "kuberang-nginx"}}, "spec": {"nodeName": "node1"},
+				"status": {"podIP": "10.0.0.10", "conditions": [{"type": "Ready", "status": "True"}]}}]}`), nil
+		case strings.HasPrefix(command, "get pods -l app=kuberang-busybox,"):
+			return []byte(`{"items": [{"metadata": {"name": "kuberang-busybox-a"}, "spec": {"nodeName": "node1"},
+				"status": {"podIP": "10.0.0.11", "conditions": [{"type": "Ready", "status": "True"}]}}]}`), nil
+		case strings.HasPrefix(command, "get endpoints"):
+			// Parsed only when kubectl succeeds
+			return []byte("Unable to connect to the server\n"), errors.New("exit status 1")
+		}
+		return []byte(`{"items": []}`), nil
+	}
+}
+
+func TestCheckKubernetesWritesToOut(t *testing.T) {
+	defer func(c func(context.Context, []byte, ...string) ([]byte, error)) { kubectlCommand = c }(kubectlCommand)
+	f, err := ioutil.TempFile("", "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	defer func(stdout *os.File) { os.Stdout = stdout }(os.Stdout)
+	os.Stdout = f
+	dir, err := ioutil.TempDir("", "kuberang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cfg := testConfig(t, dir)
+	cfg.NodeChecks = NodeChecksOff
+	cfg.Offline = true
+
+	for _, format := range []string{OutputSimple, OutputJSON} {
+		cfg.OutputFormat = format
+		kubectlCommand = fakeCluster()
+		var out bytes.Buffer
+		// The emptyDir check finds no checksums in the exec output
+		if err := CheckKubernetes(context.Background(), cfg, &out); err == nil {
+			t.Errorf("Expected the %s run to fail", format)
+		}
+		// Past the preconditions, through the checks of the workloads
+		if !strings.Contains(out.String(), "service-ip-from-pod") && !strings.Contains(out.String(), "Accessed Nginx service at 10.96.0.20 from BusyBox") {
+			t.Errorf("Expected the %s report of the whole workflow on out, got %q", format, out.String())
+		}
+		if format == OutputJSON {
+			if err := json.Unmarshal(out.Bytes(), &CheckResult{}); err != nil {
+				t.Errorf("Expected only the JSON report on out, got %q (%v)", out.String(), err)
+			}
+		}
+	}
+	if b, _ := ioutil.ReadFile(f.Name()); len(b) > 0 {
+		t.Errorf("Unexpected output on os.Stdout: %q", b)
+	}
+}
+
+func TestConcurrentRuns(t *testing.T) {
+	// Every run goes through a fake cluster of its own, recording the
+	// namespace and the command of each kubectl call
+	type call struct {
+		namespace, command string
+	}
+	calls := map[string][]call{}
+	var mu sync.Mutex
+	runner := func(run string) func(context.Context, []byte, ...string) ([]byte, error) {
+		cluster := fakeCluster()
+		return func(ctx context.Context, input []byte, args ...string) ([]byte, error) {
+			c := call{}
+			rest := args
+			for len(rest) > 0 && strings.HasPrefix(rest[0], "--") {
+				if strings.HasPrefix(rest[0], "--namespace=") {
+					c.namespace = strings.TrimPrefix(rest[0], "--namespace=")
+				}
+				rest = rest[1:]
+			}
+			c.command = strings.Join(rest, " ")
+			mu.Lock()
+			calls[run] = append(calls[run], c)
+			mu.Unlock()
+			return cluster(ctx, input, args...)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, ns := range []string{"team-a", "team-b"} {
+		dir, err := ioutil.TempDir("", "kuberang")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		cfg := testConfig(t, dir)
+		cfg.Namespace = ns
+		cfg.NodeChecks = NodeChecksOff
+		cfg.Offline = true
+		cfg.OutputFormat = OutputJSON
+		cfg.KubectlRunner = runner(ns)
+		wg.Add(1)
+		go func(ns string) {
+			defer wg.Done()
+			var out bytes.Buffer
+			CheckKubernetes(context.Background(), cfg, &out)
+			result := CheckResult{}
+			if err := json.Unmarshal(out.Bytes(), &result); err != nil || result.Namespace != ns {
+				t.Errorf("Expected the report of the run in %s, got %q (%v)", ns, out.String(), err)
+			}
+			if _, ok := checkOf(&result, "service-ip-from-pod"); !ok {
+				t.Errorf("Expected the run in %s to go through the checks of the workloads, got %v", ns, result.Checks)
+			}
+		}(ns)
+	}
+	wg.Wait()
+
+	for _, ns := range []string{"team-a", "team-b"} {
+		// The deletion of existing workloads comes before the deployment,
+		// the cleanup after it
+		deployed, deleted := false, false
+		for _, c := range calls[ns] {
+			if c.namespace != ns {
+				t.Errorf("Expected every kubectl call of the run in %s to be namespaced to it, got %q in %q", ns, c.command, c.namespace)
+			}
+			deployed = deployed || strings.HasPrefix(c.command, "run kuberang-nginx ")
+			deleted = deleted || deployed && strings.HasPrefix(c.command, "delete ")
+		}
+		if !deployed || !deleted {
+			t.Errorf("Expected the run in %s to deploy nginx and clean up in its namespace, got %v", ns, calls[ns])
+		}
+	}
+}
+
+func TestCleanupContextOutlivesCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := newReporter(ioutil.Discard, &CheckResult{})
+	r.ctx = ctx
+	r.kube = r.kube.withContext(ctx)
+
+	restore := cleanupContext(r)
+	if r.ctx.Err() != nil || r.kube.ctx.Err() != nil {
+		t.Error("Expected the cleanup to run with a live context")
+	}
+	if _, ok := r.ctx.Deadline(); !ok {
+		t.Error("Expected the cleanup context to be bounded")
+	}
+	restore()
+	if r.ctx != ctx || r.kube.ctx != ctx {
+		t.Error("Expected the context of the run to be restored")
+	}
+}
+
+func TestCancelledRunStopsWaiting(t *testing.T) {
+	defer func(c func(context.Context, []byte, ...string) ([]byte, error)) { kubectlCommand = c }(kubectlCommand)
+	// The job is created but never completes
+	kubectlCommand = func(ctx context.Context, input []byte, args ...string) ([]byte, error) {
+		if args[0] == "get" {
+			return []byte(`{"status": {"active": 1}}`), nil
+		}
+		return nil, nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r := newReporter(ioutil.Discard, &CheckResult{})
+	r.ctx = ctx
+	if err := runCancelled(r); err != nil {
+		t.Errorf("Unexpected error before the cancellation: %v", err)
+	}
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+	start := time.Now()
+	if checkJob(r, newWorkloads(r.cfg, 1)) {
+		t.Error("Expected the job check to fail")
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Expected the cancellation to end the wait, it took %v", elapsed)
+	}
+	if err := runCancelled(r); err == nil {
+		t.Error("Expected an error once the run is cancelled")
+	}
+}
+
+func TestCleanupContextBudget(t *testing.T) {
+	r := newReporter(ioutil.Discard, &CheckResult{})
+	r.cfg.VerifyCleanup = true
+	r.cfg.VerifyCleanupTimeout = time.Minute
+	r.cfg.EphemeralNamespace = true
+	r.cfg.NamespaceDeletionTimeout = 5 * time.Minute
+	defer cleanupContext(r)()
+	deadline, _ := r.ctx.Deadline()
+	if left := deadline.Sub(time.Now()); left < cleanupTimeout+6*time.Minute-time.Minute/2 {
+		t.Errorf("Expected the cleanup to have time to verify it and delete the namespace, %v left", left)
+	}
+}
+
+func TestRunDelete(t *testing.T) {
+	defer func(c func(context.Context, []byte, ...string) ([]byte, error)) { kubectlCommand = c }(kubectlCommand)
+	var deleted []string
+	kubectlCommand = func(ctx context.Context, input []byte, args ...string) ([]byte, error) {
+		deleted = args
+		return nil, nil
+	}
+	r := newReporter(ioutil.Discard, &CheckResult{})
+	runDelete(r.kube, "--ignore-not-found=true", "deployment/kuberang-busybox")
+	if expected := []string{"delete", "--ignore-not-found=true", "deployment/kuberang-busybox"}; !reflect.DeepEqual(deleted, expected) {
+		t.Errorf("Expected a graceful delete %q, got %q", expected, deleted)
+	}
+	r.cfg.ForceDelete = true
+	runDelete(r.kube, "--ignore-not-found=true", "deployment/kuberang-busybox")
+	if expected := []string{"delete", "--ignore-not-found=true", "deployment/kuberang-busybox", "--grace-period=0", "--force"}; !reflect.DeepEqual(deleted, expected) {
+		t.Errorf("Expected a forced delete %q, got %q", expected, deleted)
+	}
+}
@@ -0,0 +1,98 @@
+package kuberang
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// multiPortServicePorts are the ports of the multi-port service variant, all
+// mapping to the nginx container port
+var multiPortServicePorts = []int{80, 8080}
+
+// exposeMultiPort creates the nginx service variant exposing the nginx pods
+// on several ports. kubectl expose only handles a single port, so the
+// service is created from a manifest.
+func exposeMultiPort(r *reporter, w *workloads) bool {
+	manifest, _ := json.Marshal(multiPortService(w))
+	if ko := r.kube.runWithInput(manifest, "create", "-f", "-"); !ko.Success {
+		r.err("nginx-expose-multi-port", "Issued expose Nginx multi-port service request", ko.CombinedOut)
+		return false
+	}
+	r.ok("nginx-expose-multi-port", "Issued expose Nginx multi-port service request")
+	return true
+}
+
+// multiPortService returns the multi-port service variant, selecting the
+// nginx pods of the run with every port mapping to the nginx container port
+func multiPortService(w *workloads) map[string]interface{} {
+	ports := []interface{}{}
+	for _, port := range multiPortServicePorts {
+		ports = append(ports, map[string]interface{}{
+			"name":       fmt.Sprintf("http-%d", port),
+			"port":       port,
+			"targetPort": 80,
+			"protocol":   "TCP",
+		})
+	}
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata": map[string]interface{}{
+			"name": w.ngMultiPortService,
+			"labels": map[string]interface{}{
+				"app":             "kuberang-nginx",
+				"kuberang/testid": strconv.FormatInt(w.testID, 10),
+			},
+		},
+		"spec": map[string]interface{}{
+			"selector": map[string]interface{}{
+				"app":             "kuberang-nginx",
+				"kuberang/testid": strconv.FormatInt(w.testID, 10),
+			},
+			"ports": ports,
+		},
+	}
+}
+
+// checkMultiPort accesses every port of the multi-port service variant from
+// the BusyBox pod, and makes sure every nginx pod backs every port
+func checkMultiPort(r *reporter, w *workloads, busyboxPodName string, podIPs []string) bool {
+	var ko KubeOutput
+	var serviceIP string
+	if !retry(r.ctx, 3, func() bool {
+		if ko = r.kube.getService(w.ngMultiPortService); ko.Success {
+			serviceIP = ko.ServiceCluserIP()
+		}
+		return serviceIP != ""
+	}) {
+		r.err("multi-port-service-ip", "Grab Nginx multi-port service ip address", ko.CombinedOut)
+		return false
+	}
+
+	success := true
+	for _, port := range multiPortServicePorts {
+		target := fmt.Sprintf("%s:%d", serviceIP, port)
+		name := "Accessed Nginx multi-port service at " + target + " from BusyBox"
+		if attempt := retryAttempts(r.ctx, 3, func() bool {
+			ko = wgetNginx(r.kube, busyboxPodName, target)
+			return ko.Success
+		}); attempt > 0 {
+			r.okAfter("multi-port-service-from-pod", name, attempt, 3)
+		} else {
+			r.err("multi-port-service-from-pod", name, ko.CombinedOut)
+			success = false
+		}
+	}
+
+	name := "Every Nginx pod backs every port of the multi-port service"
+	expected := len(podIPs) * len(multiPortServicePorts)
+	ko = r.kube.run("get", "endpoints", w.ngMultiPortService, "-o", "json")
+	if endpoints := ko.Endpoints(); !ko.Success || len(endpoints) != expected {
+		r.err("multi-port-endpoints", name, fmt.Sprintf("Expected %d endpoints, found %d: %v\n", expected, len(endpoints), endpoints))
+		success = false
+	} else {
+		r.ok("multi-port-endpoints", name)
+	}
+	return success
+}
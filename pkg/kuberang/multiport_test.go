@@ -0,0 +1,62 @@
+package kuberang
+
+import (
+	"context"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+func TestMultiPortService(t *testing.T) {
+	w := newWorkloads(&config.Config{Profile: ProfileFull}, 1)
+	spec := multiPortService(w)["spec"].(map[string]interface{})
+	selector := map[string]interface{}{"app": "kuberang-nginx", "kuberang/testid": "1"}
+	if !reflect.DeepEqual(spec["selector"], selector) {
+		t.Errorf("Expected the service to select the nginx pods of the run, got %v", spec["selector"])
+	}
+	ports := spec["ports"].([]interface{})
+	if len(ports) != 2 {
+		t.Fatalf("Expected 2 ports, got %v", ports)
+	}
+	for i, port := range []int{80, 8080} {
+		p := ports[i].(map[string]interface{})
+		if p["port"] != port || p["targetPort"] != 80 {
+			t.Errorf("Expected port %d to map to port 80, got %v", port, p)
+		}
+	}
+}
+
+func TestCheckMultiPortEndpoints(t *testing.T) {
+	defer func(c func(context.Context, []byte, ...string) ([]byte, error)) { kubectlCommand = c }(kubectlCommand)
+	// endpoints is the endpoints response of the multi-port service
+	var endpoints string
+	kubectlCommand = func(ctx context.Context, input []byte, args ...string) ([]byte, error) {
+		switch {
+		case args[0] == "get" && args[1] == "service":
+			return []byte(`{"spec": {"clusterIP": "10.96.0.32"}}`), nil
+		case args[0] == "get" && args[1] == "endpoints":
+			return []byte(endpoints), nil
+		}
+		return []byte("<html></html>\n"), nil
+	}
+	podIPs := []string{"172.16.0.4", "172.16.0.5"}
+	ports := `"ports": [{"name": "http-80", "port": 80}, {"name": "http-8080", "port": 80}]`
+
+	endpoints = `{"subsets": [{"addresses": [{"ip": "172.16.0.4"}, {"ip": "172.16.0.5"}], ` + ports + `}]}`
+	r := newReporter(ioutil.Discard, &CheckResult{})
+	w := newWorkloads(&config.Config{Profile: ProfileFull}, 1)
+	if !checkMultiPort(r, w, "busybox", podIPs) {
+		t.Errorf("Expected every pod to back every port, got %v", r.result.Checks)
+	}
+
+	endpoints = `{"subsets": [{"addresses": [{"ip": "172.16.0.4"}], ` + ports + `}]}`
+	r = newReporter(ioutil.Discard, &CheckResult{})
+	if checkMultiPort(r, w, "busybox", podIPs) {
+		t.Fatal("Expected the check to fail with a pod missing from the endpoints")
+	}
+	if c, _ := checkOf(r.result, "multi-port-endpoints"); c.Status != StatusError {
+		t.Errorf("Expected the endpoint count check to fail, got %+v", c)
+	}
+}
@@ -0,0 +1,63 @@
+package kuberang
+
+// nginxPortName is the name of the nginx container port referenced by the
+// named port service variant
+const nginxPortName = "http"
+
+// namedPortDiagnosis calls out a failure of the named port service variant
+// while the numeric one works
+const namedPortDiagnosis = "Named port resolution problem: the service works with a numeric targetPort but not with a named one"
+
+// namedPortSpec declares the nginx container port under nginxPortName
+func namedPortSpec(container map[string]interface{}) {
+	appendToList(container, "ports", map[string]interface{}{
+		"name":          nginxPortName,
+		"containerPort": 80,
+		"protocol":      "TCP",
+	})
+}
+
+// exposeNamedPort creates the nginx service variant whose targetPort
+// references the container port by name
+func exposeNamedPort(r *reporter, w *workloads) bool {
+	if ko := r.kube.run("expose", "deployment", w.ngDeployment, "--name="+w.ngNamedService, "--port=80", "--target-port="+nginxPortName, "--labels="+w.labels("kuberang-nginx")); !ko.Success {
+		r.err("nginx-expose-named-port", "Issued expose Nginx named port service request", ko.CombinedOut)
+		return false
+	}
+	r.ok("nginx-expose-named-port", "Issued expose Nginx named port service request")
+	return true
+}
+
+// checkNamedPort accesses the named port service variant from the BusyBox
+// pod. When it fails while the numeric port service works, the failure is
+// called out as a named port resolution problem, in the summary as well.
+func checkNamedPort(r *reporter, w *workloads, busyboxPodName string, numericOK bool) bool {
+	var ko KubeOutput
+	var serviceIP string
+	ok := retry(r.ctx, 3, func() bool {
+		if ko = r.kube.getService(w.ngNamedService); ko.Success {
+			serviceIP = ko.ServiceCluserIP()
+		}
+		return serviceIP != ""
+	})
+	attempt := 0
+	if ok {
+		attempt = retryAttempts(r.ctx, 3, func() bool {
+			ko = wgetNginx(r.kube, busyboxPodName, serviceIP)
+			return ko.Success
+		})
+		ok = attempt > 0
+	}
+	name := "Accessed Nginx service with a named targetPort at " + serviceIP + " from BusyBox"
+	switch {
+	case ok:
+		r.okAfter("named-port-service-ip-from-pod", name, attempt, 3)
+		return true
+	case numericOK:
+		r.result.NamedPortDiagnosis = namedPortDiagnosis
+		r.err("named-port-service-ip-from-pod", name, namedPortDiagnosis+"\n"+ko.CombinedOut)
+	default:
+		r.err("named-port-service-ip-from-pod", name, ko.CombinedOut)
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package kuberang
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestCheckNamedPort(t *testing.T) {
+	defer func(c func(context.Context, []byte, ...string) ([]byte, error)) { kubectlCommand = c }(kubectlCommand)
+	// named is set when the named port service variant answers
+	var named bool
+	kubectlCommand = func(ctx context.Context, input []byte, args ...string) ([]byte, error) {
+		if args[0] == "get" {
+			return []byte(`{"spec": {"clusterIP": "10.96.0.31"}}`), nil
+		}
+		if !named {
+			return []byte("wget: can't connect to remote host (10.96.0.31): Connection refused\n"), errors.New("exit status 1")
+		}
+		return []byte("<html></html>\n"), nil
+	}
+	newNamedPortReporter := func() (*reporter, *workloads) {
+		r := newReporter(ioutil.Discard, &CheckResult{})
+		r.cfg.Profile = ProfileFull
+		// Every request is attempted once
+		r.ctx = withRetrySettings(context.Background(), retrySettings{budget: &retryBudget{}})
+		return r, newWorkloads(r.cfg, 1)
+	}
+
+	named = true
+	r, w := newNamedPortReporter()
+	if !checkNamedPort(r, w, "busybox", true) || r.result.NamedPortDiagnosis != "" {
+		t.Errorf("Expected the named port service to pass without a diagnosis, got %v", r.result.Checks)
+	}
+
+	named = false
+	r, w = newNamedPortReporter()
+	if checkNamedPort(r, w, "busybox", true) {
+		t.Fatal("Expected the named port service check to fail")
+	}
+	if r.result.NamedPortDiagnosis != namedPortDiagnosis || !strings.Contains(r.result.Checks[0].Detail, "Named port resolution problem") {
+		t.Errorf("Expected a named port resolution problem when the numeric port works, got %q and %v", r.result.NamedPortDiagnosis, r.result.Checks)
+	}
+
+	r, w = newNamedPortReporter()
+	if checkNamedPort(r, w, "busybox", false) || r.result.NamedPortDiagnosis != "" {
+		t.Errorf("Expected no named port diagnosis when the numeric port fails as well, got %q", r.result.NamedPortDiagnosis)
+	}
+}
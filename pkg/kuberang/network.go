@@ -0,0 +1,53 @@
+package kuberang
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+// Network runs the checks of the pod network only: pods reaching services
+// and other pods, DNS, and the kubernetes service. The checks reaching the
+// internet or run from this machine are left out.
+func Network(ctx context.Context, cfg *config.Config, out io.Writer) error {
+	c := *cfg
+	c.NetworkOnly = true
+	return run(ctx, &c, out, runChecks)
+}
+
+// networkOnlySkipped are the IDs and names of the checks reaching beyond the
+// pod network, reported as skipped when only the pod network is checked
+var networkOnlySkipped = [][2]string{
+	{"internet-from-pod", "Accessed Google.com from BusyBox"},
+	{"pod-ip-from-node", "Accessed Nginx pods from this node"},
+	{"internet-from-node", "Accessed Google.com from this node"},
+	{"emptydir-write-read", "Wrote and read back a file in an emptyDir volume"},
+}
+
+// checkKubernetesService opens a TCP connection to the kubernetes service,
+// through which pods reach the API server, from the BusyBox pod
+func checkKubernetesService(r *reporter, busyboxPodName string) bool {
+	address := "kubernetes.default.svc." + r.cfg.ClusterDomain
+	if r.cfg.SkipDNSTests {
+		ko := r.kube.run("get", "service", "kubernetes", "--namespace=default", "-o", "json")
+		if address = ko.ServiceCluserIP(); !ko.Success || address == "" {
+			r.err("kubernetes-service-from-pod", "Reached the kubernetes service from BusyBox", ko.CombinedOut)
+			return false
+		}
+	}
+	name := "Reached the kubernetes service at " + address + ":443 from BusyBox"
+	var ko KubeOutput
+	attempt := retryAttempts(r.ctx, 3, func() bool {
+		ko = r.kube.run("exec", busyboxPodName, "--", "sh", "-c",
+			fmt.Sprintf("nc -w %s %s 443 </dev/null", wgetTimeoutSeconds, address))
+		return ko.Success
+	})
+	if attempt == 0 {
+		r.err("kubernetes-service-from-pod", name, ko.CombinedOut)
+		return false
+	}
+	r.okAfter("kubernetes-service-from-pod", name, attempt, 3)
+	return true
+}
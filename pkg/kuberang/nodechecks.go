@@ -0,0 +1,158 @@
+package kuberang
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+// Ways of treating the checks run from this node
+const (
+	NodeChecksRequired = "required"
+	NodeChecksIgnored  = "ignored"
+	NodeChecksOff      = "off"
+)
+
+// nodeHTTPClient returns the HTTP client used by the checks run from this
+// node, trusting the CA bundle given with --ca-cert in addition to the system
+// trust store
+func nodeHTTPClient(cfg *config.Config) (*http.Client, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	if cfg.CACert != "" {
+		pem, err := ioutil.ReadFile(cfg.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA bundle: %v", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("No PEM certificates found in CA bundle %s", cfg.CACert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return &http.Client{
+		Timeout:   httpTimeout,
+		Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: tlsConfig},
+	}, nil
+}
+
+// checkServiceFromNode resolves the nginx service FQDN using the DNS
+// configuration of the current machine, or of the node given with
+// --from-node, and accesses the service through it. This only works on
+// machines that use the cluster DNS, such as some control plane nodes.
+func checkServiceFromNode(r *reporter, w *workloads, client *http.Client) bool {
+	fqdn := w.serviceFQDN()
+	name := "Accessed Nginx service via DNS " + fqdn + " from " + nodeDescription(r.cfg)
+	if w.fromNodeDeployed {
+		if err := getNginxFromNode(r.kube, w, client, fqdn); err != nil {
+			r.err("service-dns-from-node", name, err.Error())
+			return false
+		}
+		r.ok("service-dns-from-node", name)
+		return true
+	}
+	addrs, err := net.LookupHost(fqdn)
+	if err != nil {
+		r.err("service-dns-from-node", name, fmt.Sprintf("The DNS configuration of this node cannot resolve cluster names: %v\n", err))
+		return false
+	}
+	if err := getNginxFromNode(r.kube, w, client, fqdn); err != nil {
+		r.err("service-dns-from-node", name, fmt.Sprintf("%s resolved to %v, but the service could not be reached: %v\n", fqdn, addrs, err))
+		return false
+	}
+	r.ok("service-dns-from-node", name)
+	return true
+}
+
+// checkFromNode accesses the URL from this node, or from the node given with
+// --from-node, and reports the outcome according to --node-checks. It
+// returns false if a required check failed.
+func checkFromNode(r *reporter, w *workloads, client *http.Client, id, name, url string) bool {
+	if r.cfg.NodeChecks == NodeChecksOff {
+		r.skipped(id, name, "--node-checks=off")
+		return true
+	}
+	return reportFromNode(r, id, name, getFromNode(r.kube, w, client, url))
+}
+
+// checkPodsFromNode accesses every nginx pod from this node, or from the node
+// given with --from-node. The pods are accessed concurrently, up to
+// --parallelism at a time, and reported in the order given.
+func checkPodsFromNode(r *reporter, w *workloads, client *http.Client, podIPs []string) bool {
+	if r.cfg.NodeChecks == NodeChecksOff {
+		for _, podIP := range podIPs {
+			r.onPod(podIP).skipped("pod-ip-from-node", "Accessed Nginx pod at "+podIP+" from "+nodeDescription(r.cfg), "--node-checks=off")
+		}
+		return true
+	}
+	failed := make([]bool, len(podIPs))
+	runConcurrently(r, len(podIPs), r.cfg.Parallelism, func(r *reporter, i int) {
+		podIP := podIPs[i]
+		err := getNginxFromNode(r.kube, w, client, podIP)
+		failed[i] = !reportFromNode(r.onPod(podIP), "pod-ip-from-node", "Accessed Nginx pod at "+podIP+" from "+nodeDescription(r.cfg), err)
+	})
+	for _, f := range failed {
+		if f {
+			return false
+		}
+	}
+	return true
+}
+
+// getFromNode accesses the URL from this node, or from the node given with
+// --from-node
+func getFromNode(k *kubectl, w *workloads, client *http.Client, url string) error {
+	if w.fromNodeDeployed {
+		return getFromNodePod(k, w, url)
+	}
+	resp, err := client.Get(url)
+	if err == nil {
+		resp.Body.Close()
+	}
+	return err
+}
+
+// reportFromNode reports the outcome of a check run from the node according
+// to --node-checks. It returns false if a required check failed.
+func reportFromNode(r *reporter, id, name string, err error) bool {
+	switch {
+	case err == nil:
+		r.ok(id, name)
+	case r.cfg.NodeChecks == NodeChecksRequired:
+		r.err(id, name, err.Error())
+		return false
+	default:
+		r.ignored(id, name, err.Error())
+	}
+	return true
+}
+
+// parallelize calls f with every index from 0 to n-1, running at most limit
+// calls at a time, and returns once all of them returned
+func parallelize(n, limit int, f func(i int)) {
+	if limit < 1 {
+		limit = 1
+	}
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			f(i)
+		}(i)
+	}
+	wg.Wait()
+}
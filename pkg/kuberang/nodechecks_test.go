@@ -0,0 +1,122 @@
+package kuberang
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+func TestNodeHTTPClientCACert(t *testing.T) {
+	cfg := &config.Config{}
+
+	cfg.CACert = "/nonexistent/ca.pem"
+	if _, err := nodeHTTPClient(cfg); err == nil {
+		t.Errorf("Expected a missing CA bundle to be rejected")
+	}
+
+	f, err := ioutil.TempFile("", "kuberang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("not a certificate")
+	f.Close()
+	cfg.CACert = f.Name()
+	if _, err := nodeHTTPClient(cfg); err == nil {
+		t.Errorf("Expected a CA bundle without certificates to be rejected")
+	}
+
+	cfg.CACert = ""
+	if _, err := nodeHTTPClient(cfg); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestParallelize(t *testing.T) {
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	results := make([]int, 20)
+	parallelize(len(results), 3, func(i int) {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+		time.Sleep(time.Millisecond)
+		results[i] = i * i
+		mu.Lock()
+		running--
+		mu.Unlock()
+	})
+	if maxRunning > 3 {
+		t.Errorf("Expected at most 3 concurrent calls, got %d", maxRunning)
+	}
+	for i, v := range results {
+		if v != i*i {
+			t.Errorf("Missing result for index %d", i)
+		}
+	}
+}
+
+func TestCheckFromNode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	// Nothing listens once closed
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	down.Close()
+
+	tests := []struct {
+		mode   string
+		url    string
+		status string
+		passed bool
+	}{
+		{NodeChecksRequired, server.URL, StatusOK, true},
+		{NodeChecksRequired, down.URL, StatusError, false},
+		{NodeChecksIgnored, server.URL, StatusOK, true},
+		{NodeChecksIgnored, down.URL, StatusIgnored, true},
+		{NodeChecksOff, server.URL, StatusSkipped, true},
+		{NodeChecksOff, down.URL, StatusSkipped, true},
+	}
+	for _, test := range tests {
+		r := newReporter(ioutil.Discard, &CheckResult{})
+		r.cfg.NodeChecks = test.mode
+		passed := checkFromNode(r, newWorkloads(r.cfg, 1), http.DefaultClient, "pod-ip-from-node", "Accessed Nginx pod", test.url)
+		if passed != test.passed || len(r.result.Checks) != 1 || r.result.Checks[0].Status != test.status {
+			t.Errorf("--node-checks=%s against %s: expected %s and %v, got %v and %v", test.mode, test.url, test.status, test.passed, r.result.Checks, passed)
+		}
+	}
+}
+
+func TestReportFromNode(t *testing.T) {
+	tests := []struct {
+		mode   string
+		err    error
+		status string
+		passed bool
+	}{
+		{NodeChecksRequired, nil, StatusOK, true},
+		{NodeChecksRequired, errors.New("connection refused"), StatusError, false},
+		{NodeChecksIgnored, nil, StatusOK, true},
+		{NodeChecksIgnored, errors.New("connection refused"), StatusIgnored, true},
+	}
+	for _, test := range tests {
+		r := newReporter(ioutil.Discard, &CheckResult{})
+		r.cfg.NodeChecks = test.mode
+		passed := reportFromNode(r, "pod-ip-from-node", "Accessed Nginx pod", test.err)
+		if passed != test.passed || len(r.result.Checks) != 1 || r.result.Checks[0].Status != test.status {
+			t.Errorf("--node-checks=%s with error %v: expected %s and %v, got %v and %v", test.mode, test.err, test.status, test.passed, r.result.Checks, passed)
+		}
+		if test.err != nil && r.result.Checks[0].Detail != test.err.Error() {
+			t.Errorf("Expected the error as detail, got %q", r.result.Checks[0].Detail)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package kuberang
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultNodeLocalDNSIP is the link-local address NodeLocal DNSCache listens
+// on unless configured otherwise
+const DefaultNodeLocalDNSIP = "169.254.20.10"
+
+// nodeLocalDNSSelector selects the daemonset of NodeLocal DNSCache
+const nodeLocalDNSSelector = "k8s-app=node-local-dns"
+
+// nodeLocalDNSFindings are the outcomes of the NodeLocal DNSCache checks
+type nodeLocalDNSFindings struct {
+	// ip is the address of the node-local listener
+	ip string
+	// detected is set when the daemonset or the listener in the pod
+	// resolv.conf was found
+	detected bool
+	// cacheOK and serviceOK are set when the name resolved through the
+	// node-local cache and through the cluster DNS service respectively
+	cacheOK   bool
+	serviceOK bool
+}
+
+// diagnosis tells a broken node-local cache apart from broken cluster DNS,
+// empty when NodeLocal DNSCache is not in use or answers
+func (f nodeLocalDNSFindings) diagnosis() string {
+	switch {
+	case !f.detected || f.cacheOK:
+		return ""
+	case f.serviceOK:
+		return "NodeLocal DNSCache at " + f.ip + " does not answer while the cluster DNS service does: check the node-local-dns pod of the node"
+	}
+	return "Neither NodeLocal DNSCache at " + f.ip + " nor the cluster DNS service answers: the cache has nothing to forward to, check the cluster DNS"
+}
+
+// checkNodeLocalDNS detects NodeLocal DNSCache, from its daemonset or its
+// link-local listener in the BusyBox resolv.conf, and resolves the name
+// through the cache and through the cluster DNS service at dnsIP, so that a
+// broken node-local cache is told apart from broken cluster DNS
+func checkNodeLocalDNS(r *reporter, busyboxPodName, dnsName, dnsIP string) nodeLocalDNSFindings {
+	ip := r.cfg.NodeLocalDNSIP
+	f := nodeLocalDNSFindings{ip: ip}
+	if ip == "" {
+		r.skipped("dns-nodelocal-cache", "Resolved "+dnsName+" through NodeLocal DNSCache from BusyBox", "no NodeLocal DNSCache address configured")
+		return f
+	}
+	cacheName := "Resolved " + dnsName + " through NodeLocal DNSCache at " + ip + " from BusyBox"
+
+	found := []string{}
+	if ko := r.kube.run("get", "daemonsets", "-l", nodeLocalDNSSelector, "--namespace="+systemNamespace, "-o", "json"); ko.Success {
+		for _, ds := range ko.Objects() {
+			found = append(found, fmt.Sprintf("daemonset %s (%s)", ds.Name, ds.Status))
+		}
+	}
+	usedByPods := false
+	if ko := r.kube.run("exec", busyboxPodName, "--", "cat", "/etc/resolv.conf"); ko.Success && containsString(resolvConfNameservers(ko.CombinedOut), ip) {
+		usedByPods = true
+		found = append(found, "nameserver "+ip+" in the BusyBox resolv.conf")
+	}
+	if len(found) == 0 {
+		r.skipped("dns-nodelocal-cache", cacheName, "NodeLocal DNSCache not detected")
+		return f
+	}
+	f.detected = true
+	name := "Detected NodeLocal DNSCache: " + strings.Join(found, ", ")
+	if usedByPods {
+		r.ok("dns-nodelocal-detected", name)
+	} else {
+		// Pods only go through the cache when the kubelet hands them its
+		// address, or when the cache also intercepts the service address
+		r.warn("dns-nodelocal-detected", name, "The BusyBox resolv.conf does not list "+ip+", pods may bypass the cache unless it intercepts the cluster DNS service address\n")
+	}
+
+	// The service path first, for the diagnosis of a failing cache
+	serviceName := "Resolved " + dnsName + " through the cluster DNS service from BusyBox"
+	if dnsIP == "" {
+		r.skipped("dns-nodelocal-service", serviceName, "the kube-dns service is not visible")
+	} else {
+		f.serviceOK = resolveFromPod(r, busyboxPodName, "dns-nodelocal-service", serviceName+" at "+dnsIP, dnsName, dnsIP)
+	}
+
+	var ko KubeOutput
+	attempt := retryAttempts(r.ctx, 3, func() bool {
+		ko = r.kube.run("exec", busyboxPodName, "--", "nslookup", dnsName, ip)
+		return ko.Success
+	})
+	if attempt == 0 {
+		detail := f.diagnosis() + "\n"
+		if dnsIP == "" {
+			detail = "NodeLocal DNSCache at " + ip + " does not answer\n"
+		}
+		r.err("dns-nodelocal-cache", cacheName, detail+ko.CombinedOut)
+		return f
+	}
+	f.cacheOK = true
+	r.okAfter("dns-nodelocal-cache", cacheName, attempt, 3)
+	return f
+}
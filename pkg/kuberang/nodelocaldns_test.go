@@ -0,0 +1,58 @@
+package kuberang
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestCheckNodeLocalDNS(t *testing.T) {
+	defer func(c func(context.Context, []byte, ...string) ([]byte, error)) { kubectlCommand = c }(kubectlCommand)
+	daemonsets, nameserver := "", ""
+	kubectlCommand = func(ctx context.Context, input []byte, args ...string) ([]byte, error) {
+		command := strings.Join(args, " ")
+		switch {
+		case strings.HasPrefix(command, "get daemonsets"):
+			return []byte(daemonsets), nil
+		case strings.HasSuffix(command, "cat /etc/resolv.conf"):
+			return []byte("nameserver " + nameserver + "\nsearch default.svc.cluster.local\n"), nil
+		case strings.HasSuffix(command, "nslookup nginx.default.svc.cluster.local 10.96.0.10"):
+			return []byte("Name: nginx.default.svc.cluster.local\nAddress 1: 10.96.0.42\n"), nil
+		}
+		return []byte(";; connection timed out; no servers could be reached\n"), errors.New("exit status 1")
+	}
+	// Cancelled so that the failing lookups are not retried
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	newNodeLocalReporter := func() *reporter {
+		r := newReporter(ioutil.Discard, &CheckResult{})
+		r.ctx = ctx
+		r.cfg.NodeLocalDNSIP = DefaultNodeLocalDNSIP
+		return r
+	}
+
+	daemonsets, nameserver = `{"items": []}`, "10.96.0.10"
+	r := newNodeLocalReporter()
+	if f := checkNodeLocalDNS(r, "busybox", "nginx.default.svc.cluster.local", "10.96.0.10"); f.detected {
+		t.Errorf("Expected NodeLocal DNSCache not to be detected")
+	}
+	if len(r.result.Checks) != 1 || r.result.Checks[0].Status != StatusSkipped {
+		t.Errorf("Expected the check to be skipped, got %v", r.result.Checks)
+	}
+
+	daemonsets, nameserver = `{"items": [{"kind": "DaemonSet", "metadata": {"name": "node-local-dns"}}]}`, DefaultNodeLocalDNSIP
+	r = newNodeLocalReporter()
+	f := checkNodeLocalDNS(r, "busybox", "nginx.default.svc.cluster.local", "10.96.0.10")
+	if !f.detected || f.cacheOK || !f.serviceOK {
+		t.Fatalf("Expected a detected cache failing while the service answers, got %+v", f)
+	}
+	failed := r.result.Failed()
+	if len(failed) != 1 || failed[0].ID != "dns-nodelocal-cache" {
+		t.Fatalf("Expected only the cache check to fail, got %v", r.result.Checks)
+	}
+	if !strings.Contains(failed[0].Detail, "does not answer while the cluster DNS service does") {
+		t.Errorf("Expected the cache to be blamed, got %q", failed[0].Detail)
+	}
+}
@@ -0,0 +1,323 @@
+package kuberang
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/apprenda/kuberang/pkg/config"
+	"github.com/apprenda/kuberang/pkg/util"
+)
+
+// discoverNodes reports on the state of the cluster's nodes and selects the
+// ones on which the test workloads are expected to run
+func discoverNodes(r *reporter, w *workloads) error {
+	ko := r.kube.getNodes()
+	if !ko.Success {
+		r.err("list-nodes", "Listed cluster nodes", ko.CombinedOut)
+		return errors.New("Failed to list cluster nodes")
+	}
+
+	r.result.Topology = &Topology{
+		Nodes:   topologyNodes(ko.Nodes()),
+		Pods:    []TopologyPod{},
+		Service: TopologyService{Name: w.ngService, Endpoints: []string{}},
+	}
+	if r.cfg.FromNode != "" {
+		name := "Node " + r.cfg.FromNode + " given with --from-node exists"
+		if !hasNode(ko, r.cfg.FromNode) {
+			r.err("from-node-exists", name, "No node named "+r.cfg.FromNode+" in the cluster\n")
+			return fmt.Errorf("Unknown node passed to --from-node: %s", r.cfg.FromNode)
+		}
+		r.ok("from-node-exists", name)
+	}
+	r.result.CordonedNodes = cordonedNodes(ko)
+	for _, name := range r.result.CordonedNodes {
+		r.print(0, util.PrettyPrintSkipped, "Node %s cordoned, excluded", name)
+	}
+	for _, name := range controlPlaneNodes(r.cfg, ko) {
+		r.print(0, util.PrettyPrintSkipped, "Node %s is a control-plane node or tainted against scheduling, excluded", name)
+	}
+	r.result.ReadyNodes, r.result.NotReadyNodes = nodesByReadiness(ko)
+	if len(r.result.NotReadyNodes) == 0 {
+		r.ok("nodes-ready", "All nodes are Ready")
+	} else if r.cfg.RequireAllNodes {
+		r.warn("nodes-ready", "All nodes are Ready", "NotReady nodes: "+strings.Join(r.result.NotReadyNodes, ", ")+"\n")
+	} else {
+		r.warn("nodes-ready", "All nodes are Ready", "NotReady nodes excluded from the checks: "+strings.Join(r.result.NotReadyNodes, ", ")+"\n")
+	}
+
+	if r.cfg.MinNodes > 0 {
+		name := fmt.Sprintf("At least %d schedulable nodes are Ready", r.cfg.MinNodes)
+		if ready := readySchedulableCount(r.cfg, ko); ready < r.cfg.MinNodes {
+			r.err("min-nodes", name, fmt.Sprintf("Found %d Ready schedulable nodes, expected at least %d\n", ready, r.cfg.MinNodes))
+			return fmt.Errorf("Cluster has %d Ready schedulable nodes, fewer than the minimum of %d", ready, r.cfg.MinNodes)
+		}
+		r.ok("min-nodes", name)
+	}
+
+	labelled, lko := labelledNodes(r.kube)
+	if !lko.Success {
+		r.err("list-nodes", "Listed nodes matching "+nodeLabelSelector(r.cfg), lko.CombinedOut)
+		return errors.New("Failed to list cluster nodes")
+	}
+	nodes, err := selectNodes(r.cfg, ko, labelled)
+	if err != nil {
+		return err
+	}
+	w.nodes = nodes
+	w.tolerations = nodeTolerations(r.cfg, ko, nodes)
+	r.result.Nodes = nodes
+	if isNodeRestricted(r.cfg) {
+		r.result.NodesRestricted = true
+		util.PrettyPrintWarn(r.out, "Checks restricted to nodes: %s", strings.Join(nodes, ", "))
+	}
+	return nil
+}
+
+// hasNode returns true if the node is listed
+func hasNode(ko KubeOutput, name string) bool {
+	for _, n := range ko.Nodes() {
+		if n.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// selectNodes returns the names of the schedulable nodes on which the test
+// workloads are expected to run. Nodes that are not Ready are left out unless
+// --require-all-nodes is set, and so are control-plane nodes unless
+// --include-control-plane is set. When the run is restricted with --nodes,
+// the requested names are validated against the cluster's nodes, and a
+// requested node that cannot be targeted is an error rather than being left
+// out silently. When
+// labelled is not nil, only the nodes it contains are considered. Nodes passed
+// to --exclude-nodes are never selected, even if also passed to --nodes.
+func selectNodes(cfg *config.Config, ko KubeOutput, labelled map[string]bool) ([]string, error) {
+	known := map[string]Node{}
+	schedulable := []string{}
+	for _, n := range ko.Nodes() {
+		known[n.Name] = n
+		if isSchedulable(cfg, n) && (labelled == nil || labelled[n.Name]) {
+			schedulable = append(schedulable, n.Name)
+		}
+	}
+	if len(schedulable) == 0 && labelled != nil {
+		return nil, fmt.Errorf("No schedulable nodes match the node selector %q", nodeLabelSelector(cfg))
+	}
+	excluded := map[string]bool{}
+	for _, name := range cfg.ExcludeNodes {
+		excluded[name] = true
+	}
+
+	candidates := schedulable
+	if len(cfg.Nodes) > 0 {
+		unknown := []string{}
+		untargetable := []string{}
+		candidates = []string{}
+		for _, name := range cfg.Nodes {
+			n, ok := known[name]
+			if !ok {
+				unknown = append(unknown, name)
+				continue
+			}
+			if reason := untargetableReason(cfg, n, labelled); reason != "" {
+				untargetable = append(untargetable, fmt.Sprintf("%s (%s)", name, reason))
+				continue
+			}
+			candidates = append(candidates, name)
+		}
+		if len(unknown) > 0 {
+			return nil, fmt.Errorf("Unknown node(s) passed to --nodes: %s", strings.Join(unknown, ", "))
+		}
+		if len(untargetable) > 0 {
+			return nil, fmt.Errorf("Node(s) passed to --nodes cannot be targeted: %s", strings.Join(untargetable, ", "))
+		}
+	}
+
+	selected := []string{}
+	for _, name := range candidates {
+		if !excluded[name] {
+			selected = append(selected, name)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("No schedulable nodes are left once the nodes passed to --exclude-nodes are removed")
+	}
+	return selected, nil
+}
+
+// untargetableReason tells why the test workloads cannot run on the node,
+// or returns an empty string if they can
+func untargetableReason(cfg *config.Config, n Node, labelled map[string]bool) string {
+	switch {
+	case n.Unschedulable:
+		return "cordoned"
+	case !cfg.IncludeControlPlane && isControlPlane(n):
+		return "control-plane node, use --include-control-plane"
+	case !n.Ready && !cfg.RequireAllNodes:
+		return "NotReady, use --require-all-nodes"
+	case labelled != nil && !labelled[n.Name]:
+		return "does not match the node selector"
+	}
+	return ""
+}
+
+// labelledNodes returns the names of the nodes matching the node label
+// selector, or nil if no selector was given
+func labelledNodes(k *kubectl) (map[string]bool, KubeOutput) {
+	selector := nodeLabelSelector(k.cfg)
+	if selector == "" {
+		return nil, KubeOutput{Success: true}
+	}
+	ko := k.run("get", "nodes", "-l", selector, "-o", "json")
+	if !ko.Success {
+		return nil, ko
+	}
+	labelled := map[string]bool{}
+	for _, n := range ko.Nodes() {
+		labelled[n.Name] = true
+	}
+	return labelled, ko
+}
+
+// nodesWithoutPods returns the nodes, in the order given, that are not
+// running any of the given pods
+func nodesWithoutPods(nodes []string, pods []Pod) []string {
+	covered := map[string]bool{}
+	for _, p := range pods {
+		covered[p.NodeName] = true
+	}
+	uncovered := []string{}
+	for _, n := range nodes {
+		if !covered[n] {
+			uncovered = append(uncovered, n)
+		}
+	}
+	return uncovered
+}
+
+// isSchedulable returns true if the test workloads are expected to be
+// scheduled on the node
+func isSchedulable(cfg *config.Config, n Node) bool {
+	return acceptsTestPods(cfg, n) && (n.Ready || cfg.RequireAllNodes)
+}
+
+// cordonedNodes returns the names of the nodes that are marked unschedulable
+func cordonedNodes(ko KubeOutput) []string {
+	cordoned := []string{}
+	for _, n := range ko.Nodes() {
+		if n.Unschedulable {
+			cordoned = append(cordoned, n.Name)
+		}
+	}
+	return cordoned
+}
+
+// readySchedulableCount returns the number of nodes that are Ready and
+// accept the test workloads
+func readySchedulableCount(cfg *config.Config, ko KubeOutput) int {
+	count := 0
+	for _, n := range ko.Nodes() {
+		if n.Ready && acceptsTestPods(cfg, n) {
+			count++
+		}
+	}
+	return count
+}
+
+// nodesByReadiness splits the names of the nodes by their Ready condition
+func nodesByReadiness(ko KubeOutput) (ready []string, notReady []string) {
+	ready = []string{}
+	notReady = []string{}
+	for _, n := range ko.Nodes() {
+		if n.Ready {
+			ready = append(ready, n.Name)
+		} else {
+			notReady = append(notReady, n.Name)
+		}
+	}
+	return ready, notReady
+}
+
+// nodeLabelSelector returns the label selector built from --node-role and
+// --node-label, or an empty string if neither was given
+func nodeLabelSelector(cfg *config.Config) string {
+	selectors := []string{}
+	if cfg.NodeRole != "" {
+		selectors = append(selectors, "node-role.kubernetes.io/"+cfg.NodeRole)
+	}
+	if cfg.NodeLabel != "" {
+		selectors = append(selectors, cfg.NodeLabel)
+	}
+	return strings.Join(selectors, ",")
+}
+
+// isNodeRestricted returns true when the user has limited the nodes on which
+// the test workloads can be scheduled
+func isNodeRestricted(cfg *config.Config) bool {
+	return len(cfg.Nodes) > 0 || len(cfg.ExcludeNodes) > 0 || nodeLabelSelector(cfg) != ""
+}
+
+// nodeAffinity returns the pod affinity that pins a workload to the given
+// nodes, or nil when the run is not restricted to specific nodes.
+func nodeAffinity(cfg *config.Config, nodes []string) map[string]interface{} {
+	if !isNodeRestricted(cfg) {
+		return nil
+	}
+	requirements := []interface{}{}
+	if len(cfg.Nodes) > 0 || nodeLabelSelector(cfg) != "" {
+		requirements = append(requirements, map[string]interface{}{
+			"key":      "metadata.name",
+			"operator": "In",
+			"values":   nodes,
+		})
+	}
+	if len(cfg.ExcludeNodes) > 0 {
+		requirements = append(requirements, map[string]interface{}{
+			"key":      "metadata.name",
+			"operator": "NotIn",
+			"values":   cfg.ExcludeNodes,
+		})
+	}
+	return matchFieldsAffinity(requirements)
+}
+
+// pinnedNodeAffinity returns the pod affinity that pins a workload to exactly
+// the given nodes, whether or not the run is restricted. DaemonSets need it
+// as they are otherwise scheduled on cordoned and NotReady nodes as well.
+func pinnedNodeAffinity(nodes []string) map[string]interface{} {
+	return matchFieldsAffinity([]interface{}{
+		map[string]interface{}{
+			"key":      "metadata.name",
+			"operator": "In",
+			"values":   nodes,
+		},
+	})
+}
+
+// matchFieldsAffinity returns the required node affinity made of the given
+// node field requirements
+func matchFieldsAffinity(requirements []interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"nodeAffinity": map[string]interface{}{
+			"requiredDuringSchedulingIgnoredDuringExecution": map[string]interface{}{
+				"nodeSelectorTerms": []interface{}{
+					map[string]interface{}{
+						"matchFields": requirements,
+					},
+				},
+			},
+		},
+	}
+}
+
+// topologyNodes returns the topology entries of the given nodes
+func topologyNodes(nodes []Node) []TopologyNode {
+	entries := make([]TopologyNode, len(nodes))
+	for i, n := range nodes {
+		entries[i] = TopologyNode{Name: n.Name, InternalIP: n.InternalIP}
+	}
+	return entries
+}
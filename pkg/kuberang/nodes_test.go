@@ -0,0 +1,238 @@
+package kuberang
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+func TestSelectNodes(t *testing.T) {
+	cfg := &config.Config{}
+	ko := KubeOutput{
+		Success:     true,
+		CombinedOut: SampleNodeRespones,
+		RawOut:      []byte(SampleNodeRespones),
+	}
+	// every node in the sample is NotReady
+	cfg.RequireAllNodes = true
+
+	cfg.Nodes = nil
+	nodes, err := selectNodes(cfg, ko, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(nodes) != 3 {
+		t.Errorf("Expected the 3 schedulable nodes, got %v", nodes)
+	}
+
+	cfg.Nodes = []string{"node2"}
+	nodes, err = selectNodes(cfg, ko, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(nodes) != 1 || nodes[0] != "node2" {
+		t.Errorf("Expected only node2 to be selected, got %v", nodes)
+	}
+
+	// a requested node that cannot be targeted is not left out silently
+	cfg.Nodes = []string{"node2", "node1"}
+	if _, err = selectNodes(cfg, ko, nil); err == nil || !strings.Contains(err.Error(), "node1 (cordoned)") {
+		t.Errorf("Expected an error naming the cordoned node, got %v", err)
+	}
+	cfg.RequireAllNodes = false
+	cfg.Nodes = []string{"node2"}
+	if _, err = selectNodes(cfg, ko, nil); err == nil || !strings.Contains(err.Error(), "node2 (NotReady") {
+		t.Errorf("Expected an error naming the NotReady node, got %v", err)
+	}
+	cfg.RequireAllNodes = true
+
+	cfg.Nodes = []string{"node2", "bogus"}
+	if _, err = selectNodes(cfg, ko, nil); err == nil || !strings.Contains(err.Error(), "bogus") {
+		t.Errorf("Expected an error naming the unknown node, got %v", err)
+	}
+
+	cfg.Nodes = nil
+	cfg.ExcludeNodes = []string{"node3"}
+	nodes, err = selectNodes(cfg, ko, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(nodes) != 2 || nodes[0] != "node2" || nodes[1] != "node4" {
+		t.Errorf("Expected node2 and node4 to be selected, got %v", nodes)
+	}
+
+	// exclusion wins over inclusion
+	cfg.Nodes = []string{"node2", "node3"}
+	nodes, err = selectNodes(cfg, ko, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(nodes) != 1 || nodes[0] != "node2" {
+		t.Errorf("Expected only node2 to be selected, got %v", nodes)
+	}
+
+	cfg.ExcludeNodes = []string{"node2", "node3"}
+	if _, err = selectNodes(cfg, ko, nil); err == nil {
+		t.Errorf("Expected an error when every selected node is excluded")
+	}
+
+	// label selection composes with the other restrictions
+	cfg.Nodes = nil
+	cfg.ExcludeNodes = []string{"node3"}
+	cfg.NodeRole = "worker"
+	nodes, err = selectNodes(cfg, ko, map[string]bool{"node1": true, "node3": true, "node4": true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(nodes) != 1 || nodes[0] != "node4" {
+		t.Errorf("Expected only node4 to be selected, got %v", nodes)
+	}
+	if _, err = selectNodes(cfg, ko, map[string]bool{}); err == nil {
+		t.Errorf("Expected an error when no node matches the selector")
+	}
+}
+
+func TestNodeLabelSelector(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.NodeRole = "worker"
+	cfg.NodeLabel = "disktype=ssd"
+	if s := nodeLabelSelector(cfg); s != "node-role.kubernetes.io/worker,disktype=ssd" {
+		t.Errorf("Unexpected node selector %q", s)
+	}
+}
+
+func TestNodesWithoutPods(t *testing.T) {
+	ko := KubeOutput{
+		Success:     true,
+		CombinedOut: SamplePodsResponse,
+		RawOut:      []byte(SamplePodsResponse),
+	}
+	uncovered := nodesWithoutPods([]string{"node2", "node3", "node4"}, ko.Pods())
+	if len(uncovered) != 1 || uncovered[0] != "node4" {
+		t.Errorf("Expected only node4 to be uncovered, got %v", uncovered)
+	}
+}
+
+func TestCordonedNodes(t *testing.T) {
+	cfg := &config.Config{}
+	ko := KubeOutput{
+		Success:     true,
+		CombinedOut: SampleNodeRespones,
+		RawOut:      []byte(SampleNodeRespones),
+	}
+	cfg.RequireAllNodes = true
+	cordoned := cordonedNodes(ko)
+	if len(cordoned) != 1 || cordoned[0] != "node1" {
+		t.Errorf("Expected node1 to be reported as cordoned, got %v", cordoned)
+	}
+	// the cordoned node must not be counted towards the expected replicas
+	nodes, err := selectNodes(cfg, ko, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, n := range nodes {
+		if n == "node1" {
+			t.Errorf("Cordoned node1 was selected for the test workloads")
+		}
+	}
+}
+
+func TestNotReadyNodes(t *testing.T) {
+	cfg := &config.Config{}
+	ko := KubeOutput{
+		Success:     true,
+		CombinedOut: SampleNodeStatusResponse,
+		RawOut:      []byte(SampleNodeStatusResponse),
+	}
+
+	ready, notReady := nodesByReadiness(ko)
+	if len(ready) != 2 || len(notReady) != 1 || notReady[0] != "node3" {
+		t.Errorf("Unexpected readiness split: ready %v, not ready %v", ready, notReady)
+	}
+	if count := readySchedulableCount(cfg, ko); count != 1 {
+		t.Errorf("Expected 1 Ready schedulable node, got %d", count)
+	}
+
+	nodes, err := selectNodes(cfg, ko, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(nodes) != 1 || nodes[0] != "node2" {
+		t.Errorf("Expected only node2 to be selected, got %v", nodes)
+	}
+
+	cfg.RequireAllNodes = true
+	nodes, err = selectNodes(cfg, ko, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(nodes) != 2 || nodes[0] != "node2" || nodes[1] != "node3" {
+		t.Errorf("Expected node2 and node3 to be selected, got %v", nodes)
+	}
+}
+
+// SampleNodeStatusResponse holds a cordoned node, a Ready node and a NotReady node
+const SampleNodeStatusResponse = `
+{
+    "kind": "List",
+    "apiVersion": "v1",
+    "metadata": {},
+    "items": [
+        {
+            "kind": "Node",
+            "apiVersion": "v1",
+            "metadata": {
+                "name": "node1"
+            },
+            "spec": {
+                "unschedulable": true
+            },
+            "status": {
+                "conditions": [
+                    {
+                        "type": "Ready",
+                        "status": "True"
+                    }
+                ]
+            }
+        },
+        {
+            "kind": "Node",
+            "apiVersion": "v1",
+            "metadata": {
+                "name": "node2"
+            },
+            "spec": {},
+            "status": {
+                "conditions": [
+                    {
+                        "type": "MemoryPressure",
+                        "status": "False"
+                    },
+                    {
+                        "type": "Ready",
+                        "status": "True"
+                    }
+                ]
+            }
+        },
+        {
+            "kind": "Node",
+            "apiVersion": "v1",
+            "metadata": {
+                "name": "node3"
+            },
+            "spec": {},
+            "status": {
+                "conditions": [
+                    {
+                        "type": "Ready",
+                        "status": "Unknown"
+                    }
+                ]
+            }
+        }
+    ]
+}
+`
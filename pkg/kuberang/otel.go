@@ -0,0 +1,292 @@
+package kuberang
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+// otlpSpanKind and otlpStatus codes as defined by the OTLP protocol
+const (
+	otlpSpanKindInternal = 1
+	otlpStatusOK         = 1
+	otlpStatusError      = 2
+)
+
+type otlpAttribute struct {
+	Key   string            `json:"key"`
+	Value map[string]string `json:"value"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes"`
+	Status            struct {
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	} `json:"status"`
+}
+
+// kubectlCall is a kubectl invocation made while tracing
+type kubectlCall struct {
+	args    []string
+	start   time.Time
+	end     time.Time
+	success bool
+}
+
+// kubectlTrace collects the kubectl invocations of a traced run. Checks may
+// run kubectl in parallel.
+type kubectlTrace struct {
+	mu    sync.Mutex
+	calls []kubectlCall
+}
+
+func (t *kubectlTrace) record(c kubectlCall) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.calls = append(t.calls, c)
+}
+
+// otelTracesURL returns the URL receiving the traces, from --otel-endpoint
+// or the standard OTEL_EXPORTER_OTLP_TRACES_ENDPOINT and
+// OTEL_EXPORTER_OTLP_ENDPOINT environment variables, or an empty string when
+// tracing is off. OTEL_SDK_DISABLED=true turns tracing off.
+func otelTracesURL(cfg *config.Config) string {
+	if strings.ToLower(os.Getenv("OTEL_SDK_DISABLED")) == "true" {
+		return ""
+	}
+	if cfg.OTelEndpoint != "" {
+		return strings.TrimSuffix(cfg.OTelEndpoint, "/") + "/v1/traces"
+	}
+	if url := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"); url != "" {
+		return url
+	}
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); endpoint != "" {
+		return strings.TrimSuffix(endpoint, "/") + "/v1/traces"
+	}
+	return ""
+}
+
+// otelKeyValues parses the comma-separated key=value pairs of an OTEL_*
+// environment variable such as OTEL_EXPORTER_OTLP_HEADERS. Malformed pairs
+// are left out.
+func otelKeyValues(s string) [][2]string {
+	pairs := [][2]string{}
+	for _, pair := range strings.Split(s, ",") {
+		i := strings.Index(pair, "=")
+		if i <= 0 {
+			continue
+		}
+		pairs = append(pairs, [2]string{strings.TrimSpace(pair[:i]), strings.TrimSpace(pair[i+1:])})
+	}
+	return pairs
+}
+
+// otelTimeout returns the export timeout, from OTEL_EXPORTER_OTLP_TIMEOUT in
+// milliseconds when set
+func otelTimeout() time.Duration {
+	for _, env := range []string{"OTEL_EXPORTER_OTLP_TRACES_TIMEOUT", "OTEL_EXPORTER_OTLP_TIMEOUT"} {
+		if ms, err := strconv.Atoi(os.Getenv(env)); err == nil && ms > 0 {
+			return time.Duration(ms) * time.Millisecond
+		}
+	}
+	return 10 * time.Second
+}
+
+// exportTrace sends the run as an OpenTelemetry trace to the OTLP/HTTP
+// traces URL, the run being the root span, every check one of its children
+// and every kubectl invocation a child of the check it was made for. The
+// headers set with OTEL_EXPORTER_OTLP_HEADERS are sent along.
+func exportTrace(url string, result *CheckResult) error {
+	b, err := json.Marshal(traceRequest(result))
+	if err != nil {
+		return fmt.Errorf("error marshaling trace: %v", err)
+	}
+	req, err := http.NewRequest("POST", url, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("error exporting trace: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for _, env := range []string{"OTEL_EXPORTER_OTLP_HEADERS", "OTEL_EXPORTER_OTLP_TRACES_HEADERS"} {
+		for _, h := range otelKeyValues(os.Getenv(env)) {
+			req.Header.Set(h[0], h[1])
+		}
+	}
+	client := http.Client{Timeout: otelTimeout()}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error exporting trace: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("error exporting trace: %s returned %s", url, resp.Status)
+	}
+	return nil
+}
+
+// traceRequest returns the OTLP/JSON export request holding the spans of the
+// run. A kubectl invocation belongs to the first check whose span it starts
+// in, and invocations outside of any check belong to the run.
+func traceRequest(result *CheckResult) map[string]interface{} {
+	traceID := randomHex(16)
+	root := otlpSpan{
+		TraceID:           traceID,
+		SpanID:            randomHex(8),
+		Name:              "kuberang",
+		Kind:              otlpSpanKindInternal,
+		StartTimeUnixNano: unixNano(result.StartTime),
+		EndTimeUnixNano:   unixNano(result.EndTime),
+		Attributes: []otlpAttribute{
+			stringAttribute("kuberang.test_id", fmt.Sprintf("%d", result.TestID)),
+			stringAttribute("k8s.namespace.name", result.Namespace),
+			stringAttribute("kuberang.context", result.Context),
+		},
+	}
+	root.Status.Code = otlpStatusOK
+	if !result.Success {
+		root.Status.Code = otlpStatusError
+		root.Status.Message = result.Error
+	}
+	spans := []otlpSpan{root}
+	calls := result.kubectlCalls
+	for _, c := range result.Checks {
+		start, end := c.interval()
+		span := otlpSpan{
+			TraceID:           traceID,
+			SpanID:            randomHex(8),
+			ParentSpanID:      root.SpanID,
+			Name:              c.ID,
+			Kind:              otlpSpanKindInternal,
+			StartTimeUnixNano: unixNano(start),
+			EndTimeUnixNano:   unixNano(end),
+			Attributes: []otlpAttribute{
+				stringAttribute("kuberang.check.id", c.ID),
+				stringAttribute("kuberang.check.name", c.Name),
+				stringAttribute("kuberang.check.status", c.Status),
+				stringAttribute("k8s.namespace.name", result.Namespace),
+				stringAttribute("kuberang.context", result.Context),
+			},
+		}
+		if c.Node != "" {
+			span.Attributes = append(span.Attributes, stringAttribute("kuberang.check.node", c.Node))
+		}
+		if c.podIP != "" {
+			span.Attributes = append(span.Attributes, stringAttribute("kuberang.check.pod_ip", c.podIP))
+		}
+		if c.Attempts > 0 {
+			span.Attributes = append(span.Attributes, intAttribute("kuberang.check.attempts", c.Attempts))
+		}
+		span.Status.Code = otlpStatusOK
+		if c.Status == StatusError {
+			span.Status.Code = otlpStatusError
+			span.Status.Message = c.Detail
+		}
+		spans = append(spans, span)
+		// Calls are recorded as they end, which is close enough to the order
+		// in which they started
+		remaining := calls[:0:0]
+		for _, call := range calls {
+			if call.start.Before(end) && !call.start.Before(start) {
+				spans = append(spans, kubectlSpan(traceID, span.SpanID, call))
+			} else {
+				remaining = append(remaining, call)
+			}
+		}
+		calls = remaining
+	}
+	for _, call := range calls {
+		spans = append(spans, kubectlSpan(traceID, root.SpanID, call))
+	}
+	return map[string]interface{}{
+		"resourceSpans": []interface{}{
+			map[string]interface{}{
+				"resource": map[string]interface{}{
+					"attributes": resourceAttributes(),
+				},
+				"scopeSpans": []interface{}{
+					map[string]interface{}{
+						"scope": map[string]interface{}{"name": "github.com/apprenda/kuberang"},
+						"spans": spans,
+					},
+				},
+			},
+		},
+	}
+}
+
+// kubectlSpan returns the span of a kubectl invocation
+func kubectlSpan(traceID, parentSpanID string, call kubectlCall) otlpSpan {
+	verb := "kubectl"
+	if len(call.args) > 0 {
+		verb += " " + call.args[0]
+	}
+	span := otlpSpan{
+		TraceID:           traceID,
+		SpanID:            randomHex(8),
+		ParentSpanID:      parentSpanID,
+		Name:              verb,
+		Kind:              otlpSpanKindInternal,
+		StartTimeUnixNano: unixNano(call.start),
+		EndTimeUnixNano:   unixNano(call.end),
+		Attributes:        []otlpAttribute{stringAttribute("kuberang.kubectl.args", strings.Join(call.args, " "))},
+	}
+	span.Status.Code = otlpStatusOK
+	if !call.success {
+		span.Status.Code = otlpStatusError
+	}
+	return span
+}
+
+// resourceAttributes describes kuberang as the service emitting the trace,
+// as named by OTEL_SERVICE_NAME, along with the OTEL_RESOURCE_ATTRIBUTES
+func resourceAttributes() []otlpAttribute {
+	attributes := []otlpAttribute{}
+	for _, a := range otelKeyValues(os.Getenv("OTEL_RESOURCE_ATTRIBUTES")) {
+		if a[0] != "service.name" {
+			attributes = append(attributes, stringAttribute(a[0], a[1]))
+		}
+	}
+	name := os.Getenv("OTEL_SERVICE_NAME")
+	if name == "" {
+		name = "kuberang"
+	}
+	return append(attributes, stringAttribute("service.name", name))
+}
+
+func stringAttribute(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: map[string]string{"stringValue": value}}
+}
+
+// intAttribute returns an integer attribute, encoded as a string as OTLP/JSON
+// does for 64-bit integers
+func intAttribute(key string, value int) otlpAttribute {
+	return otlpAttribute{Key: key, Value: map[string]string{"intValue": fmt.Sprintf("%d", value)}}
+}
+
+func unixNano(t time.Time) string {
+	return fmt.Sprintf("%d", t.UnixNano())
+}
+
+// randomHex returns n random bytes, hex encoded, for trace and span IDs
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
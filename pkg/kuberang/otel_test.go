@@ -0,0 +1,104 @@
+package kuberang
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+func TestTraceRequest(t *testing.T) {
+	start := time.Unix(100, 0)
+	result := &CheckResult{
+		Success:   false,
+		StartTime: start,
+		EndTime:   start.Add(3 * time.Second),
+		Checks: []Check{
+			{ID: "kubectl-configured", Status: StatusOK, start: start, time: start.Add(time.Second)},
+			{ID: "pod-ip-from-pod", Status: StatusError, Detail: "timed out", Node: "node2", Attempts: 3, MaxAttempts: 3, start: start.Add(time.Second), time: start.Add(2 * time.Second), podIP: "10.244.1.5"},
+		},
+	}
+	b, _ := json.Marshal(traceRequest(result))
+	req := struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []otlpSpan `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}{}
+	if err := json.Unmarshal(b, &req); err != nil {
+		t.Fatal(err)
+	}
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 3 {
+		t.Fatalf("Expected a root span and 2 check spans, got %d", len(spans))
+	}
+	if spans[0].Status.Code != otlpStatusError || spans[1].ParentSpanID != spans[0].SpanID || spans[1].TraceID != spans[0].TraceID {
+		t.Errorf("Unexpected root span or parent: %+v", spans[:2])
+	}
+	if check := spans[2]; check.StartTimeUnixNano != "101000000000" || check.EndTimeUnixNano != "102000000000" || check.Status.Code != otlpStatusError {
+		t.Errorf("Unexpected check span: %+v", check)
+	}
+	attributes := map[string]map[string]string{}
+	for _, a := range spans[2].Attributes {
+		attributes[a.Key] = a.Value
+	}
+	if attributes["kuberang.check.node"]["stringValue"] != "node2" || attributes["kuberang.check.pod_ip"]["stringValue"] != "10.244.1.5" || attributes["kuberang.check.attempts"]["intValue"] != "3" {
+		t.Errorf("Expected the node, pod IP and attempts of the check, got %v", attributes)
+	}
+	for _, a := range spans[1].Attributes {
+		if a.Key == "kuberang.check.node" || a.Key == "kuberang.check.pod_ip" || a.Key == "kuberang.check.attempts" {
+			t.Errorf("Unexpected attribute %v on a check without node, pod or attempts", a)
+		}
+	}
+}
+
+func TestTraceRequestKubectlSpans(t *testing.T) {
+	start := time.Unix(100, 0)
+	result := &CheckResult{
+		StartTime: start,
+		EndTime:   start.Add(3 * time.Second),
+		Checks: []Check{
+			{ID: "kubectl-configured", Status: StatusOK, start: start, time: start.Add(time.Second)},
+		},
+		kubectlCalls: []kubectlCall{
+			{args: []string{"version"}, start: start.Add(100 * time.Millisecond), end: start.Add(900 * time.Millisecond), success: true},
+			{args: []string{"delete", "deployments", "kuberang-busybox"}, start: start.Add(2 * time.Second), end: start.Add(3 * time.Second)},
+		},
+	}
+	spans := traceRequest(result)["resourceSpans"].([]interface{})[0].(map[string]interface{})["scopeSpans"].([]interface{})[0].(map[string]interface{})["spans"].([]otlpSpan)
+	if len(spans) != 4 {
+		t.Fatalf("Expected a root, a check and 2 kubectl spans, got %d", len(spans))
+	}
+	if spans[2].Name != "kubectl version" || spans[2].ParentSpanID != spans[1].SpanID {
+		t.Errorf("Expected kubectl version to be a child of the check: %+v", spans[2])
+	}
+	if spans[3].Name != "kubectl delete" || spans[3].ParentSpanID != spans[0].SpanID || spans[3].Status.Code != otlpStatusError {
+		t.Errorf("Expected kubectl delete to be a failed child of the run: %+v", spans[3])
+	}
+}
+
+func TestOTelTracesURL(t *testing.T) {
+	cfg := &config.Config{}
+	for _, env := range []string{"OTEL_SDK_DISABLED", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "OTEL_EXPORTER_OTLP_ENDPOINT"} {
+		defer os.Setenv(env, os.Getenv(env))
+		os.Unsetenv(env)
+	}
+	if url := otelTracesURL(cfg); url != "" {
+		t.Errorf("Expected tracing to be off, got %q", url)
+	}
+	os.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318/")
+	if url := otelTracesURL(cfg); url != "http://collector:4318/v1/traces" {
+		t.Errorf("Unexpected URL from OTEL_EXPORTER_OTLP_ENDPOINT: %q", url)
+	}
+	cfg.OTelEndpoint = "http://other:4318"
+	if url := otelTracesURL(cfg); url != "http://other:4318/v1/traces" {
+		t.Errorf("Expected --otel-endpoint to take precedence, got %q", url)
+	}
+	os.Setenv("OTEL_SDK_DISABLED", "true")
+	if url := otelTracesURL(cfg); url != "" {
+		t.Errorf("Expected OTEL_SDK_DISABLED to turn tracing off, got %q", url)
+	}
+}
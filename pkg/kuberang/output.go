@@ -0,0 +1,111 @@
+package kuberang
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"text/template"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+// Output formats supported by kuberang
+const (
+	OutputSimple   = "simple"
+	OutputJSON     = "json"
+	OutputTemplate = "template"
+	OutputCompact  = "compact"
+)
+
+type renderFunc func(out io.Writer, result *CheckResult) error
+
+// templateFuncs are made available to user supplied output templates
+var templateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+}
+
+// resultRenderer returns the function that renders the result of a run in
+// the configured output format. A nil function is returned for the simple
+// format, in which case results are pretty printed as the checks run.
+func resultRenderer(cfg *config.Config) (renderFunc, error) {
+	switch cfg.OutputFormat {
+	case "", OutputSimple:
+		if cfg.GroupBy == GroupByNode {
+			return func(out io.Writer, result *CheckResult) error {
+				return renderGroupedByNode(cfg, out, result)
+			}, nil
+		}
+		return nil, nil
+	case OutputJSON:
+		return renderJSON, nil
+	case OutputCompact:
+		return renderCompact, nil
+	case OutputGitHub:
+		return func(out io.Writer, result *CheckResult) error {
+			return renderGitHub(cfg, out, result)
+		}, nil
+	case OutputTeamCity:
+		// The service messages are printed as the checks run
+		return func(io.Writer, *CheckResult) error { return nil }, nil
+	case OutputTemplate:
+		tmpl, err := parseOutputTemplate(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return func(out io.Writer, result *CheckResult) error {
+			return tmpl.Execute(out, result)
+		}, nil
+	}
+	return nil, fmt.Errorf("Unsupported output format %q", cfg.OutputFormat)
+}
+
+func renderJSON(out io.Writer, result *CheckResult) error {
+	b, err := json.MarshalIndent(result, "", "    ")
+	if err != nil {
+		return fmt.Errorf("error marshaling result: %v", err)
+	}
+	fmt.Fprintln(out, string(b))
+	return nil
+}
+
+// renderCompact renders the result as a single greppable line, e.g.
+// kuberang: PASS (23/24, ctx=prod)
+func renderCompact(out io.Writer, result *CheckResult) error {
+	status := "PASS"
+	if !result.Success {
+		status = "FAIL"
+	}
+	passed := len(result.Checks) - len(result.Failed())
+	line := fmt.Sprintf("kuberang: %s (%d/%d", status, passed, len(result.Checks))
+	if result.Context != "" {
+		line += ", ctx=" + result.Context
+	}
+	_, err := fmt.Fprintln(out, line+")")
+	return err
+}
+
+// parseOutputTemplate parses the template given inline or in a file
+func parseOutputTemplate(cfg *config.Config) (*template.Template, error) {
+	text := cfg.Template
+	switch {
+	case cfg.Template != "" && cfg.TemplateFile != "":
+		return nil, errors.New("Only one of --template and --template-file can be used")
+	case cfg.TemplateFile != "":
+		b, err := ioutil.ReadFile(cfg.TemplateFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading template file: %v", err)
+		}
+		text = string(b)
+	case cfg.Template == "":
+		return nil, errors.New("The template output format requires --template or --template-file")
+	}
+	tmpl, err := template.New("output").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing template: %v", err)
+	}
+	return tmpl, nil
+}
@@ -0,0 +1,70 @@
+package kuberang
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+func sampleResult() *CheckResult {
+	return &CheckResult{
+		Success:   false,
+		TestID:    42,
+		Namespace: "default",
+		Checks: []Check{
+			{ID: "service-ip-from-pod", Name: "Accessed Nginx service at 10.0.0.1 from BusyBox", Status: StatusOK},
+			{ID: "pod-ip-from-pod", Name: "Accessed Nginx pod at 172.16.3.5 from BusyBox", Status: StatusError, Detail: "wget: download timed out\n"},
+			{ID: "internet-from-node", Name: "Accessed Google.com from this node", Status: StatusIgnored},
+		},
+	}
+}
+
+func TestRenderJSON(t *testing.T) {
+	out := &bytes.Buffer{}
+	if err := renderJSON(out, sampleResult()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	result := CheckResult{}
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		t.Fatalf("Output is not valid JSON: %v", err)
+	}
+	if len(result.Checks) != 3 || result.Checks[1].Status != StatusError {
+		t.Errorf("Unexpected checks in output: %+v", result.Checks)
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.OutputFormat = OutputTemplate
+	cfg.Template = `{{if .Success}}PASS{{else}}FAIL{{end}}{{range .Failed}} {{.ID}}{{end}}`
+	render, err := resultRenderer(cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	out := &bytes.Buffer{}
+	if err := render(out, sampleResult()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if out.String() != "FAIL pod-ip-from-pod" {
+		t.Errorf("Unexpected template output: %q", out.String())
+	}
+
+	cfg.Template = ""
+	if _, err := resultRenderer(cfg); err == nil {
+		t.Errorf("Expected an error when no template is given")
+	}
+}
+
+func TestRenderCompact(t *testing.T) {
+	result := sampleResult()
+	result.Context = "prod"
+	var b bytes.Buffer
+	if err := renderCompact(&b, result); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "kuberang: FAIL (2/3, ctx=prod)\n"; b.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, b.String())
+	}
+}
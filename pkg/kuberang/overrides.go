@@ -0,0 +1,41 @@
+package kuberang
+
+import "encoding/json"
+
+// podSpecOverrides returns the `kubectl run --overrides` argument that merges
+// the given fields into the pod template of the generated deployment. An
+// empty slice is returned when there is nothing to override, so the result
+// can always be appended to the kubectl arguments.
+func podSpecOverrides(podSpec map[string]interface{}) []string {
+	if len(podSpec) == 0 {
+		return []string{}
+	}
+	overrides := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": podSpec,
+			},
+		},
+	}
+	b, _ := json.Marshal(overrides)
+	return []string{"--overrides=" + string(b)}
+}
+
+// podOverrides returns the `kubectl run --overrides` argument that merges the
+// given fields into the spec of the pod run with --restart=Never. Like
+// podSpecOverrides, it returns an empty slice when there is nothing to
+// override.
+func podOverrides(podSpec map[string]interface{}) []string {
+	if len(podSpec) == 0 {
+		return []string{}
+	}
+	b, _ := json.Marshal(map[string]interface{}{"spec": podSpec})
+	return []string{"--overrides=" + string(b)}
+}
+
+// appendToList appends the item to the list found under the key of the given
+// object, creating the list if needed
+func appendToList(object map[string]interface{}, key string, item interface{}) {
+	list, _ := object[key].([]interface{})
+	object[key] = append(list, item)
+}
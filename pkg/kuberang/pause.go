@@ -0,0 +1,61 @@
+package kuberang
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/apprenda/kuberang/pkg/util"
+)
+
+// The prompt of --pause-before-cleanup reads the answer from pauseIn and
+// writes to pauseOut, when isTerminal says someone may answer it
+var (
+	pauseIn              = os.Stdin
+	pauseOut   io.Writer = os.Stderr
+	isTerminal           = util.IsTerminal
+)
+
+// keepWorkloads offers the user the chance to keep the test workloads of a
+// failed run for investigation, and returns true if they should be kept.
+// Cleanup proceeds once the pause timeout expires, so that unattended runs
+// do not hang, and right away when the run is interrupted, so it is asked
+// with the context of the run rather than the one of the cleanup. The
+// prompt goes to stderr, out of the way of the structured output formats.
+func keepWorkloads(r *reporter, w *workloads) bool {
+	if !isTerminal(pauseIn) {
+		printLine(pauseOut, "--pause-before-cleanup has no effect without a terminal, cleaning up")
+		return false
+	}
+	printLine(pauseOut, "")
+	printLine(pauseOut, "Checks failed. The following objects are about to be deleted:")
+	if ko := r.kube.run("get", scannedKinds, "-l", fmt.Sprintf("kuberang/testid=%d", w.testID), "-o", "json"); ko.Success {
+		printObjects(pauseOut, ko.Objects())
+	}
+	return askToKeep(r, pauseOut, pauseIn, r.cfg.PauseTimeout)
+}
+
+// askToKeep waits for the user to press enter, or to type keep, for up to
+// the timeout. An interrupted run cleans up without waiting.
+func askToKeep(r *reporter, out io.Writer, in io.Reader, timeout time.Duration) bool {
+	printLine(out, "Press enter to clean up, or type keep to leave them running (cleaning up in %v)", timeout)
+	answers := make(chan string, 1)
+	go func() {
+		answer, _ := bufio.NewReader(in).ReadString('\n')
+		answers <- strings.TrimSpace(answer)
+	}()
+	select {
+	case answer := <-answers:
+		if strings.ToLower(answer) == "keep" {
+			r.skipped("cleanup", "Cleaned up the test workloads", "kept for investigation")
+			return true
+		}
+	case <-time.After(timeout):
+		printLine(out, "No answer after %v, cleaning up", timeout)
+	case <-r.ctx.Done():
+	}
+	return false
+}
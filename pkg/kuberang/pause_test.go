@@ -0,0 +1,135 @@
+package kuberang
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAskToKeep(t *testing.T) {
+	tests := []struct {
+		in   io.Reader
+		keep bool
+	}{
+		{strings.NewReader("keep\n"), true},
+		{strings.NewReader("KEEP\n"), true},
+		{strings.NewReader("\n"), false},
+	}
+	for _, test := range tests {
+		r := newReporter(&bytes.Buffer{}, &CheckResult{})
+		if keep := askToKeep(r, &bytes.Buffer{}, test.in, time.Second); keep != test.keep {
+			t.Errorf("askToKeep returned %v, expected %v", keep, test.keep)
+		}
+	}
+
+	// Nobody answers
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	out := &bytes.Buffer{}
+	if askToKeep(newReporter(&bytes.Buffer{}, &CheckResult{}), out, pr, 10*time.Millisecond) {
+		t.Errorf("Expected cleanup to proceed when nobody answers")
+	}
+	if !strings.Contains(out.String(), "No answer after") {
+		t.Errorf("Expected a note about the timeout, got %q", out.String())
+	}
+
+	// Interrupted while waiting
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := newReporter(&bytes.Buffer{}, &CheckResult{})
+	r.ctx = ctx
+	if askToKeep(r, &bytes.Buffer{}, pr, time.Minute) {
+		t.Errorf("Expected cleanup to proceed when interrupted")
+	}
+}
+
+func TestPauseBeforeCleanupInterrupted(t *testing.T) {
+	defer func(c func(context.Context, []byte, ...string) ([]byte, error)) { kubectlCommand = c }(kubectlCommand)
+	defer func(in *os.File, out io.Writer, terminal func(*os.File) bool) {
+		pauseIn, pauseOut, isTerminal = in, out, terminal
+	}(pauseIn, pauseOut, isTerminal)
+	// Nobody answers the prompt
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pr.Close()
+	defer pw.Close()
+	prompted := make(chan struct{})
+	pauseIn, pauseOut = pr, &promptWriter{prompted: prompted}
+	isTerminal = func(*os.File) bool { return true }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var mu sync.Mutex
+	deleted := false
+	kubectlCommand = func(kctx context.Context, input []byte, args ...string) ([]byte, error) {
+		// The global flags come first
+		for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+			args = args[1:]
+		}
+		command := strings.Join(args, " ")
+		switch {
+		case kctx.Err() != nil:
+			return nil, kctx.Err()
+		case strings.HasPrefix(command, "version"), strings.HasPrefix(command, "create -f -"):
+			return nil, nil
+		case strings.HasPrefix(command, "delete namespace"):
+			mu.Lock()
+			deleted = true
+			mu.Unlock()
+			return nil, nil
+		case strings.HasPrefix(command, "get namespace "):
+			return []byte("Error from server (NotFound): namespaces not found\n"), errors.New("exit status 1")
+		}
+		return []byte("error: the server is unavailable\n"), errors.New("exit status 1")
+	}
+	// Interrupted while the prompt waits for an answer
+	go func() {
+		<-prompted
+		cancel()
+	}()
+
+	dir, err := ioutil.TempDir("", "kuberang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cfg := testConfig(t, dir)
+	cfg.EphemeralNamespace = true
+	cfg.NamespaceDeletionTimeout = time.Minute
+	cfg.PauseBeforeCleanup = true
+	cfg.PauseTimeout = time.Minute
+	start := time.Now()
+	if err := CheckKubernetes(ctx, cfg, ioutil.Discard); err == nil {
+		t.Error("Expected the run to fail")
+	}
+	if elapsed := time.Since(start); elapsed > 30*time.Second {
+		t.Errorf("Expected the interruption to end the pause, the run took %v", elapsed)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !deleted {
+		t.Error("Expected the namespace to be deleted with a live context after the interruption")
+	}
+}
+
+// promptWriter closes prompted once the pause prompt is written
+type promptWriter struct {
+	prompted chan struct{}
+	once     sync.Once
+}
+
+func (w *promptWriter) Write(p []byte) (int, error) {
+	if strings.Contains(string(p), "Press enter") {
+		w.once.Do(func() { close(w.prompted) })
+	}
+	return len(p), nil
+}
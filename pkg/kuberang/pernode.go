@@ -0,0 +1,34 @@
+package kuberang
+
+// checkServiceFromEveryNode accesses the nginx service IP and an nginx pod IP
+// from the probe pod of every node. A node reaching the pod but not the
+// service points at kube-proxy or SNAT rather than at the pod network.
+func checkServiceFromEveryNode(r *reporter, w *workloads, serviceIP string, podIPs []string) bool {
+	if len(podIPs) == 0 {
+		return true
+	}
+	failed := make([]bool, len(w.nodes))
+	runConcurrently(r, len(w.nodes), r.cfg.Parallelism, func(r *reporter, i int) {
+		node := w.nodes[i]
+		name := "Accessed Nginx service at " + serviceIP + " from a pod on node " + node
+		pod := w.probePods[node]
+		r.onNode(node)
+		ko := wgetNginx(r.kube, pod, serviceIP)
+		if ko.Success {
+			r.ok("service-ip-from-node-pod", name)
+			return
+		}
+		failed[i] = true
+		if wgetNginx(r.kube, pod, podIPs[0]).Success {
+			r.err("service-ip-from-node-pod", name, "Pods on this node reach Nginx pod "+podIPs[0]+" but not the service, check kube-proxy and the SNAT rules of the node\n"+ko.CombinedOut)
+			return
+		}
+		r.err("service-ip-from-node-pod", name, "Pods on this node reach neither the service nor Nginx pod "+podIPs[0]+", check the pod network of the node\n"+ko.CombinedOut)
+	})
+	for _, f := range failed {
+		if f {
+			return false
+		}
+	}
+	return true
+}
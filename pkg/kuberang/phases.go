@@ -0,0 +1,70 @@
+package kuberang
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Phases of a run, timed separately to tell whether a slow run spends its
+// time scheduling the test workloads or accessing them
+const (
+	// PhasePrecheck covers kubectl, the preconditions and the node discovery
+	PhasePrecheck = "precheck"
+	// PhaseDeploy covers deploying the test workloads and waiting for them,
+	// each wait being bounded by deploymentTimeout
+	PhaseDeploy = "deploy"
+	// PhaseConnectivity covers the checks accessing the test workloads
+	PhaseConnectivity = "connectivity"
+	// PhaseCleanup covers removing the test workloads
+	PhaseCleanup = "cleanup"
+)
+
+// PhaseTiming is the time a run spent in one of its phases
+type PhaseTiming struct {
+	Name       string `json:"name"`
+	DurationMs int64  `json:"durationMs"`
+	// start is when the phase started
+	start time.Time
+}
+
+// startPhase ends the phase in progress, if any, and starts timing the next
+func (r *reporter) startPhase(name string) {
+	r.endPhase()
+	r.result.Phases = append(r.result.Phases, PhaseTiming{Name: name, start: time.Now()})
+	r.inPhase = true
+}
+
+// endPhase records the duration of the phase in progress, if any
+func (r *reporter) endPhase() {
+	if !r.inPhase {
+		return
+	}
+	p := &r.result.Phases[len(r.result.Phases)-1]
+	p.DurationMs = int64(time.Since(p.start) / time.Millisecond)
+	r.inPhase = false
+}
+
+// printTimings prints the duration of the run along with the time spent in
+// each of its phases
+func printTimings(out io.Writer, result *CheckResult) {
+	phases := []string{}
+	for _, p := range result.Phases {
+		phase := fmt.Sprintf("%s %v", p.Name, shortMs(p.DurationMs))
+		if p.Name == PhaseDeploy {
+			phase += fmt.Sprintf(" (timeout %v per deployment)", deploymentTimeout)
+		}
+		phases = append(phases, phase)
+	}
+	line := fmt.Sprintf("Completed in %v", shortMs(result.DurationMs))
+	if len(phases) > 0 {
+		line += ": " + strings.Join(phases, ", ")
+	}
+	fmt.Fprintln(out, line)
+}
+
+// shortMs returns a duration in milliseconds to the tenth of a second
+func shortMs(ms int64) time.Duration {
+	return time.Duration(ms/100) * 100 * time.Millisecond
+}
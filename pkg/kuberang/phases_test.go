@@ -0,0 +1,36 @@
+package kuberang
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPhases(t *testing.T) {
+	r := newReporter(&bytes.Buffer{}, &CheckResult{})
+	r.endPhase()
+	r.startPhase(PhasePrecheck)
+	r.result.Phases[0].start = time.Now().Add(-1500 * time.Millisecond)
+	r.startPhase(PhaseDeploy)
+	r.endPhase()
+	r.endPhase()
+	phases := r.result.Phases
+	if len(phases) != 2 || phases[0].Name != PhasePrecheck || phases[0].DurationMs < 1500 || phases[1].DurationMs > 1000 {
+		t.Fatalf("Unexpected phases %+v", phases)
+	}
+
+	out := &bytes.Buffer{}
+	printTimings(out, &CheckResult{DurationMs: 65432, Phases: []PhaseTiming{
+		{Name: PhasePrecheck, DurationMs: 1234},
+		{Name: PhaseDeploy, DurationMs: 60050},
+		{Name: PhaseCleanup, DurationMs: 4148},
+	}})
+	expected := "Completed in 1m5.4s: precheck 1.2s, deploy 1m0s (timeout 5m0s per deployment), cleanup 4.1s\n"
+	if out.String() != expected {
+		t.Errorf("Unexpected timings %q", out.String())
+	}
+	if strings.Contains(out.String(), PhaseConnectivity) {
+		t.Error("Expected the phases that did not run to be left out")
+	}
+}
@@ -0,0 +1,50 @@
+package kuberang
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+// plainTokens are the fixed-width prefixes of the result lines printed with
+// --plain, by check status. They are part of a documented parsing contract
+// and must not change.
+var plainTokens = map[string]string{
+	StatusOK:      "PASS ",
+	StatusError:   "FAIL ",
+	StatusWarning: "WARN ",
+	StatusSkipped: "SKIP ",
+	StatusIgnored: "IGNR ",
+}
+
+// plainDetailToken prefixes every line of the detail of a check
+const plainDetailToken = "DETAIL "
+
+// plainPrinter prints every check as it is recorded on a single line made of
+// its status token, its ID and its name, followed by its detail with one
+// DETAIL line per line. No colors or rules are printed.
+func plainPrinter(cfg *config.Config, out io.Writer) func(Check) {
+	return func(c Check) {
+		if cfg.Verbosity < 0 && c.Status != StatusError && c.Status != StatusWarning {
+			return
+		}
+		fmt.Fprintf(out, "%s%s %s\n", plainTokens[c.Status], c.ID, c.Name)
+		detail := c.Detail
+		if c.Status == StatusOK && c.Attempts > 1 {
+			detail = fmt.Sprintf("Succeeded on attempt %d/%d\n", c.Attempts, c.MaxAttempts)
+		}
+		for _, line := range strings.Split(strings.TrimRight(detail, "\n"), "\n") {
+			if line != "" {
+				fmt.Fprintf(out, "%s%s\n", plainDetailToken, line)
+			}
+		}
+	}
+}
+
+// printPlainSummary prints the number of checks by status on a single line
+func printPlainSummary(out io.Writer, result *CheckResult) {
+	s := result.Summarize()
+	fmt.Fprintf(out, "SUMMARY passed=%d failed=%d warned=%d skipped=%d ignored=%d\n", s.Passed, s.Failed, s.Warned, s.Skipped, s.Ignored)
+}
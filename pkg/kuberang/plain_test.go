@@ -0,0 +1,30 @@
+package kuberang
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPlainPrinter(t *testing.T) {
+	var out bytes.Buffer
+	r := newReporter(&bytes.Buffer{}, &CheckResult{})
+	r.listener = plainPrinter(r.cfg, &out)
+	r.ok("service-ip-from-pod", "Accessed Nginx service at 10.96.0.12 from BusyBox")
+	r.okAfter("pod-ip-from-pod", "Accessed Nginx pod at 172.16.0.5 from BusyBox", 2, 3)
+	r.err("internet-from-pod", "Accessed Google.com from BusyBox", "wget: bad address\nexit status 1\n")
+	r.skipped("emptydir-write-read", "Wrote and read back a file on an emptyDir volume", "--offline")
+	printPlainSummary(&out, r.result)
+
+	expected := "PASS service-ip-from-pod Accessed Nginx service at 10.96.0.12 from BusyBox\n" +
+		"PASS pod-ip-from-pod Accessed Nginx pod at 172.16.0.5 from BusyBox\n" +
+		"DETAIL Succeeded on attempt 2/3\n" +
+		"FAIL internet-from-pod Accessed Google.com from BusyBox\n" +
+		"DETAIL wget: bad address\n" +
+		"DETAIL exit status 1\n" +
+		"SKIP emptydir-write-read Wrote and read back a file on an emptyDir volume\n" +
+		"DETAIL --offline\n" +
+		"SUMMARY passed=2 failed=1 warned=0 skipped=1 ignored=0\n"
+	if out.String() != expected {
+		t.Errorf("Unexpected plain output:\n%s", out.String())
+	}
+}
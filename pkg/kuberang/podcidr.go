@@ -0,0 +1,62 @@
+package kuberang
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// checkPodCIDRs warns about the nginx pods whose IP is outside the pod CIDR
+// assigned to their node, a sign of IPAM problems. Some CNI plugins ignore
+// the per-node ranges, so this is only a warning, and the check is skipped
+// when no node has a range assigned.
+func checkPodCIDRs(r *reporter, pods []Pod) {
+	name := "Nginx pod IPs fall within the pod CIDR of their node"
+	ko := r.kube.getNodes()
+	if !ko.Success {
+		r.skipped("pod-ip-in-node-cidr", name, "the nodes are not visible")
+		return
+	}
+	cidrs := map[string][]string{}
+	for _, n := range ko.Nodes() {
+		if len(n.PodCIDRs) > 0 {
+			cidrs[n.Name] = n.PodCIDRs
+		}
+	}
+	if len(cidrs) == 0 {
+		r.skipped("pod-ip-in-node-cidr", name, "no node has a pod CIDR assigned")
+		return
+	}
+	if outside := podsOutsideNodeCIDR(pods, cidrs); len(outside) > 0 {
+		r.warn("pod-ip-in-node-cidr", name, strings.Join(outside, ""))
+		return
+	}
+	r.ok("pod-ip-in-node-cidr", name)
+}
+
+// podsOutsideNodeCIDR describes the pods whose IP is in none of the pod
+// CIDRs of their node. Pods on nodes without a pod CIDR are left out.
+func podsOutsideNodeCIDR(pods []Pod, cidrs map[string][]string) []string {
+	outside := []string{}
+	for _, p := range pods {
+		nodeCIDRs, ok := cidrs[p.NodeName]
+		if !ok || p.IP == "" {
+			continue
+		}
+		if !ipInCIDRs(p.IP, nodeCIDRs) {
+			outside = append(outside, fmt.Sprintf("Pod %s has IP %s, outside %s assigned to node %s\n", p.Name, p.IP, strings.Join(nodeCIDRs, ", "), p.NodeName))
+		}
+	}
+	return outside
+}
+
+// ipInCIDRs returns true if the IP is in one of the CIDRs
+func ipInCIDRs(ip string, cidrs []string) bool {
+	parsed := net.ParseIP(ip)
+	for _, cidr := range cidrs {
+		if _, ipNet, err := net.ParseCIDR(cidr); err == nil && parsed != nil && ipNet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,20 @@
+package kuberang
+
+import "testing"
+
+func TestPodsOutsideNodeCIDR(t *testing.T) {
+	cidrs := map[string][]string{
+		"node1": {"172.16.0.0/24"},
+		"node2": {"172.16.3.0/24", "fd00:10:244:3::/64"},
+	}
+	pods := []Pod{
+		{Name: "nginx-a", IP: "172.16.0.12", NodeName: "node1"},
+		{Name: "nginx-b", IP: "172.16.1.7", NodeName: "node1"},
+		{Name: "nginx-c", IP: "fd00:10:244:3::5", NodeName: "node2"},
+		{Name: "nginx-d", IP: "10.0.0.3", NodeName: "node3"},
+	}
+	outside := podsOutsideNodeCIDR(pods, cidrs)
+	if len(outside) != 1 || outside[0] != "Pod nginx-b has IP 172.16.1.7, outside 172.16.0.0/24 assigned to node node1\n" {
+		t.Errorf("Unexpected pods outside their node CIDR: %q", outside)
+	}
+}
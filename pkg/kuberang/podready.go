@@ -0,0 +1,41 @@
+package kuberang
+
+import (
+	"fmt"
+	"strings"
+)
+
+// unreadyPods describes the pods whose Ready condition is not True
+func unreadyPods(pods []Pod) []string {
+	unready := []string{}
+	for _, p := range pods {
+		if p.Ready {
+			continue
+		}
+		node := p.NodeName
+		if node == "" {
+			node = "<unscheduled>"
+		}
+		unready = append(unready, fmt.Sprintf("Pod %s on node %s is not ready (%s)\n", p.Name, node, p.Phase))
+	}
+	return unready
+}
+
+// checkNginxPodsReady confirms that every nginx pod reports the Ready
+// condition before the service is accessed, so that the connectivity checks
+// do not race pods that are scheduled but not serving yet. Unready pods are
+// expected when --min-ready-fraction allows for them, and only warned about.
+func checkNginxPodsReady(r *reporter, pods []Pod) bool {
+	name := "Every Nginx pod is Ready"
+	unready := unreadyPods(pods)
+	switch {
+	case len(unready) == 0:
+		r.ok("nginx-pods-ready", name)
+	case r.cfg.MinReadyFraction < 1:
+		r.warn("nginx-pods-ready", name, strings.Join(unready, ""))
+	default:
+		r.err("nginx-pods-ready", name, strings.Join(unready, ""))
+		return false
+	}
+	return true
+}
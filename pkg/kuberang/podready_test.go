@@ -0,0 +1,15 @@
+package kuberang
+
+import "testing"
+
+func TestUnreadyPods(t *testing.T) {
+	pods := []Pod{
+		{Name: "nginx-a", NodeName: "node1", Ready: true, Phase: "Running"},
+		{Name: "nginx-b", NodeName: "node2", Ready: false, Phase: "Running"},
+		{Name: "nginx-c", Ready: false, Phase: "Pending"},
+	}
+	unready := unreadyPods(pods)
+	if len(unready) != 2 || unready[0] != "Pod nginx-b on node node2 is not ready (Running)\n" || unready[1] != "Pod nginx-c on node <unscheduled> is not ready (Pending)\n" {
+		t.Errorf("Unexpected unready pods %q", unready)
+	}
+}
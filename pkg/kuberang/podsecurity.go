@@ -0,0 +1,85 @@
+package kuberang
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+// PodSecurityRestricted makes the test pods comply with the restricted Pod
+// Security Standard
+const PodSecurityRestricted = "restricted"
+
+// restrictedUser is the user the test pods run as with the restricted
+// preset. It is the nginx user of the nginx image, and BusyBox runs as any
+// user.
+const restrictedUser = 101
+
+// validatePodSecurity returns an error when --pod-security is not a
+// supported preset, or when it is combined with a check whose pod cannot
+// comply with it
+func validatePodSecurity(cfg *config.Config) error {
+	switch cfg.PodSecurity {
+	case "":
+		return nil
+	case PodSecurityRestricted:
+		if cfg.FromNode != "" {
+			return errors.New("--pod-security restricted cannot be used with --from-node, whose pod runs privileged in the network namespace of the node")
+		}
+		return nil
+	}
+	return fmt.Errorf("Unsupported --pod-security value %q", cfg.PodSecurity)
+}
+
+// podSecuritySpec sets the security context required by the configured Pod
+// Security preset on the given pod spec, on its main container and on its
+// init containers. With the restricted preset, the pod runs as a non-root
+// user with the runtime default seccomp profile, and its containers drop
+// every capability and cannot escalate privileges.
+//
+// nginx runs unprivileged as well: it gets writable emptyDir volumes for its
+// cache and PID file, and ports below 1024 are made unprivileged in the pod
+// network namespace so that it still listens on port 80. This sysctl is
+// allowed by the restricted profile.
+func podSecuritySpec(cfg *config.Config, spec, container map[string]interface{}, nginx bool) {
+	if cfg.PodSecurity != PodSecurityRestricted {
+		return
+	}
+	podContext := map[string]interface{}{
+		"runAsNonRoot": true,
+		"runAsUser":    restrictedUser,
+		"runAsGroup":   restrictedUser,
+		"seccompProfile": map[string]interface{}{
+			"type": "RuntimeDefault",
+		},
+	}
+	if nginx {
+		podContext["sysctls"] = []interface{}{
+			map[string]interface{}{"name": "net.ipv4.ip_unprivileged_port_start", "value": "0"},
+		}
+		for _, v := range [][2]string{{"kuberang-nginx-cache", "/var/cache/nginx"}, {"kuberang-nginx-run", "/var/run"}} {
+			appendToList(spec, "volumes", map[string]interface{}{
+				"name":     v[0],
+				"emptyDir": map[string]interface{}{},
+			})
+			appendToList(container, "volumeMounts", map[string]interface{}{
+				"name":      v[0],
+				"mountPath": v[1],
+			})
+		}
+	}
+	spec["securityContext"] = podContext
+
+	initContainers, _ := spec["initContainers"].([]interface{})
+	for _, c := range append([]interface{}{container}, initContainers...) {
+		if c, ok := c.(map[string]interface{}); ok {
+			c["securityContext"] = map[string]interface{}{
+				"allowPrivilegeEscalation": false,
+				"capabilities": map[string]interface{}{
+					"drop": []string{"ALL"},
+				},
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package kuberang
+
+import (
+	"testing"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+func TestPodSecuritySpec(t *testing.T) {
+	cfg := &config.Config{}
+	spec, container := map[string]interface{}{}, map[string]interface{}{"name": "nginx"}
+	podSecuritySpec(cfg, spec, container, true)
+	if len(spec) != 0 || len(container) != 1 {
+		t.Errorf("Expected no change without a preset, got %v and %v", spec, container)
+	}
+
+	cfg.PodSecurity = PodSecurityRestricted
+	initContainer := map[string]interface{}{"name": "generate-payload"}
+	spec["initContainers"] = []interface{}{initContainer}
+	podSecuritySpec(cfg, spec, container, true)
+	podContext := spec["securityContext"].(map[string]interface{})
+	if podContext["runAsNonRoot"] != true || podContext["sysctls"] == nil {
+		t.Errorf("Unexpected pod security context %v", podContext)
+	}
+	for _, c := range []map[string]interface{}{container, initContainer} {
+		if sc, ok := c["securityContext"].(map[string]interface{}); !ok || sc["allowPrivilegeEscalation"] != false {
+			t.Errorf("Unexpected security context of container %v", c["name"])
+		}
+	}
+	if volumes := spec["volumes"].([]interface{}); len(volumes) != 2 || len(container["volumeMounts"].([]interface{})) != 2 {
+		t.Errorf("Expected writable volumes for nginx, got %v", volumes)
+	}
+	if err := validatePodSecurity(&config.Config{PodSecurity: "baseline"}); err == nil {
+		t.Error("Expected an error for an unsupported preset")
+	}
+	if err := validatePodSecurity(&config.Config{PodSecurity: PodSecurityRestricted, FromNode: "node1"}); err == nil {
+		t.Error("Expected an error for the privileged pod of --from-node")
+	}
+}
+
+func TestGeneratedPodsComplyWithRestricted(t *testing.T) {
+	cfg := &config.Config{PodSecurity: PodSecurityRestricted, ServiceAccount: defaultServiceAccount, DNSProbeImage: "dig"}
+	specs := generatedPodSpecs(t, newWorkloads(cfg, 1))
+	for _, name := range []string{"kuberang-nginx", "kuberang-busybox", "kuberang-dns-probe-1"} {
+		if _, ok := specs[name]; !ok {
+			t.Errorf("Expected the %s pod to be run", name)
+		}
+	}
+	for name, spec := range specs {
+		if name == "from node" {
+			continue
+		}
+		if podContext, ok := spec["securityContext"].(map[string]interface{}); !ok || podContext["runAsNonRoot"] != true {
+			t.Errorf("Expected the %s pod to run as non-root, got %v", name, spec["securityContext"])
+		}
+		initContainers, _ := spec["initContainers"].([]interface{})
+		for _, c := range append(spec["containers"].([]interface{}), initContainers...) {
+			c := c.(map[string]interface{})
+			if sc, ok := c["securityContext"].(map[string]interface{}); !ok || sc["allowPrivilegeEscalation"] != false {
+				t.Errorf("Unexpected security context of container %v of the %s pod", c["name"], name)
+			}
+		}
+	}
+}
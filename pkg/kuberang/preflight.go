@@ -0,0 +1,57 @@
+package kuberang
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+// Preflight runs the preconditions of the checks without deploying anything,
+// to tell quickly whether the cluster is worth testing
+func Preflight(ctx context.Context, cfg *config.Config, out io.Writer) error {
+	return run(ctx, cfg, out, runPreflight)
+}
+
+func runPreflight(r *reporter, w *workloads) error {
+	r.startPhase(PhasePrecheck)
+	if !precheckKubectl(r) {
+		return errors.New("Kubectl must be configured on this machine before running kuberang")
+	}
+	r.ok("kubectl-configured", "Kubectl configured on this node")
+
+	success := checkPreconditions(r, w)
+	checkNoLeftovers(r)
+	if err := discoverNodes(r, w); err != nil {
+		return err
+	}
+	if !success {
+		return errors.New("Pre-conditions failed")
+	}
+	return nil
+}
+
+// checkNoLeftovers warns about kuberang objects left behind in the namespace
+// by previous runs, which the next run deletes
+func checkNoLeftovers(r *reporter) {
+	name := "No kuberang objects left behind in the namespace"
+	leftovers, err := findLeftovers(r.kube, false)
+	if err != nil {
+		r.skipped("no-leftovers", name, err.Error())
+		return
+	}
+	refs := []string{}
+	for _, o := range leftovers {
+		if !o.Owned {
+			refs = append(refs, strings.ToLower(o.Kind)+"/"+o.Name)
+		}
+	}
+	if len(refs) > 0 {
+		r.warn("no-leftovers", name, fmt.Sprintf("Found %s\n", strings.Join(refs, ", ")))
+		return
+	}
+	r.ok("no-leftovers", name)
+}
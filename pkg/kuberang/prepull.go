@@ -0,0 +1,163 @@
+package kuberang
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// imagePullFailures are the container waiting reasons that mean an image
+// cannot be pulled
+var imagePullFailures = map[string]bool{
+	"ErrImagePull":     true,
+	"ImagePullBackOff": true,
+	"InvalidImageName": true,
+}
+
+// prePullImages pulls the test images on every node under test by running a
+// DaemonSet whose init containers use the images, and reports the pull
+// latency of each node. The DaemonSet is removed along with the other test
+// workloads.
+func prePullImages(r *reporter, w *workloads) bool {
+	manifest, _ := json.Marshal(prePullDaemonSet(w))
+	if ko := r.kube.runWithInput(manifest, "create", "-f", "-"); !ko.Success {
+		r.err("prepull-start", "Issued image pre-pull request", ko.CombinedOut)
+		return false
+	}
+	w.prePullDeployed = true
+	r.ok("prepull-start", "Issued image pre-pull request")
+
+	var pulled map[string]time.Duration
+	var failed map[string]string
+	var pending []string
+	start := time.Now()
+	for {
+		ko := r.kube.run("get", "pods", "-l", w.labels("kuberang-prepull"), "-o", "json")
+		if ko.Success {
+			pulled, failed, pending = prePullStatus(w.nodes, ko.Pods())
+			if len(pending) == 0 {
+				break
+			}
+		}
+		if time.Since(start) > deploymentTimeout || !sleep(r.ctx, 1*time.Second) {
+			break
+		}
+	}
+
+	success := true
+	for _, node := range w.nodes {
+		name := "Pulled test images on node " + node
+		if latency, ok := pulled[node]; ok {
+			r.ok("prepull-node", fmt.Sprintf("%s in %v", name, latency))
+		} else if reason, ok := failed[node]; ok {
+			r.err("prepull-node", name, reason+"\n")
+			success = false
+		} else {
+			r.err("prepull-node", name, "Timed out waiting for the images to be pulled\n")
+			success = false
+		}
+	}
+	return success
+}
+
+// prePullStatus splits the given nodes into the ones that pulled the test
+// images, along with the time it took, the ones that failed to pull them,
+// along with the reason, and the ones still pulling
+func prePullStatus(nodes []string, pods []Pod) (pulled map[string]time.Duration, failed map[string]string, pending []string) {
+	pulled = map[string]time.Duration{}
+	failed = map[string]string{}
+	pending = []string{}
+	byNode := map[string]Pod{}
+	for _, p := range pods {
+		byNode[p.NodeName] = p
+	}
+	for _, node := range nodes {
+		p, ok := byNode[node]
+		if !ok {
+			pending = append(pending, node)
+			continue
+		}
+		scheduled := findCondition(p.Conditions, "PodScheduled")
+		initialized := findCondition(p.Conditions, "Initialized")
+		switch {
+		case initialized != nil && initialized.Status == "True":
+			var latency time.Duration
+			if scheduled != nil {
+				latency = initialized.LastTransitionTime.Sub(scheduled.LastTransitionTime)
+			}
+			pulled[node] = latency
+		case imagePullFailures[p.WaitingReason]:
+			failed[node] = fmt.Sprintf("Pod %s failed to pull an image: %s", p.Name, p.WaitingReason)
+		default:
+			pending = append(pending, node)
+		}
+	}
+	return pulled, failed, pending
+}
+
+// prePullDaemonSet returns the DaemonSet that pulls the test images on the
+// nodes under test. Each image is used by an init container that exits right
+// away, so the pod is initialized once every image is pulled. Like the test
+// pods, it tolerates the taints of the nodes under test.
+func prePullDaemonSet(w *workloads) map[string]interface{} {
+	labels := map[string]interface{}{
+		"app":             "kuberang-prepull",
+		"kuberang/testid": fmt.Sprintf("%d", w.testID),
+	}
+	container := map[string]interface{}{
+		"name":            "pause",
+		"image":           w.image("busybox:latest"),
+		"imagePullPolicy": "IfNotPresent",
+		"command":         []string{"sleep", "3600"},
+	}
+	spec := map[string]interface{}{
+		"affinity": pinnedNodeAffinity(w.nodes),
+		"initContainers": []interface{}{
+			prePullContainer("pull-busybox", w.image("busybox:latest")),
+			prePullContainer("pull-nginx", w.image("nginx:stable-alpine")),
+		},
+		"containers": []interface{}{container},
+	}
+	serviceAccountSpec(w.cfg, spec)
+	podSecuritySpec(w.cfg, spec, container, false)
+	tolerationsSpec(spec, w)
+	return map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "DaemonSet",
+		"metadata": map[string]interface{}{
+			"name":   w.prePull,
+			"labels": labels,
+		},
+		"spec": map[string]interface{}{
+			"selector": map[string]interface{}{
+				"matchLabels": labels,
+			},
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"labels": labels,
+				},
+				"spec": spec,
+			},
+		},
+	}
+}
+
+// prePullContainer returns an init container that always pulls the image
+// from the registry and exits
+func prePullContainer(name, image string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":            name,
+		"image":           image,
+		"imagePullPolicy": "Always",
+		"command":         []string{"true"},
+	}
+}
+
+// removePrePull removes the image pre-pull DaemonSet
+func removePrePull(r *reporter, w *workloads) {
+	if ko := runDelete(r.kube, "daemonset", w.prePull); ko.Success {
+		r.ok("cleanup-prepull", "Removed image pre-pull DaemonSet")
+	} else {
+		r.err("cleanup-prepull", "Removed image pre-pull DaemonSet", ko.CombinedOut)
+	}
+}
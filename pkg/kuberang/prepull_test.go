@@ -0,0 +1,71 @@
+package kuberang
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrePullStatus(t *testing.T) {
+	scheduled := time.Date(2016, 10, 19, 22, 41, 0, 0, time.UTC)
+	pods := []Pod{
+		{
+			Name:     "kuberang-prepull-1-a",
+			NodeName: "node1",
+			Conditions: []Condition{
+				{Type: "PodScheduled", Status: "True", LastTransitionTime: scheduled},
+				{Type: "Initialized", Status: "True", LastTransitionTime: scheduled.Add(7 * time.Second)},
+			},
+		},
+		{
+			Name:          "kuberang-prepull-1-b",
+			NodeName:      "node2",
+			WaitingReason: "ImagePullBackOff",
+			Conditions: []Condition{
+				{Type: "PodScheduled", Status: "True", LastTransitionTime: scheduled},
+				{Type: "Initialized", Status: "False", LastTransitionTime: scheduled},
+			},
+		},
+		{
+			Name:          "kuberang-prepull-1-c",
+			NodeName:      "node3",
+			WaitingReason: "PodInitializing",
+		},
+	}
+	pulled, failed, pending := prePullStatus([]string{"node1", "node2", "node3", "node4"}, pods)
+	if len(pulled) != 1 || pulled["node1"] != 7*time.Second {
+		t.Errorf("Expected node1 to have pulled the images in 7s, got %v", pulled)
+	}
+	if len(failed) != 1 || failed["node2"] == "" {
+		t.Errorf("Expected node2 to have failed to pull the images, got %v", failed)
+	}
+	if len(pending) != 2 || pending[0] != "node3" || pending[1] != "node4" {
+		t.Errorf("Expected node3 and node4 to be pending, got %v", pending)
+	}
+}
+
+func TestPowerDownRemovesPrePull(t *testing.T) {
+	defer func(c func(context.Context, []byte, ...string) ([]byte, error)) { kubectlCommand = c }(kubectlCommand)
+	calls := []string{}
+	kubectlCommand = func(ctx context.Context, input []byte, args ...string) ([]byte, error) {
+		calls = append(calls, strings.Join(args, " "))
+		return nil, nil
+	}
+	r := newReporter(ioutil.Discard, &CheckResult{})
+	r.cfg.TargetService = "web"
+	r.cfg.ForceDelete = true
+	w := newWorkloads(r.cfg, 1)
+	powerDown(r, w)
+	if len(calls) != 1 {
+		t.Errorf("Expected the DaemonSet to be left alone until it is created, got %q", calls)
+	}
+
+	calls = nil
+	w.prePullDeployed = true
+	powerDown(r, w)
+	if len(calls) != 2 || calls[0] != "delete daemonset kuberang-prepull-1 --grace-period=0 --force" {
+		t.Errorf("Expected the DaemonSet to be force deleted, got %q", calls)
+	}
+}
@@ -0,0 +1,122 @@
+package kuberang
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+// maxProbeBody bounds the part of a response searched for --expect-body
+const maxProbeBody = 1 << 20
+
+// wgetStatusLine matches the status lines printed by wget -S, and the
+// status in the error wget prints for an error status
+var wgetStatusLine = regexp.MustCompile(`HTTP/[0-9.]+ ([0-9]{3})`)
+
+// wgetHeaderLine matches the response headers printed by wget -S
+var wgetHeaderLine = regexp.MustCompile(`(?m)^  (HTTP/[0-9.]+ .*|[A-Za-z0-9-]+: .*)\n`)
+
+// wgetStatus returns the status of the last response in the output of
+// wget -S, following redirects, or 0 when there is none
+func wgetStatus(out string) int {
+	matches := wgetStatusLine.FindAllStringSubmatch(out, -1)
+	if len(matches) == 0 {
+		return 0
+	}
+	status, _ := strconv.Atoi(matches[len(matches)-1][1])
+	return status
+}
+
+// checkStatus returns an error when the status is not the one given with
+// --expect-status. Any status wget accepts is fine when it is not set.
+func checkStatus(cfg *config.Config, url string, status int) error {
+	if cfg.ExpectStatus == 0 || status == cfg.ExpectStatus {
+		return nil
+	}
+	return fmt.Errorf("%s returned status %d, expected %d\n", url, status, cfg.ExpectStatus)
+}
+
+// validateProbePath returns an error when --probe-path is not an absolute
+// path. Leaving it unset requests the root.
+func validateProbePath(path string) error {
+	if path != "" && !strings.HasPrefix(path, "/") {
+		return errors.New("--probe-path must start with /")
+	}
+	return nil
+}
+
+// probeURL returns the URL of --probe-path on the nginx address
+func probeURL(cfg *config.Config, address string) string {
+	return "http://" + address + cfg.ProbePath
+}
+
+// checkBody returns an error when the response does not contain the string
+// given with --expect-body
+func checkBody(cfg *config.Config, body string) error {
+	if cfg.ExpectBody == "" || strings.Contains(body, cfg.ExpectBody) {
+		return nil
+	}
+	return fmt.Errorf("Response does not contain %q:\n%s\n", cfg.ExpectBody, body)
+}
+
+// wgetNginx fetches --probe-path of the nginx address from the pod. A
+// response with another status than --expect-status, or without
+// --expect-body, is a failure. wget -S prints the status of the response,
+// and wget fails on error statuses even when expected.
+func wgetNginx(k *kubectl, pod, address string) KubeOutput {
+	url := probeURL(k.cfg, address)
+	ko := k.run("exec", pod, "--", "wget", "-S", "-T", wgetTimeoutSeconds, "-qO-", url)
+	status := wgetStatus(ko.CombinedOut)
+	if !ko.Success && (status == 0 || k.cfg.ExpectStatus == 0) {
+		return ko
+	}
+	if err := checkStatus(k.cfg, url, status); err != nil {
+		ko.Success = false
+		ko.CombinedOut = err.Error() + ko.CombinedOut
+		return ko
+	}
+	if err := checkBody(k.cfg, wgetHeaderLine.ReplaceAllString(ko.CombinedOut, "")); err != nil {
+		ko.Success = false
+		ko.CombinedOut = err.Error()
+		return ko
+	}
+	ko.Success = true
+	return ko
+}
+
+// getNginxFromNode fetches --probe-path of the nginx address from this node,
+// or from the node given with --from-node. A response with another status
+// than --expect-status, or an error status when it is not set, or without
+// --expect-body is a failure.
+func getNginxFromNode(k *kubectl, w *workloads, client *http.Client, address string) error {
+	if w.fromNodeDeployed {
+		if ko := wgetNginx(k, w.fromNode, address); !ko.Success {
+			return fmt.Errorf("%s", ko.CombinedOut)
+		}
+		return nil
+	}
+	url := probeURL(w.cfg, address)
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if w.cfg.ExpectStatus == 0 && resp.StatusCode >= 400 {
+		return fmt.Errorf("%s returned %s\n", url, resp.Status)
+	}
+	if err := checkStatus(w.cfg, url, resp.StatusCode); err != nil {
+		return err
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxProbeBody))
+	if err != nil {
+		return err
+	}
+	return checkBody(w.cfg, string(body))
+}
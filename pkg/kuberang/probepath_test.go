@@ -0,0 +1,79 @@
+package kuberang
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+func TestGetNginxFromNode(t *testing.T) {
+	cfg := &config.Config{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/healthz" {
+			http.NotFound(w, req)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+	address := strings.TrimPrefix(server.URL, "http://")
+	w := newWorkloads(cfg, 1)
+	k := newKubectl(context.Background(), cfg)
+
+	cfg.ProbePath, cfg.ExpectBody = "/", ""
+	if err := getNginxFromNode(k, w, http.DefaultClient, address); err == nil {
+		t.Error("Expected a 404 to fail the check")
+	}
+	cfg.ProbePath = "/healthz"
+	if err := getNginxFromNode(k, w, http.DefaultClient, address); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	cfg.ExpectBody = "ok"
+	if err := getNginxFromNode(k, w, http.DefaultClient, address); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	cfg.ExpectBody = "healthy"
+	if err := getNginxFromNode(k, w, http.DefaultClient, address); err == nil || !strings.Contains(err.Error(), `does not contain "healthy"`) {
+		t.Errorf("Expected the missing body to fail the check, got %v", err)
+	}
+	if err := validateProbePath("healthz"); err == nil {
+		t.Error("Expected an error for a relative probe path")
+	}
+}
+
+func TestWgetStatus(t *testing.T) {
+	tests := []struct {
+		out    string
+		status int
+	}{
+		{"  HTTP/1.1 200 OK\n  Server: nginx/1.24.0\n  Content-Type: text/html\n<html></html>", 200},
+		{"  HTTP/1.1 301 Moved Permanently\n  Location: /healthz/\n  HTTP/1.1 204 No Content\n", 204},
+		{"  HTTP/1.1 500 Internal Server Error\nwget: server returned error: HTTP/1.1 500 Internal Server Error\n", 500},
+		{"wget: can't connect to remote host (10.96.0.12): Connection refused\n", 0},
+	}
+	for _, test := range tests {
+		if status := wgetStatus(test.out); status != test.status {
+			t.Errorf("Expected status %d, got %d for %q", test.status, status, test.out)
+		}
+	}
+	if body := wgetHeaderLine.ReplaceAllString(tests[0].out, ""); body != "<html></html>" {
+		t.Errorf("Unexpected body %q", body)
+	}
+}
+
+func TestCheckStatus(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.ExpectStatus = 200
+	if err := checkStatus(cfg, "http://10.96.0.12/", 500); err == nil || err.Error() != "http://10.96.0.12/ returned status 500, expected 200\n" {
+		t.Errorf("Unexpected error %v", err)
+	}
+	cfg.ExpectStatus = 0
+	if err := checkStatus(cfg, "http://10.96.0.12/", 204); err != nil {
+		t.Errorf("Expected any status to pass when none is expected, got %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package kuberang
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+// needsNodeProbes returns true if any of the enabled checks runs commands on
+// every node under test
+func needsNodeProbes(cfg *config.Config) bool {
+	return cfg.CheckClockSkew || cfg.CheckClockSpread || cfg.PerNodeServiceCheck
+}
+
+// deployNodeProbes runs a BusyBox probe pod on every node under test and
+// waits for all of them to be ready, recording the probe pod of each node
+func deployNodeProbes(r *reporter, w *workloads) bool {
+	name := "Node probe pods ready on every node"
+	manifest, _ := json.Marshal(nodeProbeDaemonSet(w))
+	if ko := r.kube.runWithInput(manifest, "create", "-f", "-"); !ko.Success {
+		r.err("node-probes-ready", name, ko.CombinedOut)
+		return false
+	}
+	w.probesDeployed = true
+
+	start := time.Now()
+	for {
+		ko := r.kube.run("get", "pods", "-l", w.labels("kuberang-probe"), "-o", "json")
+		if ko.Success {
+			w.probePods = readyPodsByNode(ko.Pods())
+			if missing := nodesWithout(w.nodes, w.probePods); len(missing) == 0 {
+				r.ok("node-probes-ready", name)
+				return true
+			}
+		}
+		if time.Since(start) > deploymentTimeout || !sleep(r.ctx, 1*time.Second) {
+			break
+		}
+	}
+	missing := nodesWithout(w.nodes, w.probePods)
+	r.err("node-probes-ready", name, "No ready probe pod on nodes: "+strings.Join(missing, ", ")+"\n")
+	return false
+}
+
+// removeNodeProbes deletes the node probe DaemonSet
+func removeNodeProbes(r *reporter, w *workloads) {
+	if ko := runDelete(r.kube, "daemonset", w.probe); ko.Success {
+		r.ok("cleanup-node-probes", "Powered down node probe DaemonSet")
+	} else {
+		r.err("cleanup-node-probes", "Powered down node probe DaemonSet", ko.CombinedOut)
+	}
+}
+
+// readyPodsByNode returns the name of a ready pod on every node
+func readyPodsByNode(pods []Pod) map[string]string {
+	byNode := map[string]string{}
+	for _, p := range pods {
+		if p.Ready && p.NodeName != "" {
+			byNode[p.NodeName] = p.Name
+		}
+	}
+	return byNode
+}
+
+// nodesWithout returns the nodes, in the order given, missing from the map
+func nodesWithout(nodes []string, byNode map[string]string) []string {
+	missing := []string{}
+	for _, n := range nodes {
+		if _, ok := byNode[n]; !ok {
+			missing = append(missing, n)
+		}
+	}
+	return missing
+}
+
+// nodeProbeDaemonSet returns the DaemonSet running an idle BusyBox pod on
+// each of the nodes under test, tolerating the taints of the control-plane
+// nodes included with --include-control-plane
+func nodeProbeDaemonSet(w *workloads) map[string]interface{} {
+	labels := map[string]interface{}{
+		"app":             "kuberang-probe",
+		"kuberang/testid": fmt.Sprintf("%d", w.testID),
+	}
+	container := map[string]interface{}{
+		"name":            "probe",
+		"image":           w.image("busybox:latest"),
+		"imagePullPolicy": "IfNotPresent",
+		"command":         []string{"sleep", "3600"},
+	}
+	spec := map[string]interface{}{
+		"affinity":   pinnedNodeAffinity(w.nodes),
+		"containers": []interface{}{container},
+	}
+	serviceAccountSpec(w.cfg, spec)
+	podSecuritySpec(w.cfg, spec, container, false)
+	tolerationsSpec(spec, w)
+	return map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "DaemonSet",
+		"metadata": map[string]interface{}{
+			"name":   w.probe,
+			"labels": labels,
+		},
+		"spec": map[string]interface{}{
+			"selector": map[string]interface{}{
+				"matchLabels": labels,
+			},
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"labels": labels,
+				},
+				"spec": spec,
+			},
+		},
+	}
+}
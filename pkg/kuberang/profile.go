@@ -0,0 +1,9 @@
+package kuberang
+
+// Profiles selecting the set of checks to run. The standard profile runs the
+// regular checks, while the full profile adds variants exercising less common
+// code paths of the cluster.
+const (
+	ProfileStandard = "standard"
+	ProfileFull     = "full"
+)
@@ -0,0 +1,129 @@
+package kuberang
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// promCheckLabel matches the check label of the success metrics of a
+// previously written textfile
+var promCheckLabel = regexp.MustCompile(`(?m)^kuberang_check_success\{check="([^"]*)"\}`)
+
+// promCheck is the outcome of the checks sharing an ID
+type promCheck struct {
+	success    int
+	start, end time.Time
+}
+
+// promEscaper escapes label values
+var promEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// promTextfile returns the metrics of the run in the Prometheus text format.
+// The checks sharing an ID are reported once: failed if any of them failed,
+// not run if all of them were skipped, lasting from the start of the first
+// to the end of the last, as they may have run concurrently. The checks of
+// the previous run that did not run this time, such as when the run aborted
+// early, are reported as not run, so that their previous outcome does not
+// linger.
+func promTextfile(result *CheckResult, previous []string, now time.Time) []byte {
+	checks := map[string]*promCheck{}
+	ids := []string{}
+	for _, c := range result.Checks {
+		start, end := c.interval()
+		pc, ok := checks[c.ID]
+		if !ok {
+			pc = &promCheck{success: 1, start: start, end: end}
+			checks[c.ID] = pc
+			ids = append(ids, c.ID)
+		}
+		switch c.Status {
+		case StatusError:
+			pc.success = 0
+		case StatusSkipped:
+			if !ok {
+				pc.success = -1
+			}
+		default:
+			if pc.success == -1 {
+				pc.success = 1
+			}
+		}
+		if start.Before(pc.start) {
+			pc.start = start
+		}
+		if end.After(pc.end) {
+			pc.end = end
+		}
+	}
+	for _, id := range previous {
+		if _, ok := checks[id]; !ok {
+			checks[id] = &promCheck{success: -1}
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	var b bytes.Buffer
+	fmt.Fprintln(&b, "# HELP kuberang_check_success Outcome of the check in the last run: 1 passed, 0 failed, -1 not run.")
+	fmt.Fprintln(&b, "# TYPE kuberang_check_success gauge")
+	for _, id := range ids {
+		fmt.Fprintf(&b, "kuberang_check_success{check=\"%s\"} %d\n", promEscaper.Replace(id), checks[id].success)
+	}
+	fmt.Fprintln(&b, "# HELP kuberang_check_duration_seconds Duration of the check in the last run.")
+	fmt.Fprintln(&b, "# TYPE kuberang_check_duration_seconds gauge")
+	for _, id := range ids {
+		fmt.Fprintf(&b, "kuberang_check_duration_seconds{check=\"%s\"} %g\n", promEscaper.Replace(id), checks[id].end.Sub(checks[id].start).Seconds())
+	}
+	success := 0
+	if result.Success {
+		success = 1
+	}
+	fmt.Fprintln(&b, "# HELP kuberang_run_success Whether the last run succeeded.")
+	fmt.Fprintln(&b, "# TYPE kuberang_run_success gauge")
+	fmt.Fprintf(&b, "kuberang_run_success %d\n", success)
+	fmt.Fprintln(&b, "# HELP kuberang_last_run_timestamp_seconds Time at which the last run ended.")
+	fmt.Fprintln(&b, "# TYPE kuberang_last_run_timestamp_seconds gauge")
+	fmt.Fprintf(&b, "kuberang_last_run_timestamp_seconds %d\n", now.Unix())
+	return b.Bytes()
+}
+
+// writePromTextfile atomically replaces the textfile at path with the
+// metrics of the run, for the textfile collector of node_exporter to pick up
+func writePromTextfile(path string, result *CheckResult) error {
+	previous := []string{}
+	if b, err := ioutil.ReadFile(path); err == nil {
+		for _, m := range promCheckLabel.FindAllStringSubmatch(string(b), -1) {
+			previous = append(previous, m[1])
+		}
+	}
+	now := result.EndTime
+	if now.IsZero() {
+		now = time.Now()
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return fmt.Errorf("error writing Prometheus textfile: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(promTextfile(result, previous, now))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		return fmt.Errorf("error writing Prometheus textfile: %v", err)
+	}
+	return nil
+}
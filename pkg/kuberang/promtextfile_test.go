@@ -0,0 +1,68 @@
+package kuberang
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPromTextfile(t *testing.T) {
+	start := time.Unix(100, 0)
+	result := &CheckResult{
+		StartTime: start,
+		Checks: []Check{
+			{ID: "kubectl-configured", Status: StatusOK, start: start, time: start.Add(250 * time.Millisecond)},
+			// Accessed concurrently
+			{ID: "pod-ip-from-pod", Status: StatusOK, start: start.Add(250 * time.Millisecond), time: start.Add(time.Second)},
+			{ID: "pod-ip-from-pod", Status: StatusError, start: start.Add(250 * time.Millisecond), time: start.Add(1500 * time.Millisecond)},
+			{ID: "internet-from-pod", Status: StatusSkipped, time: start.Add(1500 * time.Millisecond)},
+			{ID: "pod-ip-from-node", Status: StatusSkipped, time: start.Add(1500 * time.Millisecond)},
+			{ID: "pod-ip-from-node", Status: StatusOK, time: start.Add(1500 * time.Millisecond)},
+			{ID: "pod-ip-from-node", Status: StatusSkipped, time: start.Add(1500 * time.Millisecond)},
+		},
+	}
+	text := string(promTextfile(result, []string{"kubectl-configured", "service-ip-from-pod"}, time.Unix(200, 0)))
+	for _, line := range []string{
+		`kuberang_check_success{check="internet-from-pod"} -1`,
+		`kuberang_check_success{check="kubectl-configured"} 1`,
+		`kuberang_check_success{check="pod-ip-from-node"} 1`,
+		`kuberang_check_success{check="pod-ip-from-pod"} 0`,
+		`kuberang_check_success{check="service-ip-from-pod"} -1`,
+		`kuberang_check_duration_seconds{check="kubectl-configured"} 0.25`,
+		`kuberang_check_duration_seconds{check="pod-ip-from-pod"} 1.25`,
+		`kuberang_check_duration_seconds{check="service-ip-from-pod"} 0`,
+		"kuberang_run_success 0",
+		"kuberang_last_run_timestamp_seconds 200",
+	} {
+		if !strings.Contains(text, line+"\n") {
+			t.Errorf("Expected line %q in\n%s", line, text)
+		}
+	}
+}
+
+func TestWritePromTextfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kuberang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "kuberang.prom")
+	result := &CheckResult{Checks: []Check{{ID: "pod-ip-from-pod", Status: StatusOK}}}
+	if err := writePromTextfile(path, result); err != nil {
+		t.Fatal(err)
+	}
+	// An aborted run still reports the checks of the previous one
+	if err := writePromTextfile(path, &CheckResult{}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil || !strings.Contains(string(b), `kuberang_check_success{check="pod-ip-from-pod"} -1`) {
+		t.Errorf("Unexpected textfile %q (%v)", b, err)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Errorf("Expected the temporary file to be renamed, found %d files", len(files))
+	}
+}
@@ -0,0 +1,145 @@
+package kuberang
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// unreadyMarker is the file that makes an nginx pod fail its readiness
+	// probe when the readiness gate check is enabled
+	unreadyMarker = "/tmp/kuberang-unready"
+	// readinessGateTimeout bounds each endpoints transition
+	readinessGateTimeout = 60 * time.Second
+	// readinessGateRequests is the number of requests sent to the service to
+	// make sure it no longer routes to the unready pod
+	readinessGateRequests = 20
+)
+
+// ReadinessGateResult holds the observed latencies of the endpoints
+// transitions triggered by the readiness gate check
+type ReadinessGateResult struct {
+	Pod             string `json:"pod"`
+	RemovedAfterMs  int64  `json:"removedAfterMs,omitempty"`
+	RestoredAfterMs int64  `json:"restoredAfterMs,omitempty"`
+}
+
+// readinessProbeSpec adds a readiness probe to the nginx container that
+// fails while unreadyMarker exists
+func readinessProbeSpec(container map[string]interface{}) {
+	container["readinessProbe"] = map[string]interface{}{
+		"exec": map[string]interface{}{
+			"command": []string{"sh", "-c", "test ! -f " + unreadyMarker},
+		},
+		"periodSeconds":    1,
+		"failureThreshold": 1,
+	}
+}
+
+// checkReadinessGate makes an nginx pod fail its readiness probe, verifies
+// that it leaves the service endpoints and stops receiving traffic, then
+// restores it and verifies that it comes back
+func checkReadinessGate(r *reporter, w *workloads, busyboxPodName string, pods []Pod) bool {
+	var pod *Pod
+	for i := range pods {
+		if pods[i].Ready && pods[i].IP != "" {
+			pod = &pods[i]
+			break
+		}
+	}
+	if pod == nil {
+		r.err("readiness-gate-removed", "Unready Nginx pod left the service endpoints", "No ready nginx pod found\n")
+		return false
+	}
+	result := &ReadinessGateResult{Pod: pod.Name}
+	r.result.ReadinessGate = result
+
+	name := "Unready Nginx pod " + pod.Name + " left the service endpoints"
+	if ko := r.kube.run("exec", pod.Name, "--", "touch", unreadyMarker); !ko.Success {
+		r.err("readiness-gate-removed", name, ko.CombinedOut)
+		return false
+	}
+	restored := false
+	defer func() {
+		if !restored {
+			r.kube.run("exec", pod.Name, "--", "rm", "-f", unreadyMarker)
+		}
+	}()
+
+	success := true
+	elapsed, ok := waitForEndpoints(r.kube, w.ngService, pod.IP, false)
+	if ok {
+		result.RemovedAfterMs = int64(elapsed / time.Millisecond)
+		r.ok("readiness-gate-removed", fmt.Sprintf("%s after %v", name, elapsed))
+		success = checkUnreadyPodTraffic(r, w, busyboxPodName, pod.Name) && success
+	} else {
+		r.err("readiness-gate-removed", name, fmt.Sprintf("Pod IP %s still listed in the endpoints of %s after %v\n", pod.IP, w.ngService, readinessGateTimeout))
+		success = false
+	}
+
+	name = "Ready again Nginx pod " + pod.Name + " returned to the service endpoints"
+	if ko := r.kube.run("exec", pod.Name, "--", "rm", "-f", unreadyMarker); !ko.Success {
+		r.err("readiness-gate-restored", name, ko.CombinedOut)
+		return false
+	}
+	restored = true
+	elapsed, ok = waitForEndpoints(r.kube, w.ngService, pod.IP, true)
+	if ok {
+		result.RestoredAfterMs = int64(elapsed / time.Millisecond)
+		r.ok("readiness-gate-restored", fmt.Sprintf("%s after %v", name, elapsed))
+	} else {
+		r.err("readiness-gate-restored", name, fmt.Sprintf("Pod IP %s not listed in the endpoints of %s after %v\n", pod.IP, w.ngService, readinessGateTimeout))
+		success = false
+	}
+	return success
+}
+
+// checkUnreadyPodTraffic sends requests to the service and makes sure none
+// of them is answered by the unready pod. Telling backends apart requires
+// them to be identifiable.
+func checkUnreadyPodTraffic(r *reporter, w *workloads, busyboxPodName, podName string) bool {
+	name := "Nginx service stopped routing to unready pod " + podName
+	if !r.cfg.IdentifyBackends {
+		r.skipped("readiness-gate-traffic", name, "requires --identify-backends")
+		return true
+	}
+	script := fmt.Sprintf("for i in $(seq %d); do wget -T %s -qO- %s; done", readinessGateRequests, wgetTimeoutSeconds, w.ngService)
+	ko := r.kube.run("exec", busyboxPodName, "--", "sh", "-c", script)
+	if !ko.Success {
+		r.err("readiness-gate-traffic", name, ko.CombinedOut)
+		return false
+	}
+	if strings.Contains(ko.CombinedOut, podName) {
+		r.err("readiness-gate-traffic", name, "The unready pod still answered requests to the service\n")
+		return false
+	}
+	r.ok("readiness-gate-traffic", name)
+	return true
+}
+
+// waitForEndpoints waits until the pod IP is listed, or no longer listed,
+// in the endpoints of the service and returns how long it took
+func waitForEndpoints(k *kubectl, service, podIP string, listed bool) (time.Duration, bool) {
+	start := time.Now()
+	for time.Since(start) < readinessGateTimeout {
+		ko := k.run("get", "endpoints", service, "-o", "json")
+		if ko.Success && endpointsContain(ko.Endpoints(), podIP) == listed {
+			return time.Since(start), true
+		}
+		if !sleep(k.ctx, 500*time.Millisecond) {
+			break
+		}
+	}
+	return time.Since(start), false
+}
+
+// endpointsContain returns true if any of the ip:port endpoints has the IP
+func endpointsContain(endpoints []string, ip string) bool {
+	for _, e := range endpoints {
+		if strings.HasPrefix(e, ip+":") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,13 @@
+package kuberang
+
+import "testing"
+
+func TestEndpointsContain(t *testing.T) {
+	endpoints := []string{"172.16.3.5:80", "172.16.4.7:80"}
+	if !endpointsContain(endpoints, "172.16.3.5") {
+		t.Errorf("Expected 172.16.3.5 to be listed")
+	}
+	if endpointsContain(endpoints, "172.16.3.50") || endpointsContain(endpoints, "172.16.4") {
+		t.Errorf("Expected only exact IPs to match")
+	}
+}
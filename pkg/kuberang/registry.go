@@ -0,0 +1,152 @@
+package kuberang
+
+import (
+	"net/http"
+	"sort"
+)
+
+// Resources a check may require
+const (
+	resourceBusybox = "busybox"
+	resourceNginx   = "nginx"
+)
+
+// checkEnv holds what the checks run on their own need to know about the
+// resources they require
+type checkEnv struct {
+	busyboxPodName string
+	serviceIP      string
+	nginxPods      []Pod
+	podIPs         []string
+	client         *http.Client
+	retries        int
+}
+
+// checkSpec declares the resources a check requires and how to run it on
+// its own
+type checkSpec struct {
+	requires []string
+	run      func(r *reporter, w *workloads, env *checkEnv) bool
+}
+
+// checkRegistry holds the checks that can be run on their own, by ID
+var checkRegistry = map[string]checkSpec{
+	"service-ip-from-pod": {
+		requires: []string{resourceBusybox, resourceNginx},
+		run: func(r *reporter, w *workloads, env *checkEnv) bool {
+			return wgetFromBusybox(r, env, "service-ip-from-pod", "Accessed Nginx service at "+env.serviceIP+" from BusyBox", env.serviceIP)
+		},
+	},
+	"service-dns-from-pod": {
+		requires: []string{resourceBusybox, resourceNginx},
+		run: func(r *reporter, w *workloads, env *checkEnv) bool {
+			return wgetFromBusybox(r, env, "service-dns-from-pod", "Accessed Nginx service via DNS "+w.ngService+" from BusyBox", w.ngService)
+		},
+	},
+	"service-fqdn-from-pod": {
+		requires: []string{resourceBusybox, resourceNginx},
+		run: func(r *reporter, w *workloads, env *checkEnv) bool {
+			return wgetFromBusybox(r, env, "service-fqdn-from-pod", "Accessed Nginx service via DNS "+w.serviceFQDN()+" from BusyBox", w.serviceFQDN())
+		},
+	},
+	"pod-ip-from-pod": {
+		requires: []string{resourceBusybox, resourceNginx},
+		run: func(r *reporter, w *workloads, env *checkEnv) bool {
+			success := true
+			for _, podIP := range env.podIPs {
+				if !wgetFromBusybox(r, env, "pod-ip-from-pod", "Accessed Nginx pod at "+podIP+" from BusyBox", podIP) {
+					success = false
+				}
+			}
+			return success
+		},
+	},
+	"hairpin-from-pod": {
+		requires: []string{resourceNginx},
+		run: func(r *reporter, w *workloads, env *checkEnv) bool {
+			return checkHairpin(r, env.nginxPods, env.serviceIP, false)
+		},
+	},
+	"pod-ip-from-nginx": {
+		requires: []string{resourceBusybox, resourceNginx},
+		run: func(r *reporter, w *workloads, env *checkEnv) bool {
+			return checkReversePath(r, w, env.busyboxPodName, env.nginxPods)
+		},
+	},
+	"internet-from-pod": {
+		requires: []string{resourceBusybox},
+		run: func(r *reporter, w *workloads, env *checkEnv) bool {
+			var ko KubeOutput
+			attempt := retryAttempts(r.ctx, env.retries, func() bool {
+				ko = r.kube.run("exec", env.busyboxPodName, "--", "wget", "-T", wgetTimeoutSeconds, "-qO-", "Google.com")
+				return ko.Success
+			})
+			if attempt == 0 {
+				r.err("internet-from-pod", "Accessed Google.com from BusyBox", ko.CombinedOut)
+				return false
+			}
+			r.okAfter("internet-from-pod", "Accessed Google.com from BusyBox", attempt, env.retries)
+			return true
+		},
+	},
+	"pod-ip-from-node": {
+		requires: []string{resourceNginx},
+		run: func(r *reporter, w *workloads, env *checkEnv) bool {
+			success := true
+			for _, podIP := range env.podIPs {
+				ok := retry(r.ctx, env.retries, func() bool { return getNginxFromNode(r.kube, w, env.client, podIP) == nil })
+				if !reportFromNode(r, "pod-ip-from-node", "Accessed Nginx pod at "+podIP+" from "+nodeDescription(r.cfg), getFromNodeErr(ok)) {
+					success = false
+				}
+			}
+			return success
+		},
+	},
+	"internet-from-node": {
+		run: func(r *reporter, w *workloads, env *checkEnv) bool {
+			ok := retry(r.ctx, env.retries, func() bool { return getFromNode(r.kube, w, env.client, "http://google.com/") == nil })
+			return reportFromNode(r, "internet-from-node", "Accessed Google.com from "+nodeDescription(r.cfg), getFromNodeErr(ok))
+		},
+	},
+	"emptydir-write-read": {
+		requires: []string{resourceBusybox},
+		run: func(r *reporter, w *workloads, env *checkEnv) bool {
+			return checkEmptyDir(r, w, env.busyboxPodName)
+		},
+	},
+}
+
+// registeredCheckIDs returns the IDs of the registered checks, sorted
+func registeredCheckIDs() []string {
+	ids := make([]string, 0, len(checkRegistry))
+	for id := range checkRegistry {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// wgetFromBusybox accesses the nginx address from the BusyBox pod, retrying
+// as many times as the environment allows
+func wgetFromBusybox(r *reporter, env *checkEnv, id, name, address string) bool {
+	var ko KubeOutput
+	attempt := retryAttempts(r.ctx, env.retries, func() bool {
+		ko = wgetNginx(r.kube, env.busyboxPodName, address)
+		return ko.Success
+	})
+	if attempt == 0 {
+		r.err(id, name, ko.CombinedOut)
+		return false
+	}
+	r.okAfter(id, name, attempt, env.retries)
+	return true
+}
+
+// getFromNodeErr turns the outcome of retried node-side accesses into the
+// error reported by reportFromNode
+func getFromNodeErr(ok bool) error {
+	if ok {
+		return nil
+	}
+	return errNodeAccess
+}
@@ -0,0 +1,28 @@
+package kuberang
+
+import (
+	"context"
+	"testing"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+func TestCheckRegistry(t *testing.T) {
+	for _, id := range registeredCheckIDs() {
+		spec := checkRegistry[id]
+		if spec.run == nil {
+			t.Errorf("check %s has no run function", id)
+		}
+		for _, resource := range spec.requires {
+			if resource != resourceBusybox && resource != resourceNginx {
+				t.Errorf("check %s requires unknown resource %q", id, resource)
+			}
+		}
+	}
+}
+
+func TestDiagnoseUnknownCheck(t *testing.T) {
+	if err := Diagnose(context.Background(), &config.Config{}, nil, "no-such-check", false); err == nil {
+		t.Error("expected an error for an unknown check")
+	}
+}
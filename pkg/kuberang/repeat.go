@@ -0,0 +1,78 @@
+package kuberang
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+// validateRepeat returns an error describing the first invalid option of
+// the repeat mode
+func validateRepeat(cfg *config.Config) error {
+	if cfg.RepeatInterval <= 0 {
+		return errors.New("--interval must be greater than 0")
+	}
+	if cfg.BackoffFactor < 1 {
+		return errors.New("--backoff-factor must be at least 1")
+	}
+	if cfg.BackoffMax < cfg.RepeatInterval {
+		return errors.New("--backoff-max must be at least --interval")
+	}
+	return nil
+}
+
+// nextInterval returns the wait before the next run. Every failed run
+// multiplies the current interval by factor, up to max, and a successful
+// run resets it to base.
+func nextInterval(current, base, max time.Duration, factor float64, failed bool) time.Duration {
+	if !failed {
+		return base
+	}
+	next := time.Duration(float64(current) * factor)
+	if next > max {
+		return max
+	}
+	return next
+}
+
+// Repeat runs the checks until ctx is cancelled, waiting --interval between runs.
+// The wait grows after every failed run, so that a persistently broken
+// cluster is not redeployed to over and over, and goes back to --interval
+// once a run succeeds. The checks print to out, and the wait before every
+// run is printed to status.
+func Repeat(ctx context.Context, cfg *config.Config, out, status io.Writer) error {
+	if err := validateRepeat(cfg); err != nil {
+		return err
+	}
+	// Configuration errors would otherwise be retried forever
+	if err := validateConfig(cfg); err != nil {
+		return err
+	}
+	interval := cfg.RepeatInterval
+	failures := 0
+	for {
+		err := CheckKubernetes(ctx, cfg, out)
+		if ctx.Err() != nil {
+			return err
+		}
+		if err != nil {
+			failures++
+		} else {
+			failures = 0
+		}
+		interval = nextInterval(interval, cfg.RepeatInterval, cfg.BackoffMax, cfg.BackoffFactor, err != nil)
+		if failures > 0 {
+			printLine(status, "Next run in %v, backing off after %d failed run(s)", interval, failures)
+		} else {
+			printLine(status, "Next run in %v", interval)
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(interval):
+		}
+	}
+}
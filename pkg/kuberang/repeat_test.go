@@ -0,0 +1,24 @@
+package kuberang
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextInterval(t *testing.T) {
+	base, max := time.Minute, 10*time.Minute
+	interval := base
+	expected := []time.Duration{2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 10 * time.Minute, 10 * time.Minute}
+	for i, e := range expected {
+		interval = nextInterval(interval, base, max, 2, true)
+		if interval != e {
+			t.Errorf("Expected %v after %d failures, got %v", e, i+1, interval)
+		}
+	}
+	if interval = nextInterval(interval, base, max, 2, false); interval != base {
+		t.Errorf("Expected the interval to reset to %v after a success, got %v", base, interval)
+	}
+	if interval = nextInterval(base, base, max, 1, true); interval != base {
+		t.Errorf("Expected a constant interval with a factor of 1, got %v", interval)
+	}
+}
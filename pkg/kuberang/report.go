@@ -0,0 +1,402 @@
+package kuberang
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/apprenda/kuberang/pkg/config"
+	"github.com/apprenda/kuberang/pkg/util"
+)
+
+// Status of a single check
+const (
+	StatusOK      = "ok"
+	StatusError   = "error"
+	StatusIgnored = "ignored"
+	StatusSkipped = "skipped"
+	StatusWarning = "warning"
+)
+
+// Check is the outcome of a single check performed by kuberang
+type Check struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	// Node is the node the check pertains to, e.g. the node of the pod
+	// accessed or of the probe pod the check ran from
+	Node string `json:"node,omitempty"`
+	// Attempts is the attempt on which a retried check succeeded, out of
+	// MaxAttempts
+	Attempts    int `json:"attempts,omitempty"`
+	MaxAttempts int `json:"maxAttempts,omitempty"`
+	// start and time are when the check started and completed
+	start, time time.Time
+	// podIP is the IP of the nginx pod the check accessed, if any
+	podIP string
+}
+
+// interval returns when the check started and completed. A check recorded
+// without a start lasts no time.
+func (c Check) interval() (time.Time, time.Time) {
+	if c.start.IsZero() {
+		return c.time, c.time
+	}
+	return c.start, c.time
+}
+
+// CheckResult is the structured outcome of a kuberang run
+type CheckResult struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+	TestID  int64  `json:"testID"`
+	// Context is the kubeconfig context the run was performed against
+	Context   string `json:"context,omitempty"`
+	Namespace string `json:"namespace"`
+	// FromNode is the node from which the node-side checks were run, when
+	// not run from the machine running kuberang
+	FromNode string `json:"fromNode,omitempty"`
+	// As and AsGroups are the user and groups impersonated by the run
+	As       string   `json:"as,omitempty"`
+	AsGroups []string `json:"asGroups,omitempty"`
+	Nodes    []string `json:"nodes,omitempty"`
+	// CordonedNodes are left out of the checks as nothing can be scheduled on them
+	CordonedNodes []string `json:"cordonedNodes,omitempty"`
+	// ReadyNodes and NotReadyNodes split the cluster's nodes by their Ready
+	// condition. NotReady nodes are left out unless all nodes are required.
+	ReadyNodes    []string `json:"readyNodes,omitempty"`
+	NotReadyNodes []string `json:"notReadyNodes,omitempty"`
+	// NodesRestricted is set when the user limited the nodes under test,
+	// in which case the run does not validate the whole cluster
+	NodesRestricted bool `json:"nodesRestricted"`
+	// Topology is the snapshot of what was tested, set once the nodes
+	// under test are known
+	Topology *Topology `json:"topology,omitempty"`
+	// DiagnosticsBundle is the path of the support bundle collected when
+	// the run failed
+	DiagnosticsBundle string `json:"diagnosticsBundle,omitempty"`
+	// Artifacts are the files written by the run
+	Artifacts []Artifact `json:"artifacts,omitempty"`
+	// Benchmark holds the numbers of the HTTP benchmark, when requested
+	Benchmark *BenchmarkResult `json:"benchmark,omitempty"`
+	// DNSStress holds the outcome of the DNS stress check for every name
+	DNSStress []DNSStressResult `json:"dnsStress,omitempty"`
+	// Samples holds the success ratio of every sampled connectivity check
+	Samples []SampleResult `json:"samples,omitempty"`
+	// DNSDiagnosis names the failing part of the DNS chain, as found by the
+	// DNS diagnostics
+	DNSDiagnosis string `json:"dnsDiagnosis,omitempty"`
+	// NamedPortDiagnosis calls out a named port resolution problem, when the
+	// named port service variant failed while the numeric one worked
+	NamedPortDiagnosis string `json:"namedPortDiagnosis,omitempty"`
+	// ReadinessGate holds the endpoints transitions observed by the readiness
+	// gate check
+	ReadinessGate *ReadinessGateResult `json:"readinessGate,omitempty"`
+	// ClockSkew holds the clock offset of every node under test
+	ClockSkew []ClockSkewResult `json:"clockSkew,omitempty"`
+	// SelfHealing holds the outcome of replacing a deleted nginx pod
+	SelfHealing *SelfHealingResult `json:"selfHealing,omitempty"`
+	// CrossNode holds the pod-to-pod paths between nodes exercised by the
+	// cross-node check
+	CrossNode []CrossNodePath `json:"crossNode,omitempty"`
+	// Summary counts the checks by status
+	Summary *Summary `json:"summary,omitempty"`
+	// DurationMs is the wall-clock duration of the run, and Phases the
+	// time spent in each of its phases
+	DurationMs int64         `json:"durationMs,omitempty"`
+	Phases     []PhaseTiming `json:"phases,omitempty"`
+	// RetryBudgetExhausted is set when checks were attempted only once for
+	// lack of retries left under --max-total-retries
+	RetryBudgetExhausted bool      `json:"retryBudgetExhausted,omitempty"`
+	StartTime            time.Time `json:"startTime"`
+	EndTime              time.Time `json:"endTime"`
+	Checks               []Check   `json:"checks"`
+
+	// kubectlCalls are the kubectl invocations of a traced run
+	kubectlCalls []kubectlCall
+}
+
+// Topology describes the nodes, pods and service exercised by a run
+type Topology struct {
+	Nodes   []TopologyNode  `json:"nodes"`
+	Pods    []TopologyPod   `json:"pods"`
+	Service TopologyService `json:"service"`
+}
+
+// TopologyNode is a node of the cluster
+type TopologyNode struct {
+	Name       string `json:"name"`
+	InternalIP string `json:"internalIP,omitempty"`
+}
+
+// TopologyPod is an nginx pod along with the node it landed on
+type TopologyPod struct {
+	Name string `json:"name"`
+	IP   string `json:"ip"`
+	Node string `json:"node"`
+}
+
+// TopologyService is the nginx service along with its endpoints
+type TopologyService struct {
+	Name      string   `json:"name"`
+	ClusterIP string   `json:"clusterIP,omitempty"`
+	Endpoints []string `json:"endpoints"`
+}
+
+// Summary counts the checks of a run by status
+type Summary struct {
+	Passed  int `json:"passed"`
+	Failed  int `json:"failed"`
+	Warned  int `json:"warned"`
+	Skipped int `json:"skipped"`
+	Ignored int `json:"ignored"`
+}
+
+// Summarize counts the checks by status
+func (cr *CheckResult) Summarize() Summary {
+	s := Summary{}
+	for _, c := range cr.Checks {
+		switch c.Status {
+		case StatusOK:
+			s.Passed++
+		case StatusError:
+			s.Failed++
+		case StatusWarning:
+			s.Warned++
+		case StatusSkipped:
+			s.Skipped++
+		case StatusIgnored:
+			s.Ignored++
+		}
+	}
+	return s
+}
+
+// Failed returns the checks that failed
+func (cr *CheckResult) Failed() []Check {
+	return cr.withStatus(StatusError)
+}
+
+// Ignored returns the checks that failed without failing the run
+func (cr *CheckResult) Ignored() []Check {
+	return cr.withStatus(StatusIgnored)
+}
+
+func (cr *CheckResult) withStatus(status string) []Check {
+	checks := []Check{}
+	for _, c := range cr.Checks {
+		if c.Status == status {
+			checks = append(checks, c)
+		}
+	}
+	return checks
+}
+
+// reporter prints the outcome of every check and records it in the result
+type reporter struct {
+	out    io.Writer
+	result *CheckResult
+	// cfg is the configuration of the run
+	cfg *config.Config
+	// ctx is the context of the run, whose cancellation stops the retries
+	ctx context.Context
+	// kube runs kubectl with the configuration and context of the run
+	kube *kubectl
+	// promoted is set when a warning was turned into a failure by --fail-on
+	promoted bool
+	// inPhase is set while the last phase of the result is being timed
+	inPhase bool
+	// node, podIP, attempts and maxAttempts are recorded along with the next
+	// check
+	node, podIP           string
+	attempts, maxAttempts int
+	// started is when the next check started: when the previous one was
+	// recorded, or when the reporter was given its work
+	started time.Time
+	// listener receives every check as it is recorded
+	listener func(Check)
+}
+
+func newReporter(out io.Writer, result *CheckResult) *reporter {
+	cfg := &config.Config{}
+	return &reporter{out: out, result: result, cfg: cfg, ctx: context.Background(), kube: newKubectl(context.Background(), cfg), started: time.Now()}
+}
+
+func (r *reporter) record(id, name, status, detail string) {
+	now := time.Now()
+	c := Check{
+		ID:          id,
+		Name:        name,
+		Status:      status,
+		Detail:      detail,
+		Node:        r.node,
+		Attempts:    r.attempts,
+		MaxAttempts: r.maxAttempts,
+		start:       r.started,
+		time:        now,
+		podIP:       r.podIP,
+	}
+	r.node, r.podIP, r.attempts, r.maxAttempts = "", "", 0, 0
+	r.started = now
+	r.result.Checks = append(r.result.Checks, c)
+	if r.listener != nil {
+		r.listener(c)
+	}
+}
+
+// onNode attributes the next check to the node
+func (r *reporter) onNode(node string) *reporter {
+	r.node = node
+	return r
+}
+
+// onPod attributes the next check to the nginx pod with the IP and its node
+func (r *reporter) onPod(podIP string) *reporter {
+	r.podIP = podIP
+	if r.result.Topology != nil {
+		for _, p := range r.result.Topology.Pods {
+			if p.IP == podIP {
+				r.node = p.Node
+			}
+		}
+	}
+	return r
+}
+
+// print prints the message using the given util printer when the configured
+// verbosity is at least level
+func (r *reporter) print(level int, printer func(io.Writer, string, ...interface{}), msg string, a ...interface{}) {
+	if r.cfg.Verbosity >= level {
+		printer(r.out, msg, a...)
+	}
+}
+
+func (r *reporter) ok(id, name string) {
+	r.record(id, name, StatusOK, "")
+	r.print(0, util.PrettyPrintOk, "%s", name)
+}
+
+// okAfter reports a retried check that succeeded on the given attempt out of
+// max. Checks needing more than one attempt are called out, and reported as
+// warnings with --fail-on-retries.
+func (r *reporter) okAfter(id, name string, attempt, max int) {
+	r.attempts, r.maxAttempts = attempt, max
+	switch {
+	case attempt > 1 && r.cfg.FailOnRetries:
+		r.warn(id, name, fmt.Sprintf("Succeeded on attempt %d/%d\n", attempt, max))
+	case attempt > 1:
+		r.record(id, name, StatusOK, "")
+		r.print(0, util.PrettyPrintOk, "%s (OK on attempt %d/%d)", name, attempt, max)
+	default:
+		r.ok(id, name)
+	}
+}
+
+func (r *reporter) err(id, name, detail string) {
+	r.record(id, name, StatusError, detail)
+	util.PrettyPrintErr(r.out, "%s", name)
+	if detail != "" {
+		printFailureDetail(r.out, detail)
+	}
+}
+
+func (r *reporter) ignored(id, name, detail string) {
+	r.record(id, name, StatusIgnored, detail)
+	r.print(0, util.PrettyPrintErrorIgnored, "%s", name)
+}
+
+// skipped reports a check that was not performed for the given reason
+func (r *reporter) skipped(id, name, reason string) {
+	r.record(id, name, StatusSkipped, reason)
+	r.print(0, util.PrettyPrintSkipped, "%s (%s)", name, reason)
+}
+
+// warn reports a problem that does not fail the run, unless the ID of the
+// check is listed with --fail-on
+func (r *reporter) warn(id, name, detail string) {
+	if containsString(r.cfg.FailOn, id) {
+		r.promoted = true
+		r.err(id, name, detail)
+		return
+	}
+	r.record(id, name, StatusWarning, detail)
+	util.PrettyPrintWarn(r.out, "%s", name)
+	if detail != "" {
+		printFailureDetail(r.out, detail)
+	}
+}
+
+// printSummary tallies the failed checks and, separately, the ignored ones
+// whose failure was not asserted, at the end of a pretty printed run. A run
+// restricted to some nodes says so, as it does not validate the whole
+// cluster.
+func printSummary(cfg *config.Config, out io.Writer, result *CheckResult) {
+	failed, ignored := result.Failed(), result.Ignored()
+	if len(failed) == 0 && cfg.Verbosity < 0 {
+		return
+	}
+	fmt.Fprintln(out)
+	s := result.Summarize()
+	fmt.Fprintf(out, "%d passed, %d failed, %d warned, %d skipped, %d ignored\n", s.Passed, s.Failed, s.Warned, s.Skipped, s.Ignored)
+	if result.NodesRestricted {
+		util.PrintColor(out, util.Orange, "Checks restricted to nodes: %s\n", strings.Join(result.Nodes, ", "))
+	}
+	if result.DurationMs > 0 {
+		printTimings(out, result)
+	}
+	if result.RetryBudgetExhausted {
+		util.PrintColor(out, util.Orange, "The retry budget of %d retries was exhausted, the remaining checks were attempted once\n", cfg.MaxTotalRetries)
+	}
+	if len(failed) > 0 {
+		util.PrintColor(out, util.Red, "%s failed: %s\n", countChecks(len(failed)), checkIDs(failed))
+	}
+	if result.NamedPortDiagnosis != "" {
+		util.PrintColor(out, util.Red, "%s\n", result.NamedPortDiagnosis)
+	}
+	if len(ignored) > 0 && cfg.Verbosity >= 0 {
+		util.PrintColor(out, util.Orange, "%s ignored: %s\n", countChecks(len(ignored)), checkIDs(ignored))
+		fmt.Fprintln(out, "Ignored checks failed without failing the run, they do not indicate a problem with the cluster by themselves.")
+	}
+}
+
+func countChecks(n int) string {
+	if n == 1 {
+		return "1 check"
+	}
+	return fmt.Sprintf("%d checks", n)
+}
+
+// checkIDs lists the distinct IDs of the checks in order of appearance,
+// along with the number of checks sharing an ID when there are several
+func checkIDs(checks []Check) string {
+	counts := map[string]int{}
+	ids := []string{}
+	for _, c := range checks {
+		if counts[c.ID] == 0 {
+			ids = append(ids, c.ID)
+		}
+		counts[c.ID]++
+	}
+	for i, id := range ids {
+		if counts[id] > 1 {
+			ids[i] = fmt.Sprintf("%s (x%d)", id, counts[id])
+		}
+	}
+	return strings.Join(ids, ", ")
+}
+
+func printFailureDetail(out io.Writer, detail string) {
+	fmt.Fprintln(out, "-------- OUTPUT --------")
+	fmt.Fprint(out, detail)
+	fmt.Fprintln(out, "------------------------")
+	fmt.Fprintln(out)
+}
+
+// printLine prints a plain line of text, for messages that are not the
+// outcome of a check
+func printLine(out io.Writer, msg string, a ...interface{}) {
+	fmt.Fprintf(out, msg+"\n", a...)
+}
@@ -0,0 +1,84 @@
+package kuberang
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+func TestPrintSummary(t *testing.T) {
+	result := sampleResult()
+	result.Checks = append(result.Checks, Check{ID: "internet-from-node", Status: StatusIgnored}, Check{ID: "internet-from-pod", Status: StatusIgnored})
+	out := &bytes.Buffer{}
+	printSummary(&config.Config{}, out, result)
+	if !strings.Contains(out.String(), "1 passed, 1 failed, 0 warned, 0 skipped, 3 ignored\n") {
+		t.Errorf("Missing status counts in summary:\n%s", out)
+	}
+	if !strings.Contains(out.String(), "1 check failed: pod-ip-from-pod\n") {
+		t.Errorf("Missing failure tally in summary:\n%s", out)
+	}
+	if !strings.Contains(out.String(), "3 checks ignored: internet-from-node (x2), internet-from-pod\n") {
+		t.Errorf("Missing ignored tally in summary:\n%s", out)
+	}
+	if strings.Contains(out.String(), "restricted") {
+		t.Errorf("Expected no restriction in the summary of an unrestricted run:\n%s", out)
+	}
+
+	result.Nodes, result.NodesRestricted = []string{"node1", "node2"}, true
+	out.Reset()
+	printSummary(&config.Config{}, out, result)
+	if !strings.Contains(out.String(), "Checks restricted to nodes: node1, node2\n") {
+		t.Errorf("Missing node restriction in summary:\n%s", out)
+	}
+}
+
+func TestPrintSummaryNamedPortDiagnosis(t *testing.T) {
+	result := sampleResult()
+	result.Checks = append(result.Checks, Check{ID: "named-port-service-ip-from-pod", Status: StatusError})
+	out := &bytes.Buffer{}
+	printSummary(&config.Config{}, out, result)
+	if strings.Contains(out.String(), "Named port") {
+		t.Errorf("Expected no named port diagnosis without one recorded:\n%s", out)
+	}
+
+	result.NamedPortDiagnosis = namedPortDiagnosis
+	out.Reset()
+	printSummary(&config.Config{}, out, result)
+	if !strings.Contains(out.String(), namedPortDiagnosis+"\n") {
+		t.Errorf("Missing named port diagnosis in summary:\n%s", out)
+	}
+}
+
+func TestWarnFailOn(t *testing.T) {
+	r := newReporter(&bytes.Buffer{}, &CheckResult{})
+	r.cfg.FailOn = []string{"apiservices-available"}
+	r.warn("system-coredns", "CoreDNS deployment", "")
+	if r.promoted || r.result.Checks[0].Status != StatusWarning {
+		t.Errorf("Expected a warning not listed with --fail-on to stay a warning")
+	}
+	r.warn("apiservices-available", "Registered APIs available", "")
+	if !r.promoted || r.result.Checks[1].Status != StatusError {
+		t.Errorf("Expected a warning listed with --fail-on to fail the run")
+	}
+}
+
+func TestOkAfter(t *testing.T) {
+	out := &bytes.Buffer{}
+	r := newReporter(out, &CheckResult{})
+	r.okAfter("service-ip-from-pod", "Accessed Nginx service", 1, 3)
+	r.okAfter("service-ip-from-pod", "Accessed Nginx service", 3, 3)
+	if !strings.Contains(out.String(), "(OK on attempt 3/3)") || strings.Contains(out.String(), "attempt 1/3") {
+		t.Errorf("Expected only the retried check to be called out, got:\n%s", out.String())
+	}
+	if c := r.result.Checks[1]; c.Status != StatusOK || c.Attempts != 3 || c.MaxAttempts != 3 {
+		t.Errorf("Unexpected check recorded: %+v", c)
+	}
+
+	r.cfg.FailOnRetries = true
+	r.okAfter("service-ip-from-pod", "Accessed Nginx service", 2, 3)
+	if c := r.result.Checks[2]; c.Status != StatusWarning || c.Attempts != 2 {
+		t.Errorf("Expected a retried check to be a warning with --fail-on-retries, got %+v", c)
+	}
+}
@@ -0,0 +1,49 @@
+package kuberang
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// resultConfigMapKey is the key holding the JSON result in the ConfigMap
+const resultConfigMapKey = "result.json"
+
+// writeResultConfigMap stores the JSON result of the run in the named
+// ConfigMap of the namespace, creating it or replacing its content, so that
+// controllers can act on the outcome of the run
+func writeResultConfigMap(k *kubectl, name string, result *CheckResult) error {
+	b, err := json.MarshalIndent(result, "", "    ")
+	if err != nil {
+		return fmt.Errorf("error marshaling result: %v", err)
+	}
+	manifest, _ := json.Marshal(resultConfigMap(name, string(b)))
+	ko := k.runWithInput(manifest, "create", "-f", "-")
+	if !ko.Success && strings.Contains(ko.CombinedOut, "AlreadyExists") {
+		ko = k.runWithInput(manifest, "replace", "-f", "-")
+	}
+	switch {
+	case ko.Success:
+		return nil
+	case isForbidden(ko.CombinedOut):
+		return fmt.Errorf("Not allowed to write the result to ConfigMap %s in namespace %s, grant create and update on configmaps: %s", name, namespace(k.cfg), strings.TrimSpace(ko.CombinedOut))
+	}
+	return fmt.Errorf("Failed to write the result to ConfigMap %s in namespace %s: %s", name, namespace(k.cfg), strings.TrimSpace(ko.CombinedOut))
+}
+
+// resultConfigMap returns the ConfigMap holding the JSON result
+func resultConfigMap(name, result string) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name": name,
+			"labels": map[string]interface{}{
+				"app": "kuberang-result",
+			},
+		},
+		"data": map[string]interface{}{
+			resultConfigMapKey: result,
+		},
+	}
+}
@@ -0,0 +1,70 @@
+package kuberang
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+func TestWriteResultConfigMap(t *testing.T) {
+	defer func(c func(context.Context, []byte, ...string) ([]byte, error)) { kubectlCommand = c }(kubectlCommand)
+	var verbs []string
+	var manifest string
+	// answers are the outputs of the successive kubectl calls, failing when
+	// not empty
+	var answers []string
+	kubectlCommand = func(ctx context.Context, input []byte, args ...string) ([]byte, error) {
+		verbs = append(verbs, args[len(args)-3])
+		manifest = string(input)
+		answer := answers[0]
+		answers = answers[1:]
+		if answer != "" {
+			return []byte(answer), errors.New("exit status 1")
+		}
+		return nil, nil
+	}
+	k := newKubectl(context.Background(), &config.Config{Namespace: "team-a"})
+	result := &CheckResult{Success: true}
+
+	answers = []string{""}
+	if err := writeResultConfigMap(k, "kuberang-result", result); err != nil || strings.Join(verbs, ",") != "create" {
+		t.Errorf("Expected the ConfigMap to be created, got %v after %v", err, verbs)
+	}
+	if !strings.Contains(manifest, `"name":"kuberang-result"`) || !strings.Contains(manifest, resultConfigMapKey) {
+		t.Errorf("Unexpected manifest %s", manifest)
+	}
+
+	verbs = nil
+	answers = []string{"Error from server (AlreadyExists): configmaps \"kuberang-result\" already exists\n", ""}
+	if err := writeResultConfigMap(k, "kuberang-result", result); err != nil || strings.Join(verbs, ",") != "create,replace" {
+		t.Errorf("Expected the existing ConfigMap to be replaced, got %v after %v", err, verbs)
+	}
+
+	verbs = nil
+	answers = []string{"Error from server (Forbidden): configmaps is forbidden: User \"ci\" cannot create resource \"configmaps\"\n"}
+	err := writeResultConfigMap(k, "kuberang-result", result)
+	if err == nil || !strings.Contains(err.Error(), "grant create and update on configmaps") || !strings.Contains(err.Error(), "namespace team-a") {
+		t.Errorf("Expected the missing permissions to be named, got %v", err)
+	}
+}
+
+func TestResultConfigMapWithEphemeralNamespace(t *testing.T) {
+	cfg := &config.Config{
+		MinReadyFraction:   1,
+		ServiceAccount:     defaultServiceAccount,
+		Parallelism:        1,
+		Profile:            ProfileStandard,
+		NodeChecks:         NodeChecksIgnored,
+		EphemeralNamespace: true,
+	}
+	if err := validateConfig(cfg); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	cfg.ResultConfigMap = "kuberang-result"
+	if err := validateConfig(cfg); err == nil {
+		t.Error("Expected --write-result-configmap to be rejected along with --ephemeral-namespace")
+	}
+}
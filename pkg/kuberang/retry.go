@@ -1,27 +1,122 @@
 package kuberang
 
-import "time"
+import (
+	"context"
+	"sync"
+	"time"
+)
 
-func retry(times int, f func() bool) bool {
+// retryBudget is the number of retries left to the checks of a run, shared
+// by the retry helpers so that a cluster failing everywhere does not make
+// every check go through all of its attempts
+type retryBudget struct {
+	mu        sync.Mutex
+	left      int
+	exhausted bool
+}
+
+// take consumes a retry, and returns false once the budget is exhausted
+func (b *retryBudget) take() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.left == 0 {
+		b.exhausted = true
+		return false
+	}
+	b.left--
+	return true
+}
+
+// isExhausted returns true if a retry was refused for lack of budget
+func (b *retryBudget) isExhausted() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.exhausted
+}
+
+// retrySettings are the settings of a run the retry helpers find in their
+// context
+type retrySettings struct {
+	// budget is the retry budget of the run. It is nil when
+	// --max-total-retries is not set, leaving the retries unbounded.
+	budget    *retryBudget
+	verbosity int
+}
+
+type retrySettingsKey struct{}
+
+// withRetrySettings returns a copy of ctx carrying the retry settings of a run
+func withRetrySettings(ctx context.Context, s retrySettings) context.Context {
+	return context.WithValue(ctx, retrySettingsKey{}, s)
+}
+
+// retrySettingsFrom returns the retry settings carried by ctx, which are
+// unbounded and quiet when there are none
+func retrySettingsFrom(ctx context.Context) retrySettings {
+	s, _ := ctx.Value(retrySettingsKey{}).(retrySettings)
+	return s
+}
+
+func retry(ctx context.Context, times int, f func() bool) bool {
+	return retryAttempts(ctx, times, f) > 0
+}
+
+// retryAttempts calls f up to times times until it succeeds, and returns the
+// attempt on which it did, or 0 when every attempt failed. Once the retry
+// budget of the run is exhausted, f is only called once. No retry is
+// attempted once ctx is done.
+func retryAttempts(ctx context.Context, times int, f func() bool) int {
+	s := retrySettingsFrom(ctx)
 	attempt := 0
 	for attempt < times {
 		if ok := f(); ok {
-			return true
+			return attempt + 1
+		}
+		if attempt+1 == times || !s.budget.take() {
+			return 0
+		}
+		logf(s.verbosity, logDebug, "attempt %d/%d failed, retrying in 1s", attempt+1, times)
+		if !sleep(ctx, 1*time.Second) {
+			return 0
 		}
-		time.Sleep(1 * time.Second)
 		attempt++
 	}
-	return false
+	return 0
 }
 
-func retryWithBackoff(times uint, f func() bool) bool {
+func retryWithBackoff(ctx context.Context, times uint, f func() bool) bool {
+	s := retrySettingsFrom(ctx)
 	var attempt uint
 	for attempt < times {
 		if ok := f(); ok {
 			return true
 		}
-		time.Sleep((1 << attempt) * time.Second)
+		if attempt+1 == times || !s.budget.take() {
+			return false
+		}
+		logf(s.verbosity, logDebug, "attempt %d/%d failed, retrying in %v", attempt+1, times, (1<<attempt)*time.Second)
+		if !sleep(ctx, (1<<attempt)*time.Second) {
+			return false
+		}
 		attempt++
 	}
 	return false
 }
+
+// sleep waits for d, and returns false if ctx is done first
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -0,0 +1,56 @@
+package kuberang
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRetryBudget(t *testing.T) {
+	ctx := context.Background()
+	calls := 0
+	failing := func() bool {
+		calls++
+		return false
+	}
+	if attempt := retryAttempts(ctx, 1, failing); attempt != 0 || calls != 1 {
+		t.Errorf("Expected a single failed attempt, got attempt %d after %d calls", attempt, calls)
+	}
+	if retrySettingsFrom(ctx).budget.isExhausted() {
+		t.Error("Expected an unbounded budget when none is set")
+	}
+
+	budget := &retryBudget{left: 1}
+	ctx = withRetrySettings(ctx, retrySettings{budget: budget})
+	calls = 0
+	if attempt := retryAttempts(ctx, 3, failing); attempt != 0 || calls != 2 {
+		t.Errorf("Expected the budget to allow a single retry, got %d calls", calls)
+	}
+	if !budget.isExhausted() {
+		t.Error("Expected the budget to be reported as exhausted")
+	}
+	calls = 0
+	if retryWithBackoff(ctx, 3, failing) || calls != 1 {
+		t.Errorf("Expected a single attempt once the budget is exhausted, got %d calls", calls)
+	}
+	if attempt := retryAttempts(ctx, 3, func() bool { return true }); attempt != 1 {
+		t.Errorf("Expected a passing check to pass on its first attempt, got %d", attempt)
+	}
+}
+
+func TestRetryStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	start := time.Now()
+	attempt := retryAttempts(ctx, 5, func() bool {
+		calls++
+		cancel()
+		return false
+	})
+	if attempt != 0 || calls != 1 {
+		t.Errorf("Expected a single attempt once cancelled, got %d calls", calls)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected the retry to stop without waiting, took %v", elapsed)
+	}
+}
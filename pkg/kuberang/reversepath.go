@@ -0,0 +1,78 @@
+package kuberang
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+// reversePathPort is the port BusyBox listens on for the reverse path check
+const reversePathPort = 8123
+
+// reversePathMarker is the content served by BusyBox, telling its answer
+// apart from any other listener
+const reversePathMarker = "kuberang-reverse-path"
+
+// runReversePathCheck returns true if the reverse path check is enabled,
+// either explicitly or through the full profile
+func runReversePathCheck(cfg *config.Config) bool {
+	return cfg.ReversePathCheck || cfg.Profile == ProfileFull
+}
+
+// checkReversePath serves a file from BusyBox with its built-in httpd and
+// fetches it from an nginx pod, so that traffic between the pods also flows
+// from nginx to BusyBox. Asymmetric routing, or network policies letting
+// traffic through in one direction only, are caught here.
+func checkReversePath(r *reporter, w *workloads, busyboxPodName string, nginxPods []Pod) bool {
+	var nginxPod *Pod
+	for i := range nginxPods {
+		if nginxPods[i].Ready {
+			nginxPod = &nginxPods[i]
+			break
+		}
+	}
+	busyboxIP := ""
+	if ko := r.kube.run("get", "pods", "-l", w.labels("kuberang-busybox"), "-o", "json"); ko.Success {
+		for _, p := range ko.Pods() {
+			if p.Name == busyboxPodName {
+				busyboxIP = p.IP
+			}
+		}
+	}
+	switch {
+	case nginxPod == nil:
+		r.skipped("pod-ip-from-nginx", "Accessed BusyBox from an Nginx pod (reverse path pod→pod)", "no nginx pod is Ready")
+		return true
+	case busyboxIP == "":
+		r.err("pod-ip-from-nginx", "Accessed BusyBox from Nginx pod "+nginxPod.Name+" (reverse path pod→pod)", "Could not get the IP of BusyBox pod "+busyboxPodName+"\n")
+		return false
+	}
+	address := fmt.Sprintf("%s:%d", busyboxIP, reversePathPort)
+	name := "Accessed BusyBox at " + address + " from Nginx pod " + nginxPod.Name + " (reverse path pod→pod)"
+
+	// httpd forks into the background, and is stopped once the check is done
+	dir := scratchMountPath + "/reverse-path"
+	listen := fmt.Sprintf("mkdir -p %s && echo %s > %s/index.html && httpd -p %d -h %s", dir, reversePathMarker, dir, reversePathPort, dir)
+	if ko := r.kube.run("exec", busyboxPodName, "--", "sh", "-c", listen); !ko.Success {
+		r.err("pod-ip-from-nginx", name, fmt.Sprintf("BusyBox could not listen on port %d\n", reversePathPort)+ko.CombinedOut)
+		return false
+	}
+	defer r.kube.run("exec", busyboxPodName, "--", "pkill", "-f", fmt.Sprintf("httpd -p %d", reversePathPort))
+
+	var ko KubeOutput
+	attempt := retryAttempts(r.ctx, 3, func() bool {
+		ko = r.kube.run("exec", nginxPod.Name, "--", "wget", "-T", wgetTimeoutSeconds, "-qO-", "http://"+address+"/")
+		return ko.Success && strings.Contains(ko.CombinedOut, reversePathMarker)
+	})
+	switch {
+	case attempt > 0:
+		r.onNode(nginxPod.NodeName).okAfter("pod-ip-from-nginx", name, attempt, 3)
+		return true
+	case r.cfg.IgnorePodIPAccessibilityCheck:
+		r.onNode(nginxPod.NodeName).ignored("pod-ip-from-nginx", name, ko.CombinedOut)
+		return true
+	}
+	r.onNode(nginxPod.NodeName).err("pod-ip-from-nginx", name, "The nginx pod does not reach BusyBox: check the routes and network policies from its node and namespace\n"+ko.CombinedOut)
+	return false
+}
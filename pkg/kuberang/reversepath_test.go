@@ -0,0 +1,54 @@
+package kuberang
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+func TestCheckReversePath(t *testing.T) {
+	defer func(c func(context.Context, []byte, ...string) ([]byte, error)) { kubectlCommand = c }(kubectlCommand)
+	reachable, stopped := true, false
+	kubectlCommand = func(ctx context.Context, input []byte, args ...string) ([]byte, error) {
+		command := strings.Join(args, " ")
+		switch {
+		case strings.HasPrefix(command, "get pods"):
+			return []byte(`{"items": [{"metadata": {"name": "busybox"}, "spec": {"nodeName": "node1"}, "status": {"podIP": "172.16.0.4"}}]}`), nil
+		case strings.HasPrefix(command, "exec busybox -- sh -c"):
+			return nil, nil
+		case strings.HasPrefix(command, "exec busybox -- pkill"):
+			stopped = true
+			return nil, nil
+		case command == "exec nginx-b -- wget -T "+wgetTimeoutSeconds+" -qO- http://172.16.0.4:8123/" && reachable:
+			return []byte(reversePathMarker + "\n"), nil
+		}
+		return []byte("wget: download timed out\n"), errors.New("exit status 1")
+	}
+	pods := []Pod{
+		{Name: "nginx-a", NodeName: "node1"},
+		{Name: "nginx-b", NodeName: "node2", Ready: true},
+	}
+	w := newWorkloads(&config.Config{}, 1)
+
+	r := newReporter(ioutil.Discard, &CheckResult{})
+	if !checkReversePath(r, w, "busybox", pods) || !stopped {
+		t.Errorf("Expected BusyBox to be reached from nginx-b and the listener to be stopped, got %v", r.result.Checks)
+	}
+
+	// Cancelled so that the failing connections are not retried
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	reachable, stopped = false, false
+	r = newReporter(ioutil.Discard, &CheckResult{})
+	r.ctx = ctx
+	if checkReversePath(r, w, "busybox", pods) || !stopped {
+		t.Errorf("Expected the reverse path to fail and the listener to be stopped")
+	}
+	if c := r.result.Checks[0]; c.ID != "pod-ip-from-nginx" || c.Node != "node2" || !strings.Contains(c.Name, "reverse path pod→pod") {
+		t.Errorf("Unexpected check %+v", c)
+	}
+}
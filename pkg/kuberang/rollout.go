@@ -0,0 +1,72 @@
+package kuberang
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+// Deployment strategies accepted by --deployment-strategy
+const (
+	StrategyRollingUpdate = "RollingUpdate"
+	StrategyRecreate      = "Recreate"
+)
+
+// rolloutTimeout bounds the wait for the rollout of the nginx deployment
+const rolloutTimeout = 120 * time.Second
+
+// checkRollout changes an annotation of the nginx pod template, switching
+// the deployment to the configured strategy on the way, and waits for the
+// deployment controller to replace every nginx pod
+func checkRollout(r *reporter, w *workloads) bool {
+	name := "Rolled out a new template of the Nginx deployment"
+	if r.cfg.DeploymentStrategy != "" {
+		name += " using the " + r.cfg.DeploymentStrategy + " strategy"
+	}
+	patch, _ := json.Marshal(rolloutPatch(r.cfg, time.Now()))
+	if ko := r.kube.run("patch", "deployment", w.ngDeployment, "-p", string(patch)); !ko.Success {
+		r.err("rollout", name, ko.CombinedOut)
+		return false
+	}
+	var ko KubeOutput
+	start := time.Now()
+	for time.Since(start) < rolloutTimeout {
+		if ko = r.kube.getDeployment(w.ngDeployment); ko.Success && ko.DeploymentRolledOut() {
+			r.ok("rollout", fmt.Sprintf("%s in %v", name, time.Since(start)))
+			return true
+		}
+		if !sleep(r.ctx, 1*time.Second) {
+			break
+		}
+	}
+	detail := fmt.Sprintf("The rollout did not complete within %v, check the deployment controller\n", rolloutTimeout)
+	if dko := r.kube.run("rollout", "status", "deployment", w.ngDeployment, "--watch=false"); dko.CombinedOut != "" {
+		detail += dko.CombinedOut
+	}
+	r.err("rollout", name, detail)
+	return false
+}
+
+// rolloutPatch returns the merge patch triggering a rollout of the nginx
+// deployment. The rollingUpdate parameters must be cleared when switching
+// to the Recreate strategy.
+func rolloutPatch(cfg *config.Config, now time.Time) map[string]interface{} {
+	spec := map[string]interface{}{
+		"template": map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{
+					"kuberang/rollout": now.Format(time.RFC3339Nano),
+				},
+			},
+		},
+	}
+	switch cfg.DeploymentStrategy {
+	case StrategyRecreate:
+		spec["strategy"] = map[string]interface{}{"type": StrategyRecreate, "rollingUpdate": nil}
+	case StrategyRollingUpdate:
+		spec["strategy"] = map[string]interface{}{"type": StrategyRollingUpdate}
+	}
+	return map[string]interface{}{"spec": spec}
+}
@@ -0,0 +1,37 @@
+package kuberang
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+func TestRolloutPatch(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.DeploymentStrategy = StrategyRecreate
+	b, _ := json.Marshal(rolloutPatch(cfg, time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC)))
+	expected := `{"spec":{"strategy":{"rollingUpdate":null,"type":"Recreate"},"template":{"metadata":{"annotations":{"kuberang/rollout":"2017-01-02T03:04:05Z"}}}}}`
+	if string(b) != expected {
+		t.Errorf("Unexpected patch:\n%s\nexpected:\n%s", b, expected)
+	}
+}
+
+func TestDeploymentRolledOut(t *testing.T) {
+	tests := []struct {
+		status    string
+		rolledOut bool
+	}{
+		{`"observedGeneration": 2, "replicas": 3, "updatedReplicas": 3, "availableReplicas": 3`, true},
+		{`"observedGeneration": 1, "replicas": 3, "updatedReplicas": 3, "availableReplicas": 3`, false},
+		{`"observedGeneration": 2, "replicas": 4, "updatedReplicas": 3, "availableReplicas": 4`, false},
+		{`"observedGeneration": 2, "replicas": 3, "updatedReplicas": 3, "availableReplicas": 2`, false},
+	}
+	for _, test := range tests {
+		ko := KubeOutput{RawOut: []byte(`{"metadata": {"generation": 2}, "spec": {"replicas": 3}, "status": {` + test.status + `}}`)}
+		if ko.DeploymentRolledOut() != test.rolledOut {
+			t.Errorf("Expected rolled out to be %v for status %s", test.rolledOut, test.status)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package kuberang
+
+import (
+	"fmt"
+)
+
+// SampleResult is the outcome of a connectivity check repeated many times
+type SampleResult struct {
+	Target    string `json:"target"`
+	Successes int    `json:"successes"`
+	Attempts  int    `json:"attempts"`
+}
+
+// Ratio returns the fraction of successful attempts
+func (s SampleResult) Ratio() float64 {
+	if s.Attempts == 0 {
+		return 0
+	}
+	return float64(s.Successes) / float64(s.Attempts)
+}
+
+// Intermittent returns true if some, but not all, attempts succeeded
+func (s SampleResult) Intermittent() bool {
+	return s.Successes > 0 && s.Successes < s.Attempts
+}
+
+// checkPodsSampled accesses every nginx pod from the BusyBox pod many times
+// in quick succession. Pods that answer every time are ok, pods that answer
+// intermittently are flagged, and pods below the success ratio threshold
+// fail unless pod IP accessibility is ignored.
+func checkPodsSampled(r *reporter, busyboxPodName string, podIPs []string) bool {
+	success := true
+	intermittent := 0
+	for _, podIP := range podIPs {
+		name := "Accessed Nginx pod at " + podIP + " from BusyBox"
+		ko := r.kube.run("exec", busyboxPodName, "--", "sh", "-c", sampleScript(podIP, r.cfg.Sample))
+		t := parseTimings(ko.CombinedOut)
+		s := SampleResult{Target: podIP, Successes: len(t.latencies), Attempts: len(t.latencies) + t.errors}
+		if !ko.Success || s.Attempts == 0 {
+			s = SampleResult{Target: podIP, Attempts: r.cfg.Sample}
+		}
+		r.result.Samples = append(r.result.Samples, s)
+		name = fmt.Sprintf("%s (%d/%d)", name, s.Successes, s.Attempts)
+		if s.Intermittent() {
+			intermittent++
+		}
+		r.onPod(podIP)
+		switch {
+		case s.Successes == s.Attempts:
+			r.ok("pod-ip-from-pod", name)
+		case s.Successes > 0 && s.Ratio() >= r.cfg.SampleMinRatio:
+			r.warn("pod-ip-from-pod", name, fmt.Sprintf("Intermittent: %d of %d attempts failed\n", s.Attempts-s.Successes, s.Attempts))
+		case r.cfg.IgnorePodIPAccessibilityCheck:
+			r.ignored("pod-ip-from-pod", name, ko.CombinedOut)
+		default:
+			r.err("pod-ip-from-pod", name, ko.CombinedOut)
+			success = false
+		}
+	}
+	if intermittent == 0 {
+		r.ok("pod-ip-sampling", fmt.Sprintf("No intermittent connectivity to %d Nginx pods", len(podIPs)))
+	} else {
+		r.warn("pod-ip-sampling", fmt.Sprintf("No intermittent connectivity to %d Nginx pods", len(podIPs)), fmt.Sprintf("%d pods answered intermittently\n", intermittent))
+	}
+	return success
+}
+
+// sampleScript returns the shell script accessing the pod the given number
+// of times, printing the outcome of every attempt. Attempts are not timed.
+func sampleScript(podIP string, attempts int) string {
+	return fmt.Sprintf("for i in $(seq %d); do if wget -q -T %s -O /dev/null %s; then echo ok 0; else echo err; fi; done", attempts, wgetTimeoutSeconds, podIP)
+}
@@ -0,0 +1,24 @@
+package kuberang
+
+import "testing"
+
+func TestSampleResult(t *testing.T) {
+	tests := []struct {
+		sample       SampleResult
+		ratio        float64
+		intermittent bool
+	}{
+		{SampleResult{Successes: 10, Attempts: 10}, 1, false},
+		{SampleResult{Successes: 8, Attempts: 10}, 0.8, true},
+		{SampleResult{Successes: 0, Attempts: 10}, 0, false},
+		{SampleResult{}, 0, false},
+	}
+	for _, test := range tests {
+		if ratio := test.sample.Ratio(); ratio != test.ratio {
+			t.Errorf("Expected ratio %v for %+v, got %v", test.ratio, test.sample, ratio)
+		}
+		if intermittent := test.sample.Intermittent(); intermittent != test.intermittent {
+			t.Errorf("Expected intermittent to be %v for %+v", test.intermittent, test.sample)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package kuberang
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/apprenda/kuberang/pkg/config"
+	"github.com/apprenda/kuberang/pkg/util"
+)
+
+// kuberangPrefix is the name prefix shared by every object kuberang creates
+const kuberangPrefix = "kuberang-"
+
+// testIDLabel is the label carrying the test ID of the run, set on every
+// object kuberang creates
+const testIDLabel = "kuberang/testid"
+
+// scannedKinds are the kinds of objects that kuberang may leave behind
+const scannedKinds = "deployments,replicasets,daemonsets,cronjobs,jobs,pods,services,configmaps"
+
+// findLeftovers returns the objects named after kuberang found in the
+// configured namespace, or in every namespace when allNamespaces is set. It
+// is only used to list them, as other objects may be named after kuberang.
+func findLeftovers(k *kubectl, allNamespaces bool) ([]Object, error) {
+	args := []string{"get", scannedKinds, "-o", "json"}
+	if allNamespaces {
+		args = append(args, "--all-namespaces")
+	}
+	ko := k.run(args...)
+	if !ko.Success {
+		return nil, fmt.Errorf("Failed to list kuberang objects: %s", strings.TrimSpace(ko.CombinedOut))
+	}
+	leftovers := []Object{}
+	for _, o := range ko.Objects() {
+		if strings.HasPrefix(o.Name, kuberangPrefix) {
+			leftovers = append(leftovers, o)
+		}
+	}
+	return leftovers, nil
+}
+
+// findTestObjects returns the objects created by kuberang, selected by their
+// test ID label so that other objects named after kuberang are left alone.
+// The extra arguments are passed to kubectl get.
+func findTestObjects(k *kubectl, args ...string) ([]Object, error) {
+	ko := k.run(append([]string{"get", scannedKinds, "-l", testIDLabel, "-o", "json"}, args...)...)
+	if !ko.Success {
+		return nil, fmt.Errorf("Failed to list kuberang objects: %s", strings.TrimSpace(ko.CombinedOut))
+	}
+	return ko.Objects(), nil
+}
+
+// Scan lists the kuberang objects left behind by previous runs
+func Scan(ctx context.Context, cfg *config.Config, out io.Writer, allNamespaces bool) error {
+	leftovers, err := findLeftovers(newKubectl(ctx, cfg), allNamespaces)
+	if err != nil {
+		return err
+	}
+	if len(leftovers) == 0 {
+		fmt.Fprintln(out, "No kuberang objects found")
+		return nil
+	}
+	printObjects(out, leftovers)
+	return nil
+}
+
+// Cleanup removes the kuberang objects left behind by previous runs in the
+// configured namespace, as told by their test ID label. Objects owned by
+// another object (e.g. the pods of a deployment) are removed by the garbage
+// collector along with their owner.
+func Cleanup(ctx context.Context, cfg *config.Config, out io.Writer) error {
+	k := newKubectl(ctx, cfg)
+	leftovers, err := findTestObjects(k)
+	if err != nil {
+		return err
+	}
+	ok := true
+	removed := 0
+	for _, o := range leftovers {
+		if o.Owned {
+			continue
+		}
+		ref := strings.ToLower(o.Kind) + "/" + o.Name
+		if ko := k.run("delete", "--ignore-not-found=true", ref); ko.Success {
+			util.PrettyPrintOk(out, "Deleted %s", ref)
+			removed++
+		} else {
+			util.PrettyPrintErr(out, "Deleted %s", ref)
+			printFailureDetail(out, ko.CombinedOut)
+			ok = false
+		}
+	}
+	if removed == 0 && ok {
+		fmt.Fprintln(out, "No kuberang objects found")
+	}
+	if !ok {
+		return errors.New("Failed to remove one or more kuberang objects")
+	}
+	return nil
+}
+
+func printObjects(out io.Writer, objects []Object) {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tKIND\tNAME\tAGE\tSTATUS")
+	for _, o := range objects {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", o.Namespace, o.Kind, o.Name, shortDuration(time.Since(o.Created)), o.Status)
+	}
+	w.Flush()
+}
+
+// shortDuration formats a duration the way kubectl displays ages
+func shortDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
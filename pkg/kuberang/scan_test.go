@@ -0,0 +1,54 @@
+package kuberang
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+// sampleNamedObjects holds a deployment of kuberang and a user deployment
+// merely named after kuberang
+const sampleNamedObjects = `{"items": [
+	{"kind": "Deployment", "metadata": {"name": "kuberang-nginx", "labels": {"app": "kuberang-nginx", "kuberang/testid": "1"}}},
+	{"kind": "Deployment", "metadata": {"name": "kuberang-foo", "labels": {"app": "kuberang-foo"}}}
+]}`
+
+// sampleTestObjects holds the objects of sampleNamedObjects matching the
+// test ID label
+const sampleTestObjects = `{"items": [
+	{"kind": "Deployment", "metadata": {"name": "kuberang-nginx", "labels": {"app": "kuberang-nginx", "kuberang/testid": "1"}}}
+]}`
+
+func TestCleanupLeavesUnlabelledObjects(t *testing.T) {
+	defer func(c func(context.Context, []byte, ...string) ([]byte, error)) { kubectlCommand = c }(kubectlCommand)
+	deleted := []string{}
+	kubectlCommand = func(ctx context.Context, input []byte, args ...string) ([]byte, error) {
+		command := strings.Join(args, " ")
+		switch {
+		case strings.HasPrefix(command, "get ") && strings.Contains(command, "-l "+testIDLabel):
+			return []byte(sampleTestObjects), nil
+		case strings.HasPrefix(command, "get "):
+			return []byte(sampleNamedObjects), nil
+		case strings.HasPrefix(command, "delete "):
+			deleted = append(deleted, command)
+			return nil, nil
+		}
+		t.Errorf("Unexpected kubectl arguments %q", args)
+		return nil, errors.New("exit status 1")
+	}
+	out := &bytes.Buffer{}
+	if err := Scan(context.Background(), &config.Config{}, out, false); err != nil || !strings.Contains(out.String(), "kuberang-foo") {
+		t.Errorf("Expected scan to list every object named after kuberang, got %v:\n%s", err, out)
+	}
+	if err := Cleanup(context.Background(), &config.Config{}, &bytes.Buffer{}); err != nil {
+		t.Fatalf("Cleanup returned %v", err)
+	}
+	if want := []string{"delete --ignore-not-found=true deployment/kuberang-nginx"}; !reflect.DeepEqual(deleted, want) {
+		t.Errorf("got deletions %q, want %q", deleted, want)
+	}
+}
@@ -0,0 +1,65 @@
+package kuberang
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// checkSelectors verifies that the selectors of the nginx deployment and
+// service match every nginx pod. A mismatch leaves the service without
+// endpoints, which would otherwise surface as unrelated connectivity errors.
+func checkSelectors(r *reporter, w *workloads, pods []Pod) bool {
+	name := "Nginx deployment and service selectors match the Nginx pods"
+	dko := r.kube.getDeployment(w.ngDeployment)
+	sko := r.kube.getService(w.ngService)
+	if !dko.Success || !sko.Success {
+		r.err("nginx-selectors", name, dko.CombinedOut+sko.CombinedOut)
+		return false
+	}
+	problems := selectorMismatches("deployment "+w.ngDeployment, dko.DeploymentSelector(), pods)
+	problems = append(problems, selectorMismatches("service "+w.ngService, sko.ServiceSelector(), pods)...)
+	if len(problems) > 0 {
+		r.err("nginx-selectors", name, strings.Join(problems, "\n")+"\n")
+		return false
+	}
+	r.ok("nginx-selectors", name)
+	return true
+}
+
+// selectorMismatches describes the pods not matched by the selector of the
+// given object. An empty selector matches nothing, as far as kuberang is
+// concerned, since the object then does not manage the pods.
+func selectorMismatches(object string, selector map[string]string, pods []Pod) []string {
+	if len(selector) == 0 {
+		return []string{"The " + object + " has no selector"}
+	}
+	problems := []string{}
+	for _, p := range pods {
+		if !selectorMatches(selector, p.Labels) {
+			problems = append(problems, fmt.Sprintf("The selector %s of the %s does not match pod %s", formatLabels(selector), object, p.Name))
+		}
+	}
+	return problems
+}
+
+// selectorMatches returns true if all the labels of the selector are set to
+// the same value in the given labels
+func selectorMatches(selector, labels map[string]string) bool {
+	for k, v := range selector {
+		if value, ok := labels[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
+
+// formatLabels formats the labels the way kubectl accepts them, sorted by key
+func formatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,22 @@
+package kuberang
+
+import "testing"
+
+func TestSelectorMismatches(t *testing.T) {
+	selector := map[string]string{"app": "kuberang-nginx", "kuberang/testid": "1"}
+	pods := []Pod{
+		{Name: "matching", Labels: map[string]string{"app": "kuberang-nginx", "kuberang/testid": "1", "extra": "x"}},
+		{Name: "other-test", Labels: map[string]string{"app": "kuberang-nginx", "kuberang/testid": "2"}},
+		{Name: "unlabeled"},
+	}
+	problems := selectorMismatches("deployment kuberang-nginx", selector, pods)
+	if len(problems) != 2 {
+		t.Fatalf("Expected 2 mismatches, got %v", problems)
+	}
+	if problems[0] != "The selector app=kuberang-nginx,kuberang/testid=1 of the deployment kuberang-nginx does not match pod other-test" {
+		t.Errorf("Unexpected problem: %s", problems[0])
+	}
+	if problems := selectorMismatches("service kuberang-nginx-1", nil, pods); len(problems) != 1 {
+		t.Errorf("Expected an empty selector to be reported, got %v", problems)
+	}
+}
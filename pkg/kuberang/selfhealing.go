@@ -0,0 +1,123 @@
+package kuberang
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SelfHealingResult is the outcome of deleting an nginx pod and waiting for
+// its replacement
+type SelfHealingResult struct {
+	DeletedPod       string `json:"deletedPod"`
+	ReplacementPod   string `json:"replacementPod,omitempty"`
+	RecoveredAfterMs int64  `json:"recoveredAfterMs,omitempty"`
+	Probes           int    `json:"probes"`
+	FailedProbes     int    `json:"failedProbes"`
+}
+
+// checkSelfHealing deletes an nginx pod and verifies that the deployment
+// replaces it with a pod that becomes ready, while the service is probed
+// from the BusyBox pod throughout the disruption
+func checkSelfHealing(r *reporter, w *workloads, busyboxPodName string, pods []Pod) bool {
+	name := "Deleted Nginx pod replaced by a ready pod"
+	ready := map[string]bool{}
+	var victim string
+	for _, p := range pods {
+		if p.Ready {
+			ready[p.Name] = true
+			victim = p.Name
+		}
+	}
+	// Deleting the sole replica would take the service down by design
+	if len(ready) < 2 {
+		r.skipped("self-healing-replaced", name, "needs at least two ready nginx replicas")
+		return true
+	}
+	result := &SelfHealingResult{DeletedPod: victim}
+	r.result.SelfHealing = result
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			ko := r.kube.run("exec", busyboxPodName, "--", "wget", "-T", "1", "-qO", "/dev/null", probeURL(r.cfg, w.ngService))
+			result.Probes++
+			if !ko.Success {
+				result.FailedProbes++
+			}
+			if !sleep(r.ctx, 500*time.Millisecond) {
+				return
+			}
+		}
+	}()
+
+	success := true
+	start := time.Now()
+	if ko := r.kube.run("delete", "pod", victim); !ko.Success {
+		close(stop)
+		wg.Wait()
+		r.err("self-healing-replaced", name, ko.CombinedOut)
+		return false
+	}
+	for time.Since(start) < deploymentTimeout {
+		ko := r.kube.run("get", "pods", "-l", w.labels("kuberang-nginx"), "-o", "json")
+		if replacement := replacementPod(ko.Pods(), ready, victim, len(ready)); ko.Success && replacement != "" {
+			result.ReplacementPod = replacement
+			break
+		}
+		if !sleep(r.ctx, 1*time.Second) {
+			break
+		}
+	}
+	elapsed := time.Since(start)
+	close(stop)
+	wg.Wait()
+
+	if result.ReplacementPod != "" {
+		result.RecoveredAfterMs = int64(elapsed / time.Millisecond)
+		r.ok("self-healing-replaced", fmt.Sprintf("Deleted Nginx pod %s replaced by %s after %v", victim, result.ReplacementPod, elapsed))
+	} else {
+		r.err("self-healing-replaced", name, fmt.Sprintf("No ready replacement for %s after %v\n", victim, deploymentTimeout))
+		success = false
+	}
+
+	name = fmt.Sprintf("Nginx service reachable from BusyBox while the pod was replaced (%d/%d probes failed)", result.FailedProbes, result.Probes)
+	switch {
+	case result.FailedProbes == 0:
+		r.ok("self-healing-availability", name)
+	case result.FailedProbes < result.Probes:
+		r.warn("self-healing-availability", name, fmt.Sprintf("%d of %d probes failed during the disruption\n", result.FailedProbes, result.Probes))
+	default:
+		r.err("self-healing-availability", name, "Every probe failed during the disruption\n")
+		success = false
+	}
+	return success
+}
+
+// replacementPod returns the name of a ready pod that was not ready before
+// the deletion, once the number of ready pods is back to what it was
+func replacementPod(pods []Pod, before map[string]bool, deleted string, want int) string {
+	readyCount := 0
+	replacement := ""
+	for _, p := range pods {
+		if !p.Ready || p.Name == deleted {
+			continue
+		}
+		readyCount++
+		if !before[p.Name] {
+			replacement = p.Name
+		}
+	}
+	if readyCount < want {
+		return ""
+	}
+	return replacement
+}
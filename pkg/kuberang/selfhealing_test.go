@@ -0,0 +1,19 @@
+package kuberang
+
+import "testing"
+
+func TestReplacementPod(t *testing.T) {
+	before := map[string]bool{"nginx-a": true, "nginx-b": true}
+	pods := []Pod{
+		{Name: "nginx-a", Ready: true},
+		{Name: "nginx-b", Ready: false},
+		{Name: "nginx-c", Ready: false},
+	}
+	if r := replacementPod(pods, before, "nginx-b", 2); r != "" {
+		t.Errorf("Expected no replacement while it is not ready, got %s", r)
+	}
+	pods[2].Ready = true
+	if r := replacementPod(pods, before, "nginx-b", 2); r != "nginx-c" {
+		t.Errorf("Expected nginx-c to be the replacement, got %q", r)
+	}
+}
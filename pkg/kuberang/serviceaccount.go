@@ -0,0 +1,56 @@
+package kuberang
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+// defaultServiceAccount is the ServiceAccount pods run as unless told
+// otherwise, provisioned by the controller manager in every namespace
+const defaultServiceAccount = "default"
+
+// ephemeralServiceAccountTimeout is the least time given to the default
+// ServiceAccount of an ephemeral namespace to appear
+const ephemeralServiceAccountTimeout = 30 * time.Second
+
+// precheckServiceAccount verifies that the ServiceAccount the test pods run
+// as exists in the namespace, waiting up to --service-account-timeout for
+// it to be provisioned. Pods cannot be created in a namespace until then.
+func precheckServiceAccount(r *reporter) bool {
+	name := "ServiceAccount " + r.cfg.ServiceAccount + " exists in namespace " + namespace(r.cfg)
+	timeout := r.cfg.ServiceAccountTimeout
+	// A namespace created for the run gets its default ServiceAccount shortly
+	// after creation
+	if r.cfg.EphemeralNamespace && timeout < ephemeralServiceAccountTimeout {
+		timeout = ephemeralServiceAccountTimeout
+	}
+	start := time.Now()
+	for {
+		ko := r.kube.run("get", "serviceaccount", r.cfg.ServiceAccount, "-o", "json")
+		switch {
+		case ko.Success:
+			r.ok("service-account-exists", name)
+			return true
+		case isForbidden(ko.CombinedOut):
+			r.skipped("service-account-exists", name, "not allowed to read ServiceAccounts")
+			return true
+		case time.Since(start) >= timeout || r.ctx.Err() != nil:
+			detail := fmt.Sprintf("Pods cannot be created in namespace %s until ServiceAccount %s exists.", namespace(r.cfg), r.cfg.ServiceAccount)
+			if r.cfg.ServiceAccount == defaultServiceAccount {
+				detail += " The default ServiceAccount of a new namespace is provisioned by the controller manager, use --service-account-timeout to wait for it."
+			}
+			r.err("service-account-exists", name, detail+"\n"+ko.CombinedOut)
+			return false
+		}
+		sleep(r.ctx, 1*time.Second)
+	}
+}
+
+// serviceAccountSpec runs the pod as the configured ServiceAccount
+func serviceAccountSpec(cfg *config.Config, spec map[string]interface{}) {
+	if cfg.ServiceAccount != defaultServiceAccount {
+		spec["serviceAccountName"] = cfg.ServiceAccount
+	}
+}
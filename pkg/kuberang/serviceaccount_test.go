@@ -0,0 +1,74 @@
+package kuberang
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+// generatedPodSpecs returns the pod specs of the manifests created from
+// kuberang, and of the pods run through kubectl run with --overrides, by
+// name. The DNS probe pod is only run when cfg.DNSProbeImage is set.
+func generatedPodSpecs(t *testing.T, w *workloads) map[string]map[string]interface{} {
+	template := func(object map[string]interface{}) map[string]interface{} {
+		return object["spec"].(map[string]interface{})["template"].(map[string]interface{})["spec"].(map[string]interface{})
+	}
+	cronJob := cronJobManifest(w)["spec"].(map[string]interface{})["jobTemplate"].(map[string]interface{})
+	specs := map[string]map[string]interface{}{
+		"node probe":     template(nodeProbeDaemonSet(w)),
+		"pre-pull":       template(prePullDaemonSet(w)),
+		"from node":      fromNodePod(w)["spec"].(map[string]interface{}),
+		"init container": initContainerPod(w)["spec"].(map[string]interface{}),
+		"liveness":       livenessPod(w)["spec"].(map[string]interface{}),
+		"job":            template(jobManifest(w)),
+		"cronjob":        template(cronJob),
+	}
+
+	defer func(c func(context.Context, []byte, ...string) ([]byte, error)) { kubectlCommand = c }(kubectlCommand)
+	kubectlCommand = func(ctx context.Context, input []byte, args ...string) ([]byte, error) {
+		if args[0] != "run" {
+			return nil, nil
+		}
+		spec := map[string]interface{}{}
+		for _, arg := range args {
+			if strings.HasPrefix(arg, "--overrides=") {
+				overrides := map[string]interface{}{}
+				if err := json.Unmarshal([]byte(strings.TrimPrefix(arg, "--overrides=")), &overrides); err != nil {
+					t.Fatalf("Invalid overrides %q: %v", arg, err)
+				}
+				spec = overrides["spec"].(map[string]interface{})
+				if _, ok := spec["template"]; ok {
+					spec = template(overrides)
+				}
+			}
+		}
+		specs[args[1]] = spec
+		return []byte("10.96.0.10\n"), nil
+	}
+	r := newReporter(ioutil.Discard, &CheckResult{})
+	r.cfg, r.kube = w.cfg, newKubectl(r.ctx, w.cfg)
+	deployNginx(r, w)
+	deployBusybox(r, w)
+	checkDNSOverTCP(r, w)
+	return specs
+}
+
+func TestServiceAccountSpec(t *testing.T) {
+	cfg := &config.Config{ServiceAccount: defaultServiceAccount, DNSProbeImage: "dig"}
+	for name, spec := range generatedPodSpecs(t, newWorkloads(cfg, 1)) {
+		if _, ok := spec["serviceAccountName"]; ok {
+			t.Errorf("Expected the %s pod to run as the default ServiceAccount, got %v", name, spec["serviceAccountName"])
+		}
+	}
+
+	cfg.ServiceAccount = "kuberang"
+	for name, spec := range generatedPodSpecs(t, newWorkloads(cfg, 1)) {
+		if spec["serviceAccountName"] != "kuberang" {
+			t.Errorf("Expected the %s pod to run as ServiceAccount kuberang, got %v", name, spec["serviceAccountName"])
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package kuberang
+
+import (
+	"sync"
+	"time"
+
+	"github.com/apprenda/kuberang/pkg/util"
+)
+
+// orderedSink collects the checks of work running concurrently. Every piece
+// of work reports through a reporter of its own, whose output is buffered,
+// and the checks are flushed to the parent reporter in submission order, the
+// output of each piece at once, as soon as every earlier piece is done.
+type orderedSink struct {
+	parent *reporter
+	mu     sync.Mutex
+	slots  []*sinkSlot
+	// flushed is the number of slots already flushed
+	flushed int
+}
+
+// sinkSlot is the reporter of a piece of work along with its buffer
+type sinkSlot struct {
+	r    *reporter
+	out  *util.Buffer
+	done bool
+}
+
+func newOrderedSink(parent *reporter) *orderedSink {
+	return &orderedSink{parent: parent}
+}
+
+// submit returns the reporter of the next piece of work. Its checks are
+// flushed after the ones of every reporter submitted before it.
+func (s *orderedSink) submit() *reporter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := util.NewBuffer(s.parent.out)
+	r := &reporter{
+		out: out,
+		// Shared for the pods and nodes the checks are attributed to
+		result: &CheckResult{Topology: s.parent.result.Topology},
+		cfg:    s.parent.cfg,
+		ctx:    s.parent.ctx,
+		kube:   s.parent.kube,
+	}
+	s.slots = append(s.slots, &sinkSlot{r: r, out: out})
+	return r
+}
+
+// done marks the work of the reporter as done, and flushes every slot whose
+// predecessors are all done
+func (s *orderedSink) done(r *reporter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, slot := range s.slots[s.flushed:] {
+		if slot.r == r {
+			slot.done = true
+		}
+	}
+	for s.flushed < len(s.slots) && s.slots[s.flushed].done {
+		s.flush(s.slots[s.flushed])
+		s.flushed++
+	}
+}
+
+// flush records the checks of the slot in the parent reporter and prints
+// their output
+func (s *orderedSink) flush(slot *sinkSlot) {
+	p := s.parent
+	for _, c := range slot.r.result.Checks {
+		p.result.Checks = append(p.result.Checks, c)
+		if p.listener != nil {
+			p.listener(c)
+		}
+	}
+	if slot.r.promoted {
+		p.promoted = true
+	}
+	slot.out.Flush()
+}
+
+// runConcurrently runs f for every i below n, up to limit at a time, each
+// with a reporter of its own. The checks are reported in the order of i.
+func runConcurrently(r *reporter, n, limit int, f func(r *reporter, i int)) {
+	s := newOrderedSink(r)
+	reporters := make([]*reporter, n)
+	for i := range reporters {
+		reporters[i] = s.submit()
+	}
+	parallelize(n, limit, func(i int) {
+		defer s.done(reporters[i])
+		reporters[i].started = time.Now()
+		f(reporters[i], i)
+	})
+	r.started = time.Now()
+}
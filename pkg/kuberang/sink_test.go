@@ -0,0 +1,86 @@
+package kuberang
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var checkIDPattern = regexp.MustCompile(`check-\d+`)
+
+// writeRecorder records every write it receives separately
+type writeRecorder struct {
+	mu     sync.Mutex
+	writes []string
+}
+
+func (w *writeRecorder) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writes = append(w.writes, string(p))
+	return len(p), nil
+}
+
+func TestRunConcurrentlyOrdersOutput(t *testing.T) {
+	const n = 64
+	report := func(r *reporter, i int) {
+		id := fmt.Sprintf("check-%02d", i)
+		if i%3 == 0 {
+			r.err(id, "Check "+id, fmt.Sprintf("first line of %s\nsecond line of %s\nthird line of %s", id, id, id))
+			return
+		}
+		r.ok(id, "Check "+id)
+	}
+
+	var expected bytes.Buffer
+	sequential := newReporter(&expected, &CheckResult{})
+	for i := 0; i < n; i++ {
+		report(sequential, i)
+	}
+
+	out := &writeRecorder{}
+	result := &CheckResult{}
+	r := newReporter(out, result)
+	listened := []string{}
+	r.listener = func(c Check) { listened = append(listened, c.ID) }
+	runConcurrently(r, n, n, func(r *reporter, i int) {
+		time.Sleep(time.Duration(rand.Intn(5)) * time.Millisecond)
+		report(r, i)
+	})
+
+	if len(out.writes) != n {
+		t.Fatalf("Expected one write per check, got %d writes", len(out.writes))
+	}
+	for i, w := range out.writes {
+		id := fmt.Sprintf("check-%02d", i)
+		if !strings.HasPrefix(w, "Check "+id) {
+			t.Errorf("Expected write %d to start with %s, got %q", i, id, w)
+		}
+		for _, other := range checkIDPattern.FindAllString(w, -1) {
+			if other != id {
+				t.Errorf("Expected the output of %s only, got the output of %s in %q", id, other, w)
+			}
+		}
+	}
+	if got := strings.Join(out.writes, ""); got != expected.String() {
+		t.Errorf("Expected the output of a sequential run\n%s\ngot\n%s", expected.String(), got)
+	}
+	if len(result.Checks) != n || len(listened) != n {
+		t.Fatalf("Expected %d checks, got %d recorded and %d listened", n, len(result.Checks), len(listened))
+	}
+	for i, c := range result.Checks {
+		id := fmt.Sprintf("check-%02d", i)
+		if c.ID != id || listened[i] != id {
+			t.Errorf("Expected %s at position %d, got %s recorded and %s listened", id, i, c.ID, listened[i])
+		}
+		// Timed from when its own work started, not from the check before it
+		if start, end := c.interval(); start.IsZero() || end.Before(start) {
+			t.Errorf("Expected %s to last from its start to its end, got %v to %v", id, start, end)
+		}
+	}
+}
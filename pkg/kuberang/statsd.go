@@ -0,0 +1,63 @@
+package kuberang
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"time"
+)
+
+// statsdUnsafe matches the characters that cannot appear in a StatsD metric
+// name or tag
+var statsdUnsafe = regexp.MustCompile("[^A-Za-z0-9_.-]")
+
+// statsdOutcomes names the counter of every check status
+var statsdOutcomes = map[string]string{
+	StatusOK:      "pass",
+	StatusError:   "fail",
+	StatusWarning: "warn",
+	StatusSkipped: "skip",
+	StatusIgnored: "ignored",
+}
+
+// statsdLines returns the metrics of the run: a counter per check outcome
+// and a timer per check duration, tagged with the check ID when tags are
+// supported or with the ID mangled into the metric name otherwise, and a
+// gauge set to 1 when the run succeeded.
+func statsdLines(prefix string, tags bool, result *CheckResult) []string {
+	lines := []string{}
+	metric := func(id, name, value string) {
+		id = statsdUnsafe.ReplaceAllString(id, "_")
+		if tags {
+			lines = append(lines, fmt.Sprintf("%s.check.%s:%s|#check:%s", prefix, name, value, id))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s.check.%s.%s:%s", prefix, id, name, value))
+		}
+	}
+	for _, c := range result.Checks {
+		start, end := c.interval()
+		metric(c.ID, statsdOutcomes[c.Status], "1|c")
+		metric(c.ID, "duration", fmt.Sprintf("%d|ms", end.Sub(start)/time.Millisecond))
+	}
+	success := 0
+	if result.Success {
+		success = 1
+	}
+	return append(lines, fmt.Sprintf("%s.success:%d|g", prefix, success))
+}
+
+// emitStatsD sends the metrics of the run over UDP, one datagram per metric.
+// Delivery is not confirmed, and the first error stops the emission.
+func emitStatsD(addr, prefix string, tags bool, result *CheckResult) error {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return fmt.Errorf("error sending StatsD metrics: %v", err)
+	}
+	defer conn.Close()
+	for _, line := range statsdLines(prefix, tags, result) {
+		if _, err := conn.Write([]byte(line)); err != nil {
+			return fmt.Errorf("error sending StatsD metrics: %v", err)
+		}
+	}
+	return nil
+}
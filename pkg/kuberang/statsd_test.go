@@ -0,0 +1,60 @@
+package kuberang
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStatsDLines(t *testing.T) {
+	start := time.Unix(100, 0)
+	result := &CheckResult{
+		StartTime: start,
+		Checks: []Check{
+			{ID: "kubectl-configured", Status: StatusOK, start: start, time: start.Add(250 * time.Millisecond)},
+			{ID: "pod-ip-from-pod", Status: StatusError, start: start.Add(250 * time.Millisecond), time: start.Add(time.Second)},
+		},
+	}
+	expected := []string{
+		"kuberang.check.pass:1|c|#check:kubectl-configured",
+		"kuberang.check.duration:250|ms|#check:kubectl-configured",
+		"kuberang.check.fail:1|c|#check:pod-ip-from-pod",
+		"kuberang.check.duration:750|ms|#check:pod-ip-from-pod",
+		"kuberang.success:0|g",
+	}
+	if lines := statsdLines("kuberang", true, result); !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Unexpected tagged metrics %q", lines)
+	}
+	result.Success = true
+	expected = []string{
+		"ci.kuberang.check.kubectl-configured.pass:1|c",
+		"ci.kuberang.check.kubectl-configured.duration:250|ms",
+		"ci.kuberang.check.pod-ip-from-pod.fail:1|c",
+		"ci.kuberang.check.pod-ip-from-pod.duration:750|ms",
+		"ci.kuberang.success:1|g",
+	}
+	if lines := statsdLines("ci.kuberang", false, result); !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Unexpected mangled metrics %q", lines)
+	}
+}
+
+func TestEmitStatsD(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer conn.Close()
+	if err := emitStatsD(conn.LocalAddr().String(), "kuberang", false, &CheckResult{Success: true}); err != nil {
+		t.Fatal(err)
+	}
+	b := make([]byte, 512)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(b)
+	if err != nil || string(b[:n]) != "kuberang.success:1|g" {
+		t.Errorf("Unexpected datagram %q (%v)", b[:n], err)
+	}
+	if err := emitStatsD("no-such-host.invalid:8125", "kuberang", false, &CheckResult{}); err == nil {
+		t.Error("Expected an error for an unresolvable host")
+	}
+}
@@ -0,0 +1,85 @@
+package kuberang
+
+import (
+	"fmt"
+	"strings"
+)
+
+// systemNamespace is the namespace holding the cluster's own workloads
+const systemNamespace = "kube-system"
+
+// controlPlaneComponents are the control plane components that run as static
+// pods labelled with their name in self-managed clusters
+var controlPlaneComponents = []string{"kube-apiserver", "kube-controller-manager", "kube-scheduler"}
+
+// checkSystemComponents reports the health of the critical kube-system
+// workloads. Unhealthy components are reported as warnings, and components
+// that cannot be seen, as with managed control planes, are skipped.
+func checkSystemComponents(r *reporter) {
+	if ko := r.kube.run("get", "deployment", "coredns", "--namespace="+systemNamespace, "-o", "json"); ko.Success {
+		ready, desired := ko.DeploymentReadiness()
+		reportSystemComponent(r, "system-coredns", "CoreDNS deployment", ready, desired)
+	} else {
+		r.skipped("system-coredns", "CoreDNS deployment ready", "the deployment is not visible")
+	}
+
+	if ko := r.kube.run("get", "daemonset", "kube-proxy", "--namespace="+systemNamespace, "-o", "json"); ko.Success {
+		ready, desired := ko.DaemonSetReadiness()
+		reportSystemComponent(r, "system-kube-proxy", "kube-proxy daemonset", ready, desired)
+	} else {
+		r.skipped("system-kube-proxy", "kube-proxy daemonset ready", "the daemonset is not visible")
+	}
+
+	for _, component := range controlPlaneComponents {
+		id := "system-" + component
+		ko := r.kube.run("get", "pods", "-l", "component="+component, "--namespace="+systemNamespace, "-o", "json")
+		pods := ko.Pods()
+		if !ko.Success || len(pods) == 0 {
+			r.skipped(id, component+" pods ready", "the pods are not visible, as with managed control planes")
+			continue
+		}
+		var ready int64
+		for _, p := range pods {
+			if p.Ready {
+				ready++
+			}
+		}
+		reportSystemComponent(r, id, component+" pods", ready, int64(len(pods)))
+	}
+
+	ko := r.kube.run("get", "pods", "--namespace="+systemNamespace, "-o", "json")
+	if !ko.Success {
+		r.skipped("system-crashloop", "No kube-system pods in CrashLoopBackOff", "the kube-system pods are not visible")
+		return
+	}
+	if crashing := crashLoopingPods(ko.Pods()); len(crashing) == 0 {
+		r.ok("system-crashloop", "No kube-system pods in CrashLoopBackOff")
+	} else {
+		detail := []string{}
+		for _, p := range crashing {
+			detail = append(detail, fmt.Sprintf("Pod %s on node %s restarted %d times\n", p.Name, p.NodeName, p.Restarts))
+		}
+		r.warn("system-crashloop", "No kube-system pods in CrashLoopBackOff", strings.Join(detail, ""))
+	}
+}
+
+// reportSystemComponent reports the ready and desired counts of a component
+func reportSystemComponent(r *reporter, id, name string, ready, desired int64) {
+	name = fmt.Sprintf("%s ready (%d/%d)", name, ready, desired)
+	if ready >= desired && desired > 0 {
+		r.ok(id, name)
+	} else {
+		r.warn(id, name, fmt.Sprintf("%d of %d desired pods are ready\n", ready, desired))
+	}
+}
+
+// crashLoopingPods returns the pods with a container in CrashLoopBackOff
+func crashLoopingPods(pods []Pod) []Pod {
+	crashing := []Pod{}
+	for _, p := range pods {
+		if p.WaitingReason == "CrashLoopBackOff" {
+			crashing = append(crashing, p)
+		}
+	}
+	return crashing
+}
@@ -0,0 +1,104 @@
+package kuberang
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// Sample kube-system pods: a ready API server, a scheduler that is not ready
+// and a CoreDNS pod crash looping
+const (
+	sampleAPIServerPod = `{"metadata": {"name": "kube-apiserver-cp1", "labels": {"component": "kube-apiserver"}}, "spec": {"nodeName": "cp1"},
+		"status": {"conditions": [{"type": "Ready", "status": "True"}],
+			"containerStatuses": [{"name": "kube-apiserver", "ready": true, "restartCount": 0, "state": {"running": {}}}]}}`
+	sampleSchedulerPod = `{"metadata": {"name": "kube-scheduler-cp1", "labels": {"component": "kube-scheduler"}}, "spec": {"nodeName": "cp1"},
+		"status": {"conditions": [{"type": "Ready", "status": "False"}],
+			"containerStatuses": [{"name": "kube-scheduler", "ready": false, "restartCount": 1, "state": {"running": {}}}]}}`
+	sampleCoreDNSPod = `{"metadata": {"name": "coredns-5d78c9869d-x2v4q", "labels": {"k8s-app": "kube-dns"}}, "spec": {"nodeName": "node1"},
+		"status": {"conditions": [{"type": "Ready", "status": "False"}],
+			"containerStatuses": [{"name": "coredns", "ready": false, "restartCount": 7, "state": {"waiting": {"reason": "CrashLoopBackOff"}}}]}}`
+	sampleSystemPods = `{"items": [` + sampleAPIServerPod + `, ` + sampleSchedulerPod + `, ` + sampleCoreDNSPod + `]}`
+)
+
+func TestCheckSystemComponents(t *testing.T) {
+	defer func(c func(context.Context, []byte, ...string) ([]byte, error)) { kubectlCommand = c }(kubectlCommand)
+	kubectlCommand = func(ctx context.Context, input []byte, args ...string) ([]byte, error) {
+		command := strings.Join(args, " ")
+		switch {
+		case strings.HasPrefix(command, "get deployment coredns "):
+			return []byte(`{"spec": {"replicas": 2}, "status": {"readyReplicas": 1}}`), nil
+		case strings.HasPrefix(command, "get daemonset kube-proxy "):
+			return []byte(`Error from server (NotFound): daemonsets.apps "kube-proxy" not found` + "\n"), errors.New("exit status 1")
+		case strings.HasPrefix(command, "get pods -l component=kube-apiserver "):
+			return []byte(`{"items": [` + sampleAPIServerPod + `]}`), nil
+		case strings.HasPrefix(command, "get pods -l component=kube-scheduler "):
+			return []byte(`{"items": [` + sampleSchedulerPod + `]}`), nil
+		case strings.HasPrefix(command, "get pods -l component="):
+			// Not visible, as with managed control planes
+			return []byte(`{"items": []}`), nil
+		case strings.HasPrefix(command, "get pods "):
+			return []byte(sampleSystemPods), nil
+		}
+		t.Fatalf("Unexpected kubectl %q", args)
+		return nil, nil
+	}
+	r := newReporter(ioutil.Discard, &CheckResult{})
+	checkSystemComponents(r)
+
+	expected := map[string]string{
+		"system-coredns":                 StatusWarning,
+		"system-kube-proxy":              StatusSkipped,
+		"system-kube-apiserver":          StatusOK,
+		"system-kube-controller-manager": StatusSkipped,
+		"system-kube-scheduler":          StatusWarning,
+		"system-crashloop":               StatusWarning,
+	}
+	if len(r.result.Checks) != len(expected) {
+		t.Fatalf("Expected %d checks, got %v", len(expected), r.result.Checks)
+	}
+	for _, c := range r.result.Checks {
+		if c.Status != expected[c.ID] {
+			t.Errorf("Expected %s to be %s, got %+v", c.ID, expected[c.ID], c)
+		}
+	}
+	if c, _ := checkOf(r.result, "system-coredns"); c.Name != "CoreDNS deployment ready (1/2)" {
+		t.Errorf("Expected the ready and desired counts in the name, got %q", c.Name)
+	}
+	if c, _ := checkOf(r.result, "system-crashloop"); c.Detail != "Pod coredns-5d78c9869d-x2v4q on node node1 restarted 7 times\n" {
+		t.Errorf("Unexpected detail %q", c.Detail)
+	}
+}
+
+func TestReportSystemComponent(t *testing.T) {
+	tests := []struct {
+		ready, desired int64
+		status         string
+	}{
+		{2, 2, StatusOK},
+		{3, 2, StatusOK},
+		{1, 2, StatusWarning},
+		// Nothing desired is not healthy either
+		{0, 0, StatusWarning},
+	}
+	for _, test := range tests {
+		r := newReporter(ioutil.Discard, &CheckResult{})
+		reportSystemComponent(r, "system-coredns", "CoreDNS deployment", test.ready, test.desired)
+		if c := r.result.Checks[0]; c.Status != test.status {
+			t.Errorf("Expected %d/%d to be %s, got %+v", test.ready, test.desired, test.status, c)
+		}
+	}
+}
+
+func TestCrashLoopingPods(t *testing.T) {
+	ko := KubeOutput{Success: true, RawOut: []byte(sampleSystemPods)}
+	crashing := crashLoopingPods(ko.Pods())
+	if len(crashing) != 1 || crashing[0].Name != "coredns-5d78c9869d-x2v4q" || crashing[0].Restarts != 7 {
+		t.Errorf("Expected only the CoreDNS pod to be crash looping, got %+v", crashing)
+	}
+	if crashing := crashLoopingPods(nil); len(crashing) != 0 {
+		t.Errorf("Expected no crash looping pods, got %+v", crashing)
+	}
+}
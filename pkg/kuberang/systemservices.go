@@ -0,0 +1,75 @@
+package kuberang
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// systemService is an in-cluster service probed from BusyBox
+type systemService struct {
+	namespace string
+	name      string
+	port      int
+}
+
+// parseSystemService parses a service given as [namespace/]name:port, the
+// namespace defaulting to kube-system
+func parseSystemService(s string) (systemService, error) {
+	svc := systemService{namespace: systemNamespace}
+	i := strings.LastIndex(s, ":")
+	if i < 0 {
+		return svc, fmt.Errorf("Invalid system service %q, expected [namespace/]name:port", s)
+	}
+	port, err := strconv.Atoi(s[i+1:])
+	if err != nil || port < 1 || port > 65535 {
+		return svc, fmt.Errorf("Invalid port in system service %q", s)
+	}
+	svc.port = port
+	svc.name = s[:i]
+	if j := strings.Index(svc.name, "/"); j >= 0 {
+		svc.namespace, svc.name = svc.name[:j], svc.name[j+1:]
+	}
+	if svc.namespace == "" || svc.name == "" {
+		return svc, fmt.Errorf("Invalid system service %q, expected [namespace/]name:port", s)
+	}
+	return svc, nil
+}
+
+// checkSystemServices resolves each configured system service by name and
+// opens a TCP connection to its port from the BusyBox pod. Services that do
+// not exist in the cluster, such as an optional metrics-server, are skipped.
+func checkSystemServices(r *reporter, busyboxPodName string) bool {
+	success := true
+	for _, s := range r.cfg.SystemServices {
+		svc, err := parseSystemService(s)
+		if err != nil {
+			// Rejected by validateConfig
+			continue
+		}
+		fqdn := svc.name + "." + svc.namespace + ".svc." + r.cfg.ClusterDomain
+		name := fmt.Sprintf("Reached system service %s:%d from BusyBox", fqdn, svc.port)
+		ko := r.kube.run("get", "service", svc.name, "--namespace="+svc.namespace, "-o", "json")
+		if !ko.Success {
+			r.skipped("system-service-from-pod", name, "the service is not present")
+			continue
+		}
+		address := ko.ServiceCluserIP()
+		if !r.cfg.SkipDNSTests {
+			address = fqdn
+		}
+		var probe KubeOutput
+		attempt := retryAttempts(r.ctx, 3, func() bool {
+			probe = r.kube.run("exec", busyboxPodName, "--", "sh", "-c",
+				fmt.Sprintf("nc -w %s %s %d </dev/null", wgetTimeoutSeconds, address, svc.port))
+			return probe.Success
+		})
+		if attempt == 0 {
+			r.err("system-service-from-pod", name, probe.CombinedOut)
+			success = false
+			continue
+		}
+		r.okAfter("system-service-from-pod", name, attempt, 3)
+	}
+	return success
+}
@@ -0,0 +1,28 @@
+package kuberang
+
+import "testing"
+
+func TestParseSystemService(t *testing.T) {
+	tests := []struct {
+		in    string
+		valid bool
+		want  systemService
+	}{
+		{"kube-dns:53", true, systemService{"kube-system", "kube-dns", 53}},
+		{"monitoring/prometheus:9090", true, systemService{"monitoring", "prometheus", 9090}},
+		{"kube-dns", false, systemService{}},
+		{"kube-dns:dns", false, systemService{}},
+		{"kube-dns:0", false, systemService{}},
+		{"/kube-dns:53", false, systemService{}},
+	}
+	for _, test := range tests {
+		got, err := parseSystemService(test.in)
+		if (err == nil) != test.valid {
+			t.Errorf("parseSystemService(%q) returned error %v", test.in, err)
+			continue
+		}
+		if test.valid && got != test.want {
+			t.Errorf("parseSystemService(%q) = %+v, want %+v", test.in, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package kuberang
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// checkTargetPorts warns when a port of the nginx services targets a port
+// nginx does not listen on, as happens with an nginx image listening on
+// another port than 80. The target port of every service port is probed on
+// an nginx pod from the BusyBox pod before the connectivity checks, which
+// would otherwise fail without saying why.
+func checkTargetPorts(r *reporter, w *workloads, busyboxPodName string, pods []Pod) {
+	name := "Nginx services target a port the Nginx pods listen on"
+	var pod Pod
+	for _, p := range pods {
+		if p.Ready && p.IP != "" {
+			pod = p
+			break
+		}
+	}
+	if pod.IP == "" {
+		return
+	}
+	services := []string{w.ngService}
+	for _, s := range []string{w.ngNamedService, w.ngMultiPortService} {
+		if s != "" {
+			services = append(services, s)
+		}
+	}
+	problems := []string{}
+	// refused holds the outcome of the probe of every target port
+	refused := map[int64]bool{}
+	for _, service := range services {
+		ko := r.kube.getService(service)
+		if !ko.Success {
+			r.warn("nginx-target-port", name, ko.CombinedOut)
+			return
+		}
+		for _, p := range ko.ServicePorts() {
+			port, ok := resolveTargetPort(p.TargetPort, pod.Ports)
+			if !ok {
+				problems = append(problems, fmt.Sprintf("Port %d of service %s targets port %s, which pod %s does not declare (declared: %s)", p.Port, service, p.TargetPort, pod.Name, formatPorts(pod.Ports)))
+				continue
+			}
+			if _, probed := refused[port]; !probed {
+				ko := r.kube.run("exec", busyboxPodName, "--", "wget", "-T", wgetTimeoutSeconds, "-qO-", fmt.Sprintf("http://%s:%d/", pod.IP, port))
+				refused[port] = connectionRefused(ko.CombinedOut)
+			}
+			if refused[port] {
+				problems = append(problems, fmt.Sprintf("Port %d of service %s targets port %d, on which pod %s at %s refused the connection, make sure the nginx image listens on it", p.Port, service, port, pod.Name, pod.IP))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		r.warn("nginx-target-port", name, strings.Join(problems, "\n")+"\n")
+		return
+	}
+	r.ok("nginx-target-port", name)
+}
+
+// resolveTargetPort returns the port number a target port, given as a
+// number or as a port name, refers to. A port name is looked up among the
+// declared ports, and false is returned when none of them has it.
+func resolveTargetPort(targetPort string, declared []ContainerPort) (int64, bool) {
+	if number, err := strconv.ParseInt(targetPort, 10, 64); err == nil {
+		return number, true
+	}
+	for _, p := range declared {
+		if p.Name == targetPort {
+			return p.ContainerPort, true
+		}
+	}
+	return 0, false
+}
+
+// connectionRefused returns true if the wget output tells that nothing
+// listens on the port. Any HTTP response, an error status included, means
+// that something does.
+func connectionRefused(out string) bool {
+	return strings.Contains(out, "Connection refused")
+}
+
+// formatPorts lists the declared ports, with their name when they have one
+func formatPorts(ports []ContainerPort) string {
+	if len(ports) == 0 {
+		return "none"
+	}
+	formatted := make([]string, len(ports))
+	for i, p := range ports {
+		formatted[i] = strconv.FormatInt(p.ContainerPort, 10)
+		if p.Name != "" {
+			formatted[i] = p.Name + "/" + formatted[i]
+		}
+	}
+	return strings.Join(formatted, ", ")
+}
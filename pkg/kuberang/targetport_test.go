@@ -0,0 +1,74 @@
+package kuberang
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestResolveTargetPort(t *testing.T) {
+	declared := []ContainerPort{{Name: "http", ContainerPort: 8080}}
+	tests := []struct {
+		targetPort string
+		port       int64
+		resolved   bool
+	}{
+		{"80", 80, true},
+		{"http", 8080, true},
+		{"https", 0, false},
+	}
+	for _, test := range tests {
+		if port, ok := resolveTargetPort(test.targetPort, declared); port != test.port || ok != test.resolved {
+			t.Errorf("Expected target port %s to resolve to %d (%v), got %d (%v)", test.targetPort, test.port, test.resolved, port, ok)
+		}
+	}
+	if _, ok := resolveTargetPort("http", nil); ok {
+		t.Errorf("Expected a port name not to resolve without declared ports")
+	}
+}
+
+func TestCheckTargetPorts(t *testing.T) {
+	defer func(c func(context.Context, []byte, ...string) ([]byte, error)) { kubectlCommand = c }(kubectlCommand)
+	// listening is the port nginx listens on
+	var listening string
+	probes := []string{}
+	kubectlCommand = func(ctx context.Context, input []byte, args ...string) ([]byte, error) {
+		if args[0] == "get" {
+			return []byte(`{"spec": {"ports": [{"port": 80, "targetPort": 80}]}}`), nil
+		}
+		url := args[len(args)-1]
+		probes = append(probes, url)
+		if !strings.Contains(url, ":"+listening+"/") {
+			return []byte("wget: can't connect to remote host (172.16.0.5): Connection refused\n"), errors.New("exit status 1")
+		}
+		return []byte("<html></html>\n"), nil
+	}
+	pods := []Pod{
+		{Name: "nginx-a", IP: "172.16.0.4"},
+		{Name: "nginx-b", IP: "172.16.0.5", Ready: true},
+	}
+
+	listening = "80"
+	r := newReporter(ioutil.Discard, &CheckResult{})
+	checkTargetPorts(r, newWorkloads(r.cfg, 1), "busybox", pods)
+	if len(probes) != 1 || probes[0] != "http://172.16.0.5:80/" || r.result.Checks[0].Status != StatusOK {
+		t.Errorf("Expected the target port of the first ready pod to answer, got %q and %v", probes, r.result.Checks)
+	}
+
+	listening, probes = "8080", nil
+	r = newReporter(ioutil.Discard, &CheckResult{})
+	checkTargetPorts(r, newWorkloads(r.cfg, 1), "busybox", pods)
+	if c := r.result.Checks[0]; c.Status != StatusWarning || !strings.Contains(c.Detail, "refused the connection") {
+		t.Errorf("Expected a warning when nginx listens on another port, got %+v", c)
+	}
+}
+
+func TestServicePorts(t *testing.T) {
+	ko := KubeOutput{RawOut: []byte(`{"spec": {"ports": [{"port": 80, "targetPort": 8080}, {"port": 81, "targetPort": "http"}]}}`)}
+	ports := ko.ServicePorts()
+	if len(ports) != 2 || ports[0].TargetPort != "8080" || ports[1].TargetPort != "http" {
+		t.Errorf("Unexpected ports: %+v", ports)
+	}
+}
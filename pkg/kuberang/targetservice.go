@@ -0,0 +1,139 @@
+package kuberang
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+// targetNamespace returns the namespace of the service given with
+// --target-service
+func targetNamespace(cfg *config.Config) string {
+	if cfg.TargetNamespace != "" {
+		return cfg.TargetNamespace
+	}
+	return namespace(cfg)
+}
+
+// runKubectlInTarget runs kubectl against the namespace of the target
+// service. The last --namespace flag wins, overriding the configured one.
+func runKubectlInTarget(k *kubectl, args ...string) KubeOutput {
+	return k.run(append(args, "--namespace="+targetNamespace(k.cfg))...)
+}
+
+// precheckTargetService verifies that the service given with
+// --target-service exists
+func precheckTargetService(r *reporter) bool {
+	name := "Target service " + targetNamespace(r.cfg) + "/" + r.cfg.TargetService + " exists"
+	if ko := runKubectlInTarget(r.kube, "get", "service", r.cfg.TargetService, "-o", "json"); !ko.Success {
+		r.err("target-service-exists", name, ko.CombinedOut)
+		return false
+	}
+	r.ok("target-service-exists", name)
+	return true
+}
+
+// runTargetServiceChecks deploys BusyBox alone and checks the service given
+// with --target-service from it: its service IP, its DNS name and every one
+// of its endpoints
+func runTargetServiceChecks(r *reporter, w *workloads) error {
+	if !deployBusybox(r, w) || !waitForBusybox(r, w) {
+		return errors.New("Failed to deploy test workloads")
+	}
+	ko := r.kube.run("get", "pods", "-l", w.labels("kuberang-busybox"), "-o", "json")
+	busyboxPodName := ko.FirstPodName()
+	if busyboxPodName == "" {
+		r.err("busybox-pod-name", "Grab BusyBox pod name", ko.CombinedOut)
+		return errors.New("Failed to get required information from cluster")
+	}
+	r.ok("busybox-pod-name", "Grab BusyBox pod name")
+
+	service := targetNamespace(r.cfg) + "/" + r.cfg.TargetService
+	ko = runKubectlInTarget(r.kube, "get", "service", r.cfg.TargetService, "-o", "json")
+	serviceIP := ko.ServiceCluserIP()
+	ports := ko.ServicePorts()
+	if len(ports) == 0 || serviceIP == "" || serviceIP == "None" {
+		r.err("target-service-ip", "Grab target service ip address", "Service "+service+" has no cluster IP or no port\n"+ko.CombinedOut)
+		return errors.New("Failed to get required information from cluster")
+	}
+	r.ok("target-service-ip", "Grab target service ip address")
+	r.result.Topology.Service = TopologyService{Name: service, ClusterIP: serviceIP, Endpoints: []string{}}
+
+	success := true
+	port := fmt.Sprintf(":%d", ports[0].Port)
+	name := "Accessed target service at " + serviceIP + port + " from BusyBox"
+	if reached, out := reachedFromPod(r, busyboxPodName, serviceIP+port); reached {
+		r.ok("service-ip-from-pod", name)
+	} else {
+		r.err("service-ip-from-pod", name, out)
+		success = false
+	}
+
+	fqdn := fmt.Sprintf("%s.%s.svc.%s", r.cfg.TargetService, targetNamespace(r.cfg), r.cfg.ClusterDomain)
+	name = "Accessed target service via DNS " + fqdn + " from BusyBox"
+	if r.cfg.SkipDNSTests {
+		r.skipped("service-dns-from-pod", name, "--skip-dns-tests")
+	} else if reached, out := reachedFromPod(r, busyboxPodName, fqdn+port); reached {
+		r.ok("service-dns-from-pod", name)
+	} else {
+		r.err("service-dns-from-pod", name, out)
+		success = false
+	}
+
+	eko := runKubectlInTarget(r.kube, "get", "endpoints", r.cfg.TargetService, "-o", "json")
+	endpoints := eko.Endpoints()
+	r.result.Topology.Service.Endpoints = endpoints
+	if len(endpoints) == 0 {
+		r.err("target-service-endpoints", "Target service has ready endpoints", "Service "+service+" has no ready endpoints, check its selector and the readiness of its pods\n"+eko.CombinedOut)
+		success = false
+	} else {
+		r.ok("target-service-endpoints", "Target service has ready endpoints")
+	}
+	for _, endpoint := range endpoints {
+		name := "Accessed target service endpoint at " + endpoint + " from BusyBox"
+		if reached, out := reachedFromPod(r, busyboxPodName, endpoint); reached {
+			r.ok("endpoint-from-pod", name)
+		} else {
+			r.err("endpoint-from-pod", name, out)
+			success = false
+		}
+	}
+
+	if !success {
+		return errors.New("One or more required steps failed")
+	}
+	return nil
+}
+
+// reachedFromPod returns true if the address accepts HTTP requests from the
+// pod, along with the output of the last attempt. Services of users need not
+// serve anything at /, so HTTP errors count as reached.
+func reachedFromPod(r *reporter, pod, address string) (bool, string) {
+	var out string
+	reached := retry(r.ctx, 3, func() bool {
+		ko := r.kube.run("exec", pod, "--", "wget", "-T", wgetTimeoutSeconds, "-qO", "/dev/null", "http://"+address)
+		out = ko.CombinedOut
+		return ko.Success || strings.Contains(ko.CombinedOut, "server returned error")
+	})
+	return reached, out
+}
+
+// waitForBusybox waits for the BusyBox deployment to be available
+func waitForBusybox(r *reporter, w *workloads) bool {
+	name := "BusyBox deployment completed successfully within timeout"
+	start := time.Now()
+	for time.Since(start) < deploymentTimeout {
+		if busybox, _ := deploymentReplicas(r.kube, w); busybox == 1 {
+			r.ok("deployments-ready", name)
+			return true
+		}
+		if !sleep(r.ctx, 1*time.Second) {
+			break
+		}
+	}
+	r.err("deployments-ready", name, "")
+	return false
+}
@@ -0,0 +1,107 @@
+package kuberang
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// fakeTargetService simulates a cluster running BusyBox alongside the target
+// service, answering get service with the given JSON, or NotFound when
+// empty. Requests to the unreachable address time out.
+func fakeTargetService(service, unreachable string) func(context.Context, []byte, ...string) ([]byte, error) {
+	return func(ctx context.Context, input []byte, args ...string) ([]byte, error) {
+		command := strings.Join(args, " ")
+		switch {
+		case args[0] == "run":
+			return []byte("{}"), nil
+		case strings.HasPrefix(command, "get deployment "):
+			return []byte(`{"spec": {"replicas": 1}, "status": {"replicas": 1, "updatedReplicas": 1, "availableReplicas": 1, "readyReplicas": 1}}`), nil
+		case strings.HasPrefix(command, "get pods -l app=kuberang-busybox,"):
+			return []byte(`{"items": [{"metadata": {"name": "kuberang-busybox-a"}, "status": {"podIP": "10.0.0.11"}}]}`), nil
+		case strings.HasPrefix(command, "get service my-app "):
+			if service == "" {
+				return []byte(`Error from server (NotFound): services "my-app" not found` + "\n"), errors.New("exit status 1")
+			}
+			return []byte(service), nil
+		case strings.HasPrefix(command, "get endpoints my-app "):
+			return []byte(`{"subsets": [{"addresses": [{"ip": "10.0.0.20"}, {"ip": "10.0.0.21"}], "ports": [{"port": 8080}]}]}`), nil
+		case args[0] == "exec":
+			if args[len(args)-1] == "http://"+unreachable {
+				return []byte("wget: download timed out\n"), errors.New("exit status 1")
+			}
+			return []byte("wget: server returned error: HTTP/1.1 404 Not Found\n"), errors.New("exit status 1")
+		}
+		return []byte(`{"items": []}`), nil
+	}
+}
+
+func newTargetServiceReporter() *reporter {
+	r := newReporter(ioutil.Discard, &CheckResult{Topology: &Topology{}})
+	r.cfg.TargetService = "my-app"
+	r.cfg.TargetNamespace = "apps"
+	r.cfg.ClusterDomain = "cluster.local"
+	r.cfg.SkipDNSTests = true
+	// Every request is attempted once
+	r.ctx = withRetrySettings(context.Background(), retrySettings{budget: &retryBudget{}})
+	return r
+}
+
+// checkOf returns the first check with the ID
+func checkOf(result *CheckResult, id string) (Check, bool) {
+	for _, c := range result.Checks {
+		if c.ID == id {
+			return c, true
+		}
+	}
+	return Check{}, false
+}
+
+func TestRunTargetServiceChecks(t *testing.T) {
+	defer func(c func(context.Context, []byte, ...string) ([]byte, error)) { kubectlCommand = c }(kubectlCommand)
+	service := `{"spec": {"clusterIP": "10.96.0.30", "ports": [{"port": 80, "targetPort": 8080}]}}`
+
+	kubectlCommand = fakeTargetService(service, "")
+	r := newTargetServiceReporter()
+	if err := runTargetServiceChecks(r, newWorkloads(r.cfg, 1)); err != nil {
+		t.Fatalf("Unexpected error %v, checks %v", err, r.result.Checks)
+	}
+	if eps := r.result.Topology.Service.Endpoints; len(eps) != 2 || r.result.Topology.Service.Name != "apps/my-app" {
+		t.Errorf("Unexpected topology %+v", r.result.Topology.Service)
+	}
+
+	kubectlCommand = fakeTargetService("", "")
+	r = newTargetServiceReporter()
+	if err := runTargetServiceChecks(r, newWorkloads(r.cfg, 1)); err == nil {
+		t.Error("Expected a missing service to fail the checks")
+	}
+	if c, ok := checkOf(r.result, "target-service-ip"); !ok || c.Status != StatusError || !strings.Contains(c.Detail, `services "my-app" not found`) {
+		t.Errorf("Expected the NotFound error in the detail, got %+v", c)
+	}
+
+	kubectlCommand = fakeTargetService(`{"spec": {"clusterIP": "None", "ports": [{"port": 80}]}}`, "")
+	r = newTargetServiceReporter()
+	if err := runTargetServiceChecks(r, newWorkloads(r.cfg, 1)); err == nil {
+		t.Error("Expected a headless service to fail the checks")
+	}
+	if c, ok := checkOf(r.result, "target-service-ip"); !ok || c.Status != StatusError || !strings.Contains(c.Detail, "has no cluster IP") {
+		t.Errorf("Expected a headless service to be called out, got %+v", c)
+	}
+
+	kubectlCommand = fakeTargetService(service, "10.0.0.21:8080")
+	r = newTargetServiceReporter()
+	if err := runTargetServiceChecks(r, newWorkloads(r.cfg, 1)); err == nil {
+		t.Error("Expected an unreachable endpoint to fail the checks")
+	}
+	failed := []Check{}
+	for _, c := range r.result.Checks {
+		if c.ID == "endpoint-from-pod" && c.Status == StatusError {
+			failed = append(failed, c)
+		}
+	}
+	if len(failed) != 1 || !strings.Contains(failed[0].Name, "10.0.0.21:8080") || !strings.Contains(failed[0].Detail, "download timed out") {
+		t.Errorf("Expected the unreachable endpoint to fail with the wget output, got %+v", failed)
+	}
+}
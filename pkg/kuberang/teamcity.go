@@ -0,0 +1,95 @@
+package kuberang
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OutputTeamCity reports every check as a test through TeamCity service
+// messages, as the checks run
+const OutputTeamCity = "teamcity"
+
+// teamcitySuite is the name of the test suite wrapping the checks
+const teamcitySuite = "kuberang"
+
+// teamcityEscaper escapes the values of service messages
+var teamcityEscaper = strings.NewReplacer(
+	"|", "||",
+	"'", "|'",
+	"\n", "|n",
+	"\r", "|r",
+	"[", "|[",
+	"]", "|]",
+	"\u0085", "|x",
+	"\u2028", "|l",
+	"\u2029", "|p",
+)
+
+// teamcityMessage formats a service message with the given attributes,
+// given as name and value pairs
+func teamcityMessage(name string, attributes ...string) string {
+	msg := "##teamcity[" + name
+	for i := 0; i+1 < len(attributes); i += 2 {
+		msg += fmt.Sprintf(" %s='%s'", attributes[i], teamcityEscaper.Replace(attributes[i+1]))
+	}
+	return msg + "]"
+}
+
+// teamcityReporter prints the service messages of every recorded check
+type teamcityReporter struct {
+	mu      sync.Mutex
+	out     io.Writer
+	started bool
+}
+
+func newTeamCityReporter(out io.Writer) *teamcityReporter {
+	return &teamcityReporter{out: out}
+}
+
+// start opens the test suite unless already open
+func (t *teamcityReporter) start() {
+	if !t.started {
+		fmt.Fprintln(t.out, teamcityMessage("testSuiteStarted", "name", teamcitySuite))
+		t.started = true
+	}
+}
+
+// check reports the check as a test lasting as long as the check.
+// Failed checks carry their detail, skipped checks are ignored tests, and
+// the detail of warned and ignored checks goes to the test output.
+func (t *teamcityReporter) check(c Check) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.start()
+	start, end := c.interval()
+	duration := end.Sub(start)
+
+	fmt.Fprintln(t.out, teamcityMessage("testStarted", "name", c.Name, "captureStandardOutput", "false"))
+	switch c.Status {
+	case StatusError:
+		message := firstLine(c.Detail)
+		if message == "" {
+			message = c.ID + " failed"
+		}
+		fmt.Fprintln(t.out, teamcityMessage("testFailed", "name", c.Name, "message", message, "details", c.Detail))
+	case StatusSkipped:
+		fmt.Fprintln(t.out, teamcityMessage("testIgnored", "name", c.Name, "message", c.Detail))
+	case StatusWarning, StatusIgnored:
+		if c.Detail != "" {
+			fmt.Fprintln(t.out, teamcityMessage("testStdOut", "name", c.Name, "out", c.Status+": "+c.Detail))
+		}
+	}
+	fmt.Fprintln(t.out, teamcityMessage("testFinished", "name", c.Name, "duration", fmt.Sprintf("%d", duration/time.Millisecond)))
+}
+
+// finish closes the test suite
+func (t *teamcityReporter) finish(out io.Writer, result *CheckResult) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.start()
+	_, err := fmt.Fprintln(t.out, teamcityMessage("testSuiteFinished", "name", teamcitySuite))
+	return err
+}
@@ -0,0 +1,53 @@
+package kuberang
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTeamCityEscaping(t *testing.T) {
+	tests := []struct {
+		value, expected string
+	}{
+		{"plain", "plain"},
+		{"a|b", "a||b"},
+		{"it's", "it|'s"},
+		{"line\nnext\r", "line|nnext|r"},
+		{"[OK] done", "|[OK|] done"},
+		{"||[]", "|||||[|]"},
+		{"next\u0085line\u2028sep\u2029par", "next|xline|lsep|ppar"},
+	}
+	for _, test := range tests {
+		if msg := teamcityMessage("testStarted", "name", test.value); msg != "##teamcity[testStarted name='"+test.expected+"']" {
+			t.Errorf("Unexpected message for %q: %s", test.value, msg)
+		}
+	}
+}
+
+func TestTeamCityReporter(t *testing.T) {
+	var out bytes.Buffer
+	start := time.Unix(100, 0)
+	tc := newTeamCityReporter(&out)
+	tc.check(Check{ID: "service-ip-from-pod", Name: "Accessed Nginx service", Status: StatusOK, start: start, time: start.Add(250 * time.Millisecond)})
+	tc.check(Check{ID: "pod-ip-from-pod", Name: "Accessed Nginx pod", Status: StatusError, Detail: "wget: can't connect to remote host\n", start: start.Add(250 * time.Millisecond), time: start.Add(time.Second)})
+	tc.check(Check{ID: "internet-from-pod", Name: "Accessed Google.com", Status: StatusSkipped, Detail: "--offline", start: start.Add(time.Second), time: start.Add(time.Second)})
+	tc.finish(&out, &CheckResult{})
+
+	expected := []string{
+		"##teamcity[testSuiteStarted name='kuberang']",
+		"##teamcity[testStarted name='Accessed Nginx service' captureStandardOutput='false']",
+		"##teamcity[testFinished name='Accessed Nginx service' duration='250']",
+		"##teamcity[testStarted name='Accessed Nginx pod' captureStandardOutput='false']",
+		"##teamcity[testFailed name='Accessed Nginx pod' message='wget: can|'t connect to remote host' details='wget: can|'t connect to remote host|n']",
+		"##teamcity[testFinished name='Accessed Nginx pod' duration='750']",
+		"##teamcity[testStarted name='Accessed Google.com' captureStandardOutput='false']",
+		"##teamcity[testIgnored name='Accessed Google.com' message='--offline']",
+		"##teamcity[testFinished name='Accessed Google.com' duration='0']",
+		"##teamcity[testSuiteFinished name='kuberang']",
+	}
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected service messages:\n%s", out.String())
+	}
+}
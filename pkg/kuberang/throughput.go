@@ -0,0 +1,106 @@
+package kuberang
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+// throughputPayloadPath is the path under which nginx serves the payload
+const throughputPayloadPath = "/kuberang/payload"
+
+// busyboxRealTime matches the elapsed time printed by the busybox time applet
+var busyboxRealTime = regexp.MustCompile(`real\s+(\d+)m\s*([0-9.]+)s`)
+
+// throughputPayloadSpec adds an init container generating the throughput
+// payload to the given nginx pod spec. The payload lives in an emptyDir
+// volume served by nginx, so it goes away along with the pod.
+func throughputPayloadSpec(cfg *config.Config, podSpec, container map[string]interface{}, busyboxImage string) {
+	appendToList(podSpec, "volumes", map[string]interface{}{
+		"name":     "kuberang-payload",
+		"emptyDir": map[string]interface{}{},
+	})
+	appendToList(podSpec, "initContainers", map[string]interface{}{
+		"name":            "generate-payload",
+		"image":           busyboxImage,
+		"imagePullPolicy": "IfNotPresent",
+		"command":         []string{"dd", "if=/dev/urandom", "of=/payload/payload", "bs=1048576", fmt.Sprintf("count=%d", cfg.ThroughputSizeMB)},
+		"volumeMounts": []interface{}{
+			map[string]interface{}{"name": "kuberang-payload", "mountPath": "/payload"},
+		},
+	})
+	appendToList(container, "volumeMounts", map[string]interface{}{
+		"name":      "kuberang-payload",
+		"mountPath": "/usr/share/nginx/html/kuberang",
+		"readOnly":  true,
+	})
+}
+
+// checkThroughput downloads the payload from every nginx pod and reports the
+// throughput of each download along with a summary
+func checkThroughput(r *reporter, busyboxPodName string, podIPs []string) bool {
+	success := true
+	rates := []float64{}
+	for _, podIP := range podIPs {
+		name := fmt.Sprintf("Downloaded %dMB from Nginx pod at %s", r.cfg.ThroughputSizeMB, podIP)
+		start := time.Now()
+		ko := r.kube.run("exec", busyboxPodName, "--", "time", "wget", "-T", wgetTimeoutSeconds, "-qO", "/dev/null", "http://"+podIP+throughputPayloadPath)
+		elapsed, ok := parseBusyboxTime(ko.CombinedOut)
+		if !ok {
+			elapsed = time.Since(start)
+		}
+		r.onPod(podIP)
+		if !ko.Success {
+			r.err("throughput", name, ko.CombinedOut)
+			success = false
+			continue
+		}
+		rate := float64(r.cfg.ThroughputSizeMB) / elapsed.Seconds()
+		rates = append(rates, rate)
+		r.ok("throughput", fmt.Sprintf("%s at %.1f MB/s", name, rate))
+	}
+	if len(rates) > 0 {
+		min, avg, max := summarizeRates(rates)
+		r.ok("throughput-summary", fmt.Sprintf("Pod network throughput: min %.1f MB/s, avg %.1f MB/s, max %.1f MB/s", min, avg, max))
+	}
+	return success
+}
+
+// parseBusyboxTime returns the elapsed time printed by the busybox time
+// applet, e.g. "real\t0m 1.25s"
+func parseBusyboxTime(out string) (time.Duration, bool) {
+	m := busyboxRealTime.FindStringSubmatch(out)
+	if m == nil {
+		return 0, false
+	}
+	minutes, _ := strconv.Atoi(m[1])
+	seconds, err := strconv.ParseFloat(m[2], 64)
+	if err != nil {
+		return 0, false
+	}
+	elapsed := time.Duration(minutes)*time.Minute + time.Duration(seconds*float64(time.Second))
+	if elapsed <= 0 {
+		// Keep tiny downloads from yielding an infinite rate
+		elapsed = time.Millisecond
+	}
+	return elapsed, true
+}
+
+// summarizeRates returns the minimum, average and maximum of the rates
+func summarizeRates(rates []float64) (min, avg, max float64) {
+	min, max = rates[0], rates[0]
+	var sum float64
+	for _, r := range rates {
+		sum += r
+		if r < min {
+			min = r
+		}
+		if r > max {
+			max = r
+		}
+	}
+	return min, sum / float64(len(rates)), max
+}
@@ -0,0 +1,24 @@
+package kuberang
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseBusyboxTime(t *testing.T) {
+	out := "real\t1m 2.50s\nuser\t0m 0.10s\nsys\t0m 0.45s\n"
+	elapsed, ok := parseBusyboxTime(out)
+	if !ok || elapsed != 62500*time.Millisecond {
+		t.Errorf("Expected 1m2.5s, got %v (%v)", elapsed, ok)
+	}
+	if _, ok := parseBusyboxTime("wget: server returned error: HTTP/1.1 404 Not Found"); ok {
+		t.Errorf("Expected output without timing to be rejected")
+	}
+}
+
+func TestSummarizeRates(t *testing.T) {
+	min, avg, max := summarizeRates([]float64{30, 10, 20})
+	if min != 10 || avg != 20 || max != 30 {
+		t.Errorf("Expected 10/20/30, got %v/%v/%v", min, avg, max)
+	}
+}
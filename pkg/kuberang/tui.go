@@ -0,0 +1,99 @@
+package kuberang
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apprenda/kuberang/pkg/util"
+)
+
+// spinnerFrames animate the line of the check in progress
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
+// tui is a live terminal view of the run: checks are listed as they
+// complete, under a status line animated while the next check runs
+type tui struct {
+	out     io.Writer
+	mu      sync.Mutex
+	start   time.Time
+	done    int
+	failed  int
+	frame   int
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// newTUI starts the view, animating the status line until closed
+func newTUI(out io.Writer) *tui {
+	t := &tui{out: out, start: time.Now(), stop: make(chan struct{}), stopped: make(chan struct{})}
+	go func() {
+		defer close(t.stopped)
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-t.stop:
+				return
+			case <-ticker.C:
+				t.mu.Lock()
+				t.frame++
+				t.drawStatus()
+				t.mu.Unlock()
+			}
+		}
+	}()
+	return t
+}
+
+// check lists a completed check above the status line
+func (t *tui) check(c Check) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.clearStatus()
+	t.done++
+	switch c.Status {
+	case StatusOK:
+		util.PrintColor(t.out, util.Green, "✓")
+	case StatusError:
+		t.failed++
+		util.PrintColor(t.out, util.Red, "✗")
+	case StatusWarning, StatusIgnored:
+		util.PrintColor(t.out, util.Orange, "!")
+	case StatusSkipped:
+		util.PrintColor(t.out, util.Blue, "-")
+	}
+	fmt.Fprintf(t.out, " %s", c.Name)
+	if c.Attempts > 1 {
+		fmt.Fprintf(t.out, " (attempt %d/%d)", c.Attempts, c.MaxAttempts)
+	}
+	fmt.Fprintln(t.out)
+	if c.Status == StatusError && c.Detail != "" {
+		for _, line := range strings.Split(strings.TrimRight(c.Detail, "\n"), "\n") {
+			fmt.Fprintf(t.out, "    %s\n", line)
+		}
+	}
+	t.drawStatus()
+}
+
+// close stops the animation and removes the status line
+func (t *tui) close() {
+	close(t.stop)
+	<-t.stopped
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.clearStatus()
+}
+
+func (t *tui) drawStatus() {
+	t.clearStatus()
+	fmt.Fprintf(t.out, "%s Running checks: %d done, %d failed (%ds)",
+		spinnerFrames[t.frame%len(spinnerFrames)], t.done, t.failed, int(time.Since(t.start).Seconds()))
+}
+
+// clearStatus erases the status line, leaving the cursor at its start
+func (t *tui) clearStatus() {
+	fmt.Fprint(t.out, "\r\033[K")
+}
@@ -0,0 +1,33 @@
+package kuberang
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTUI(t *testing.T) {
+	out := &bytes.Buffer{}
+	view := newTUI(out)
+	r := newReporter(&bytes.Buffer{}, &CheckResult{})
+	r.listener = view.check
+	r.ok("kubectl-configured", "Kubectl configured on this node")
+	r.okAfter("service-ip-from-pod", "Accessed Nginx service", 2, 3)
+	r.err("pod-ip-from-pod", "Accessed Nginx pod", "wget: download timed out\n")
+	view.close()
+
+	s := out.String()
+	for _, want := range []string{
+		"Kubectl configured on this node\n",
+		"Accessed Nginx service (attempt 2/3)\n",
+		"Accessed Nginx pod\n    wget: download timed out\n",
+		"3 done, 1 failed",
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("Expected the view to contain %q, got:\n%q", want, s)
+		}
+	}
+	if !strings.HasSuffix(s, "\r\033[K") {
+		t.Errorf("Expected the status line to be cleared on close, got:\n%q", s)
+	}
+}
@@ -0,0 +1,58 @@
+package kuberang
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// cleanupTarget is an object, or set of objects, expected to be gone once
+// the test workloads are powered down
+type cleanupTarget struct {
+	id   string
+	name string
+	args []string
+}
+
+// cleanupTargets returns the nginx service and its endpoints, unless a
+// service of the user was checked instead, and the pods of the run
+func cleanupTargets(w *workloads) []cleanupTarget {
+	targets := []cleanupTarget{}
+	if w.cfg.TargetService == "" {
+		targets = append(targets,
+			cleanupTarget{"cleanup-verify-service", "Nginx service " + w.ngService + " is gone", []string{"get", "service", w.ngService, "-o", "name", "--ignore-not-found"}},
+			cleanupTarget{"cleanup-verify-endpoints", "Endpoints of Nginx service " + w.ngService + " are gone", []string{"get", "endpoints", w.ngService, "-o", "name", "--ignore-not-found"}},
+		)
+	}
+	return append(targets, cleanupTarget{"cleanup-verify-pods", "Pods of the run are gone", []string{"get", "pods", "-l", fmt.Sprintf("kuberang/testid=%d", w.testID), "-o", "name"}})
+}
+
+// verifyCleanup confirms that the service, its endpoints and the pods of
+// the run disappear within --verify-cleanup-timeout of being powered down,
+// catching endpoints controller lag and garbage collection leaks. Whatever
+// lingers is listed.
+func verifyCleanup(r *reporter, w *workloads) bool {
+	deadline := time.Now().Add(r.cfg.VerifyCleanupTimeout)
+	success := true
+	for _, t := range cleanupTargets(w) {
+		start := time.Now()
+		for {
+			ko := r.kube.run(t.args...)
+			lingering := strings.TrimSpace(ko.CombinedOut)
+			if ko.Success && lingering == "" {
+				r.ok(t.id, fmt.Sprintf("%s after %v", t.name, time.Since(start)))
+				break
+			}
+			if time.Now().After(deadline) || r.ctx.Err() != nil {
+				if ko.Success {
+					lingering = "Still present after " + r.cfg.VerifyCleanupTimeout.String() + ":\n" + lingering
+				}
+				r.err(t.id, t.name, lingering+"\n")
+				success = false
+				break
+			}
+			sleep(r.ctx, 2*time.Second)
+		}
+	}
+	return success
+}
@@ -0,0 +1,21 @@
+package kuberang
+
+import (
+	"testing"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+func TestCleanupTargets(t *testing.T) {
+	cfg := &config.Config{}
+	w := newWorkloads(cfg, 42)
+	cfg.TargetService = ""
+	targets := cleanupTargets(w)
+	if len(targets) != 3 || targets[1].id != "cleanup-verify-endpoints" || targets[2].args[3] != "kuberang/testid=42" {
+		t.Errorf("Unexpected targets %+v", targets)
+	}
+	cfg.TargetService = "my-service"
+	if targets := cleanupTargets(w); len(targets) != 1 || targets[0].id != "cleanup-verify-pods" {
+		t.Errorf("Expected only the pods to be verified with a target service, got %+v", targets)
+	}
+}
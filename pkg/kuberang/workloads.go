@@ -0,0 +1,136 @@
+package kuberang
+
+import (
+	"fmt"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+// workloads names the kubernetes objects deployed by a single kuberang run
+type workloads struct {
+	// cfg is the configuration of the run the workloads are deployed by
+	cfg          *config.Config
+	testID       int64
+	registryURL  string
+	bbDeployment string
+	ngDeployment string
+	ngService    string
+	ngConfigMap  string
+	// ngNamedService is the nginx service variant whose targetPort is a
+	// port name, only deployed with the full profile
+	ngNamedService string
+	// ngMultiPortService is the nginx service variant exposing several
+	// ports, only deployed with the full profile
+	ngMultiPortService string
+	// prePull is the DaemonSet pulling the test images, and prePullDeployed
+	// is set once it is created
+	prePull         string
+	prePullDeployed bool
+	probe           string
+	// fromNode is the pod running the node-side checks on the node given
+	// with --from-node, and fromNodeDeployed is set once it is created
+	fromNode         string
+	fromNodeDeployed bool
+	// initPod is the pod of the init container check, and initPodDeployed
+	// is set once it is created
+	initPod         string
+	initPodDeployed bool
+	// livenessPod is the pod of the liveness probe restart check, and
+	// livenessPodDeployed is set once it is created
+	livenessPod         string
+	livenessPodDeployed bool
+	// job is the job of the job completion check, and jobDeployed is set
+	// once it is created
+	job         string
+	jobDeployed bool
+	// cronJob is the cronjob of the cronjob scheduling check, and
+	// cronJobDeployed is set once it is created
+	cronJob         string
+	cronJobDeployed bool
+	// customResource is the custom resource of the CRD check, and
+	// crdDeployed is set once its CustomResourceDefinition is created
+	customResource string
+	crdDeployed    bool
+	// dnsService is the service resolved by the DNS diagnostics
+	dnsService string
+	// namespaceCreated is set once the ephemeral namespace is created
+	namespaceCreated bool
+	// nodes on which the test workloads are expected to run
+	nodes []string
+	// tolerations let the test pods run on the tainted nodes included with
+	// --include-control-plane
+	tolerations []interface{}
+	// probesDeployed is set once the node probe DaemonSet is created, and
+	// probePods holds the name of the probe pod of every node
+	probesDeployed bool
+	probePods      map[string]string
+}
+
+func newWorkloads(cfg *config.Config, testID int64) *workloads {
+	w := &workloads{
+		cfg:            cfg,
+		testID:         testID,
+		bbDeployment:   "kuberang-busybox",
+		ngDeployment:   "kuberang-nginx",
+		ngService:      fmt.Sprintf("kuberang-nginx-%d", testID),
+		prePull:        fmt.Sprintf("kuberang-prepull-%d", testID),
+		probe:          fmt.Sprintf("kuberang-probe-%d", testID),
+		fromNode:       fmt.Sprintf("kuberang-from-node-%d", testID),
+		initPod:        fmt.Sprintf("kuberang-init-%d", testID),
+		livenessPod:    fmt.Sprintf("kuberang-liveness-%d", testID),
+		job:            fmt.Sprintf("kuberang-job-%d", testID),
+		cronJob:        fmt.Sprintf("kuberang-cron-%d", testID),
+		customResource: fmt.Sprintf("kuberang-%d", testID),
+		dnsService:     fmt.Sprintf("kuberang-dns-%d", testID),
+	}
+	if cfg.RegistryURL != "" {
+		w.registryURL = cfg.RegistryURL + "/"
+	}
+	if cfg.Profile == ProfileFull {
+		w.ngNamedService = fmt.Sprintf("kuberang-nginx-named-%d", testID)
+		w.ngMultiPortService = fmt.Sprintf("kuberang-nginx-multiport-%d", testID)
+	}
+	if cfg.IdentifyBackends {
+		w.ngConfigMap = fmt.Sprintf("kuberang-nginx-conf-%d", testID)
+	}
+	return w
+}
+
+// nginxObjects returns the objects deployNginx creates, as kind/name
+func (w *workloads) nginxObjects() []string {
+	objects := []string{"deployment/" + w.ngDeployment, "service/" + w.ngService}
+	if w.ngNamedService != "" {
+		objects = append(objects, "service/"+w.ngNamedService)
+	}
+	if w.ngMultiPortService != "" {
+		objects = append(objects, "service/"+w.ngMultiPortService)
+	}
+	if w.ngConfigMap != "" {
+		objects = append(objects, "configmap/"+w.ngConfigMap)
+	}
+	return objects
+}
+
+// labels returns the labels applied to the objects of the given app
+func (w *workloads) labels(app string) string {
+	return fmt.Sprintf("app=%s,kuberang/testid=%d", app, w.testID)
+}
+
+// image returns the reference of the given image, pulled from the configured
+// registry if any
+func (w *workloads) image(name string) string {
+	return w.registryURL + name
+}
+
+// namespace returns the namespace in which kuberang operates
+func namespace(cfg *config.Config) string {
+	if cfg.Namespace == "" {
+		return "default"
+	}
+	return cfg.Namespace
+}
+
+// serviceFQDN returns the fully qualified domain name of the nginx service
+func (w *workloads) serviceFQDN() string {
+	return fmt.Sprintf("%s.%s.svc.%s", w.ngService, namespace(w.cfg), w.cfg.ClusterDomain)
+}
@@ -0,0 +1,22 @@
+package kuberang
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/apprenda/kuberang/pkg/config"
+)
+
+func TestNginxObjects(t *testing.T) {
+	w := newWorkloads(&config.Config{}, 7)
+	expected := []string{"deployment/kuberang-nginx", "service/kuberang-nginx-7"}
+	if objects := w.nginxObjects(); !reflect.DeepEqual(objects, expected) {
+		t.Errorf("Expected %q, got %q", expected, objects)
+	}
+
+	w = newWorkloads(&config.Config{Profile: ProfileFull, IdentifyBackends: true}, 7)
+	expected = append(expected, "service/kuberang-nginx-named-7", "service/kuberang-nginx-multiport-7", "configmap/kuberang-nginx-conf-7")
+	if objects := w.nginxObjects(); !reflect.DeepEqual(objects, expected) {
+		t.Errorf("Expected %q, got %q", expected, objects)
+	}
+}
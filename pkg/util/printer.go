@@ -1,9 +1,13 @@
 package util
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"os"
+	"strings"
 	"text/tabwriter"
+	"unicode/utf8"
 
 	"github.com/fatih/color"
 )
@@ -18,6 +22,19 @@ const (
 	errIgnoredType  = "[ERROR IGNORED]"
 )
 
+const (
+	// legacyWidth is the column of the status when the width of the
+	// terminal is unknown
+	legacyWidth = 80
+	// maxLineWidth keeps the status close to the message on wide terminals
+	maxLineWidth = legacyWidth + len(errIgnoredType) + 1
+	// minMessageWidth is the narrowest message column worth wrapping into,
+	// narrower terminals get the legacy layout
+	minMessageWidth = 20
+	// continuationIndent prefixes the continuation lines of a wrapped message
+	continuationIndent = "  "
+)
+
 var Green = color.New(color.FgGreen)
 var Red = color.New(color.FgRed)
 var Orange = color.New(color.FgRed, color.FgYellow)
@@ -85,27 +102,26 @@ func PrintSkipped(out io.Writer) {
 
 // PrintHeader will print header with predifined width
 func PrintHeader(out io.Writer, msg string) {
-	w := tabwriter.NewWriter(out, 84, 0, 0, '=', 0)
+	// Laid out in a buffer so that the header is printed with a single write
+	var b bytes.Buffer
+	w := tabwriter.NewWriter(&b, 84, 0, 0, '=', 0)
 	fmt.Fprintln(w, "")
 	format := msg + "\t\n"
 	fmt.Fprintf(w, format)
 	w.Flush()
+	out.Write(b.Bytes())
 }
 
-// PrintColor prints text in color
+// PrintColor prints text in color, leaving the escape sequences out for
+// writers that are not terminals
 func PrintColor(out io.Writer, clr *color.Color, msg string, a ...interface{}) {
 	// Remove any newline, results in only one \n
 	line := fmt.Sprintf("%s", clr.SprintfFunc()(msg, a...))
-	fmt.Fprint(out, line)
+	fmt.Fprint(plainUnlessTerminal(out), line)
 }
 
 func print(out io.Writer, msg, status string, a ...interface{}) {
-	w := tabwriter.NewWriter(out, 80, 0, 0, ' ', 0)
-	// print message
-	format := msg + "\t"
-	fmt.Fprintf(w, format, a...)
-
-	// print status
+	colored := ""
 	if status != noType {
 		// get correct color
 		var clr *color.Color
@@ -119,10 +135,98 @@ func print(out io.Writer, msg, status string, a ...interface{}) {
 		case skippedType:
 			clr = Blue
 		}
+		colored = clr.SprintFunc()(status)
+	}
+	fmt.Fprint(plainUnlessTerminal(out), formatLine(fmt.Sprintf(msg, a...), status, colored, writerWidth(out)))
+}
 
-		sformat := "%s\n"
-		fmt.Fprintf(w, sformat, clr.SprintFunc()(status))
+// writerWidth returns the width of the terminal out prints to, or 0 when out
+// is not a terminal. A Tee is as wide as its first terminal.
+func writerWidth(out io.Writer) int {
+	switch w := out.(type) {
+	case *os.File:
+		return terminalWidth(w)
+	case *SyncWriter:
+		return writerWidth(w.w)
+	case *Buffer:
+		return writerWidth(w.dest)
+	case *Tee:
+		for _, dest := range w.writers {
+			if width := writerWidth(dest); width > 0 {
+				return width
+			}
+		}
+	}
+	return 0
+}
 
+// formatLine lays out a message followed by its status, colored being the
+// status as printed. When the terminal width is known, the message is
+// wrapped to fit and the status is right-aligned at the same column on every
+// line, at most maxLineWidth. Otherwise, and on very narrow terminals, the
+// message is padded to legacyWidth columns and followed by the status.
+func formatLine(msg, status, colored string, width int) string {
+	lineWidth := width - 1
+	if lineWidth > maxLineWidth {
+		lineWidth = maxLineWidth
 	}
-	w.Flush()
+	msgWidth := lineWidth - len(errIgnoredType) - 1
+	if status == noType || msgWidth < minMessageWidth {
+		var b bytes.Buffer
+		w := tabwriter.NewWriter(&b, legacyWidth, 0, 0, ' ', 0)
+		fmt.Fprint(w, msg+"\t")
+		if status != noType {
+			fmt.Fprintf(w, "%s\n", colored)
+		}
+		w.Flush()
+		return b.String()
+	}
+	var b bytes.Buffer
+	for i, line := range wrapMessage(msg, msgWidth) {
+		b.WriteString(line)
+		if i == 0 {
+			b.WriteString(strings.Repeat(" ", lineWidth-utf8.RuneCountInString(line)-len(status)))
+			b.WriteString(colored)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// wrapMessage splits msg into lines of at most width columns, breaking
+// between words when possible. Continuation lines are indented.
+func wrapMessage(msg string, width int) []string {
+	lines := []string{}
+	line := ""
+	for _, word := range strings.Fields(msg) {
+		limit := width
+		if len(lines) > 0 {
+			limit -= len(continuationIndent)
+		}
+		switch {
+		case line == "" && utf8.RuneCountInString(word) <= limit:
+			line = word
+		case line != "" && utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) <= limit:
+			line += " " + word
+		default:
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+				limit = width - len(continuationIndent)
+			}
+			// Split the words that do not fit on a line of their own
+			for utf8.RuneCountInString(word) > limit {
+				runes := []rune(word)
+				lines = append(lines, string(runes[:limit]))
+				word = string(runes[limit:])
+				limit = width - len(continuationIndent)
+			}
+			line = word
+		}
+	}
+	lines = append(lines, line)
+	for i := 1; i < len(lines); i++ {
+		lines[i] = continuationIndent + lines[i]
+	}
+	return lines
 }
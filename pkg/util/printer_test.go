@@ -0,0 +1,57 @@
+package util
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatLineLegacy(t *testing.T) {
+	line := formatLine("Accessed Nginx service", okType, okType, 0)
+	if line != "Accessed Nginx service"+strings.Repeat(" ", 58)+"[OK]\n" {
+		t.Errorf("Unexpected legacy layout %q", line)
+	}
+	long := strings.Repeat("x", 90)
+	if line := formatLine(long, okType, okType, 0); line != long+"[OK]\n" {
+		t.Errorf("Unexpected legacy layout %q", line)
+	}
+	// Too narrow to wrap into
+	if line := formatLine("Accessed Nginx service", okType, okType, 30); !strings.HasPrefix(line, "Accessed Nginx service"+strings.Repeat(" ", 58)) {
+		t.Errorf("Expected the legacy layout on a narrow terminal, got %q", line)
+	}
+}
+
+func TestFormatLineAlignsStatus(t *testing.T) {
+	tests := []struct {
+		msg    string
+		width  int
+		status string
+	}{
+		{"Accessed Nginx service at 10.96.0.12 from BusyBox", 120, okType},
+		{"Accessed Nginx service at 10.96.0.12 from BusyBox", 60, errIgnoredType},
+		{"Accessed Nginx pod at fd00:10:244:3::5 on node worker-3 from BusyBox on node worker-1 (cross-node)", 60, okType},
+		{"Resolved kuberang-nginx-1234.kuberang-namespace-with-a-long-name.svc.cluster.local over TCP", 50, warnType},
+		{strings.Repeat("y", 200), 70, errType},
+	}
+	for _, test := range tests {
+		lineWidth := test.width - 1
+		if lineWidth > maxLineWidth {
+			lineWidth = maxLineWidth
+		}
+		out := formatLine(test.msg, test.status, test.status, test.width)
+		lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+		if !strings.HasSuffix(lines[0], test.status) || len(lines[0]) != lineWidth {
+			t.Errorf("Status not right-aligned at column %d for width %d: %q", lineWidth, test.width, lines[0])
+		}
+		words := []string{strings.TrimSpace(strings.TrimSuffix(lines[0], test.status))}
+		for _, l := range lines[1:] {
+			if len(l) > lineWidth-len(errIgnoredType)-1 || !strings.HasPrefix(l, continuationIndent) {
+				t.Errorf("Unexpected continuation line %q for width %d", l, test.width)
+			}
+			words = append(words, strings.TrimSpace(l))
+		}
+		// Words too long for a line are split, so spaces are not compared
+		if strings.Replace(strings.Join(words, ""), " ", "", -1) != strings.Replace(test.msg, " ", "", -1) {
+			t.Errorf("Message altered by wrapping: %q", words)
+		}
+	}
+}
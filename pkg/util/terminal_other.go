@@ -0,0 +1,12 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package util
+
+import "os"
+
+// terminalWidth returns 0, the width of terminals is not detected on this
+// platform
+func terminalWidth(f *os.File) int {
+	return 0
+}
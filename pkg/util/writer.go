@@ -0,0 +1,117 @@
+package util
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"regexp"
+	"sync"
+)
+
+// ansiSequence matches the escape sequences used to color the output
+var ansiSequence = regexp.MustCompile("\x1b\\[[0-9;]*[A-Za-z]")
+
+// IsTerminal tells whether f is attached to a terminal
+func IsTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// StripANSI removes the escape sequences from s
+func StripANSI(s string) string {
+	return ansiSequence.ReplaceAllString(s, "")
+}
+
+// ansiStripper removes the escape sequences from everything written to w
+type ansiStripper struct {
+	w io.Writer
+}
+
+func (s ansiStripper) Write(p []byte) (int, error) {
+	if _, err := s.w.Write(ansiSequence.ReplaceAll(p, nil)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Tee writes to several destinations, keeping the colors for terminals only.
+// Files, buffers and the other destinations receive clean text.
+type Tee struct {
+	writers []io.Writer
+}
+
+// NewTee returns a Tee writing to every given writer
+func NewTee(writers ...io.Writer) *Tee {
+	return &Tee{writers: writers}
+}
+
+func (t *Tee) Write(p []byte) (int, error) {
+	for _, w := range t.writers {
+		if _, err := plainUnlessTerminal(w).Write(p); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// SyncWriter serializes the writes to a writer shared by concurrent checks.
+// Every line is printed with a single write, so lines never interleave.
+type SyncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewSyncWriter returns a SyncWriter writing to w
+func NewSyncWriter(w io.Writer) *SyncWriter {
+	return &SyncWriter{w: w}
+}
+
+func (s *SyncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return plainUnlessTerminal(s.w).Write(p)
+}
+
+// Buffer holds the output meant for another writer, such as the output of a
+// check running concurrently with others, until Flush writes it out at once.
+// The colors are kept, and left to the destination to remove.
+type Buffer struct {
+	buf  bytes.Buffer
+	dest io.Writer
+}
+
+// NewBuffer returns a Buffer holding the output meant for dest
+func NewBuffer(dest io.Writer) *Buffer {
+	return &Buffer{dest: dest}
+}
+
+func (b *Buffer) Write(p []byte) (int, error) {
+	return b.buf.Write(p)
+}
+
+// Flush writes the buffered output to the destination in a single write
+func (b *Buffer) Flush() error {
+	if b.buf.Len() == 0 {
+		return nil
+	}
+	_, err := plainUnlessTerminal(b.dest).Write(b.buf.Bytes())
+	b.buf.Reset()
+	return err
+}
+
+// plainUnlessTerminal returns out as is when it may receive escape
+// sequences, that is when it is a terminal, a Tee deciding for each of its
+// destinations, or a SyncWriter or Buffer deciding for their destination.
+// Any other writer gets the escape sequences removed, even when colors are
+// enabled.
+func plainUnlessTerminal(out io.Writer) io.Writer {
+	switch w := out.(type) {
+	case *Tee, *SyncWriter, *Buffer, ansiStripper:
+		return out
+	case *os.File:
+		if IsTerminal(w) {
+			return out
+		}
+	}
+	return ansiStripper{w: out}
+}
@@ -0,0 +1,83 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestPrintStripsColorsForNonTerminals(t *testing.T) {
+	defer func(v bool) { color.NoColor = v }(color.NoColor)
+	color.NoColor = false
+
+	var log, report bytes.Buffer
+	PrettyPrintErr(NewTee(&log, &report), "Accessed Nginx service")
+	PrintColor(&report, Red, "1 check failed\n")
+	for _, out := range []string{log.String(), report.String()} {
+		if strings.Contains(out, "\x1b") {
+			t.Errorf("Unexpected escape sequence in %q", out)
+		}
+	}
+	if !strings.Contains(log.String(), "Accessed Nginx service") || !strings.HasSuffix(log.String(), "[ERROR]\n") {
+		t.Errorf("Unexpected output %q", log.String())
+	}
+}
+
+func TestStripANSI(t *testing.T) {
+	if s := StripANSI("\x1b[31m[ERROR]\x1b[0m \x1b[1;33mwarn\x1b[0m"); s != "[ERROR] warn" {
+		t.Errorf("Unexpected stripped text %q", s)
+	}
+}
+
+// bytewiseWriter writes one byte at a time, yielding in between, so that
+// unsynchronized concurrent writes interleave
+type bytewiseWriter struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (w *bytewiseWriter) Write(p []byte) (int, error) {
+	for _, c := range p {
+		w.mu.Lock()
+		w.buf.WriteByte(c)
+		w.mu.Unlock()
+		runtime.Gosched()
+	}
+	return len(p), nil
+}
+
+func TestSyncWriterKeepsLinesWhole(t *testing.T) {
+	dest := &bytewiseWriter{}
+	out := NewSyncWriter(dest)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				PrettyPrintOk(out, "Check %d of goroutine %d", j, i)
+			}
+		}(i)
+	}
+	wg.Wait()
+	lines := strings.Split(strings.TrimSuffix(dest.buf.String(), "\n"), "\n")
+	if len(lines) != 400 {
+		t.Fatalf("Expected 400 lines, got %d", len(lines))
+	}
+	seen := map[string]bool{}
+	for _, line := range lines {
+		var i, j int
+		if _, err := fmt.Sscanf(line, "Check %d of goroutine %d", &j, &i); err != nil || !strings.HasSuffix(line, "[OK]") {
+			t.Fatalf("Interleaved line %q", line)
+		}
+		seen[line] = true
+	}
+	if len(seen) != 400 {
+		t.Errorf("Expected 400 distinct lines, got %d", len(seen))
+	}
+}